@@ -801,6 +801,14 @@ func (b *Book) CompleteEvent(ctx context.Context, event *Event,
 	return b.cfg.Store.CompleteEvent(ctx, event, status, anchorPoint)
 }
 
+// SetEventTransferMetadata stores the transfer metadata the sender attached to
+// the transfer of the address event with the given ID.
+func (b *Book) SetEventTransferMetadata(ctx context.Context, eventID int64,
+	metadata []byte) error {
+
+	return b.cfg.Store.SetEventTransferMetadata(ctx, eventID, metadata)
+}
+
 // RegisterSubscriber adds a new subscriber for receiving events. The
 // deliverExisting boolean indicates whether already existing items should be
 // sent to the NewItemCreated channel when the subscription is started. An
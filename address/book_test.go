@@ -501,6 +501,13 @@ func (m *MockStorage) CompleteEvent(ctx context.Context, event *Event,
 	return args.Error(0)
 }
 
+func (m *MockStorage) SetEventTransferMetadata(ctx context.Context,
+	eventID int64, metadata []byte) error {
+
+	args := m.Called(ctx, eventID, metadata)
+	return args.Error(0)
+}
+
 func (m *MockStorage) InsertAddrs(ctx context.Context,
 	addrs ...AddrWithKeyInfo) error {
 
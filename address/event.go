@@ -129,6 +129,10 @@ type Event struct {
 	// proofs themselves can be large. The proofs can be fetched by the
 	// script keys of the address.
 	HasAllProofs bool
+
+	// TransferMetadata is the optional, JSON encoded metadata the sender
+	// attached to the send fragment of a V2 address transfer.
+	TransferMetadata []byte
 }
 
 // IncomingTransfer is a struct that holds the information about an incoming
@@ -275,4 +279,9 @@ type EventStorage interface {
 	// with the proof and asset that was imported/created for it.
 	CompleteEvent(ctx context.Context, event *Event, status Status,
 		anchorPoint wire.OutPoint) error
+
+	// SetEventTransferMetadata stores the transfer metadata the sender
+	// attached to the transfer of the address event with the given ID.
+	SetEventTransferMetadata(ctx context.Context, eventID int64,
+		metadata []byte) error
 }
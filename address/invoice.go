@@ -0,0 +1,219 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightninglabs/taproot-assets/fn"
+)
+
+const (
+	// MaxInvoiceMemoLength is the maximum length of an invoice memo in
+	// bytes.
+	MaxInvoiceMemoLength = 1024
+)
+
+var (
+	// ErrInvoiceNotFound is returned when an invoice can't be found.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+)
+
+// InvoiceStatus is the status of an asset invoice.
+type InvoiceStatus uint8
+
+const (
+	// InvoiceStatusOpen denotes that no assets were received for the
+	// invoice yet and the invoice hasn't expired.
+	InvoiceStatusOpen InvoiceStatus = 0
+
+	// InvoiceStatusPaid denotes that at least the requested amount was
+	// received for the invoice.
+	InvoiceStatusPaid InvoiceStatus = 1
+
+	// InvoiceStatusUnderpaid denotes that some, but not all of the
+	// requested amount was received for the invoice.
+	InvoiceStatusUnderpaid InvoiceStatus = 2
+
+	// InvoiceStatusExpired denotes that the invoice expired before any
+	// assets were received for it.
+	InvoiceStatusExpired InvoiceStatus = 3
+)
+
+// String returns a human-readable string representation of the status.
+func (s InvoiceStatus) String() string {
+	switch s {
+	case InvoiceStatusOpen:
+		return "open"
+
+	case InvoiceStatusPaid:
+		return "paid"
+
+	case InvoiceStatusUnderpaid:
+		return "underpaid"
+
+	case InvoiceStatusExpired:
+		return "expired"
+
+	default:
+		return fmt.Sprintf("unknown <%d>", s)
+	}
+}
+
+// Invoice is a request to receive a specific amount of an asset through a
+// Taproot Asset address before a given expiry time.
+type Invoice struct {
+	// ID is the database primary key ID of the invoice.
+	ID int64
+
+	// Addr is the Taproot Asset address the invoice is paid to.
+	Addr *AddrWithKeyInfo
+
+	// Amount is the requested asset amount.
+	Amount uint64
+
+	// Memo is an optional description of the invoice.
+	Memo string
+
+	// CreationTime is the time the invoice was created. Only assets
+	// received after this time count towards the invoice.
+	CreationTime time.Time
+
+	// Expiry is the time after which an unpaid invoice expires.
+	Expiry time.Time
+
+	// Status is the current status of the invoice.
+	Status InvoiceStatus
+
+	// AmountReceived is the total asset amount received for the invoice.
+	AmountReceived uint64
+
+	// SettleTime is the time the invoice was fully paid. This is the zero
+	// time if the invoice isn't paid yet.
+	SettleTime time.Time
+}
+
+// NewInvoice creates a new open invoice for the given address. If the address
+// requests a specific amount, the invoice amount must either match it or be
+// zero, in which case the address amount is used.
+func NewInvoice(addr *AddrWithKeyInfo, amount uint64, memo string,
+	creationTime time.Time, expiry time.Duration) (*Invoice, error) {
+
+	if addr == nil || addr.Tap == nil {
+		return nil, fmt.Errorf("invoice address is missing")
+	}
+
+	switch {
+	case amount == 0 && addr.Amount == 0:
+		return nil, fmt.Errorf("invoice amount is required for an " +
+			"address without amount")
+
+	case amount == 0:
+		amount = addr.Amount
+
+	case addr.Amount != 0 && addr.Amount != amount:
+		return nil, fmt.Errorf("invoice amount %d doesn't match "+
+			"address amount %d", amount, addr.Amount)
+	}
+
+	if len(memo) > MaxInvoiceMemoLength {
+		return nil, fmt.Errorf("invoice memo too long, %d bytes, "+
+			"maximum is %d", len(memo), MaxInvoiceMemoLength)
+	}
+
+	if expiry <= 0 {
+		return nil, fmt.Errorf("invoice expiry must be positive")
+	}
+
+	return &Invoice{
+		Addr:         addr,
+		Amount:       amount,
+		Memo:         memo,
+		CreationTime: creationTime,
+		Expiry:       creationTime.Add(expiry),
+		Status:       InvoiceStatusOpen,
+	}, nil
+}
+
+// IsExpired returns true if the invoice's expiry time has passed at the given
+// time.
+func (i *Invoice) IsExpired(now time.Time) bool {
+	return !now.Before(i.Expiry)
+}
+
+// UpdateAmountReceived updates the total amount received for the invoice and
+// transitions its status accordingly. Payments to an underpaid invoice are
+// accepted even after its expiry time, since the assets were received anyway.
+// True is returned if the invoice was modified.
+func (i *Invoice) UpdateAmountReceived(amountReceived uint64,
+	now time.Time) bool {
+
+	if amountReceived == i.AmountReceived {
+		return false
+	}
+
+	i.AmountReceived = amountReceived
+	switch {
+	case amountReceived >= i.Amount:
+		if i.Status != InvoiceStatusPaid {
+			i.SettleTime = now
+		}
+		i.Status = InvoiceStatusPaid
+
+	case amountReceived > 0:
+		i.Status = InvoiceStatusUnderpaid
+	}
+
+	return true
+}
+
+// UpdateExpiry marks an open invoice as expired if its expiry time has passed
+// at the given time. Partially paid invoices stay underpaid, as the received
+// assets need to be dealt with by the merchant. True is returned if the
+// invoice was modified.
+func (i *Invoice) UpdateExpiry(now time.Time) bool {
+	if i.Status != InvoiceStatusOpen || !i.IsExpired(now) {
+		return false
+	}
+
+	i.Status = InvoiceStatusExpired
+
+	return true
+}
+
+// Copy returns a copy of the invoice. The address is not deep copied, as it
+// is never modified.
+func (i *Invoice) Copy() *Invoice {
+	invoiceCopy := *i
+	return &invoiceCopy
+}
+
+// InvoiceQuery is a query for asset invoices.
+type InvoiceQuery struct {
+	// ID is the optional database ID of the invoice to query.
+	ID fn.Option[int64]
+
+	// Status is the optional status the invoices must have.
+	Status fn.Option[InvoiceStatus]
+
+	// AddrTaprootOutputKey is the optional 32-byte x-only serialized
+	// Taproot output key of the address the invoices must be paid to.
+	AddrTaprootOutputKey []byte
+}
+
+// InvoiceStore is the persistent storage of asset invoices.
+type InvoiceStore interface {
+	// InsertInvoice inserts a new invoice and sets its ID. The invoice's
+	// address must already be stored.
+	InsertInvoice(ctx context.Context, invoice *Invoice) error
+
+	// UpdateInvoice updates the status, received amount and settle time
+	// of an existing invoice.
+	UpdateInvoice(ctx context.Context, invoice *Invoice) error
+
+	// QueryInvoices returns all invoices that match the given query,
+	// ordered by their ID.
+	QueryInvoices(ctx context.Context, query InvoiceQuery) ([]*Invoice,
+		error)
+}
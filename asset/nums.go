@@ -0,0 +1,72 @@
+package asset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// NUMSKeyTag is the BIP-340 tag used when deriving application specific NUMS
+// (nothing up my sleeve) keys.
+var NUMSKeyTag = []byte("taproot-assets/nums")
+
+// DeriveNUMSKey derives a provably unspendable public key for the given
+// application domain. The key is derived with a try-and-increment approach:
+//
+//	candidate_i = h_tapnums(domain || i)
+//
+// where i is a big-endian 32-bit counter, and the first candidate that is a
+// valid x coordinate of a point on the curve (with even y) is used. Since the
+// key is the output of a hash function, nobody knows its discrete logarithm.
+// Using a distinct domain per application makes sure that NUMS keys of
+// different protocols can't be confused with each other or with the default
+// NUMS key of the Taproot Assets protocol.
+//
+// NOTE: Derived keys are only meant for script path only outputs built by
+// downstream protocols. Tombstones and zero-value split roots must always use
+// NUMSPubKey, since that is the key the protocol uses to identify them as
+// un-spendable (see ScriptKey.IsUnSpendable and Asset.IsUnSpendable).
+func DeriveNUMSKey(domain []byte) (*btcec.PublicKey, error) {
+	if len(domain) == 0 {
+		return nil, fmt.Errorf("NUMS key domain cannot be empty")
+	}
+
+	var counter [4]byte
+	for i := uint32(0); i < math.MaxUint32; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+
+		candidate := chainhash.TaggedHash(
+			NUMSKeyTag, domain, counter[:],
+		)
+
+		// Roughly half of all x coordinates are on the curve, so we'll
+		// only need a couple of iterations on average.
+		key, err := schnorr.ParsePubKey(candidate[:])
+		if err == nil {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to derive NUMS key for domain %x",
+		domain)
+}
+
+// IsNUMSKeyForDomain returns true if the given key is the NUMS key derived
+// for the given application domain. The parity of the given key is ignored, as
+// NUMS keys are only ever used in their x-only form on chain.
+func IsNUMSKeyForDomain(key *btcec.PublicKey, domain []byte) bool {
+	if key == nil {
+		return false
+	}
+
+	numsKey, err := DeriveNUMSKey(domain)
+	if err != nil {
+		return false
+	}
+
+	return numsKey.X().Cmp(key.X()) == 0
+}
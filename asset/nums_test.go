@@ -0,0 +1,42 @@
+package asset
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeriveNUMSKey tests that application specific NUMS keys are derived
+// deterministically and are domain separated.
+func TestDeriveNUMSKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := DeriveNUMSKey(nil)
+	require.ErrorContains(t, err, "domain cannot be empty")
+
+	keyA, err := DeriveNUMSKey([]byte("app-a"))
+	require.NoError(t, err)
+
+	keyA2, err := DeriveNUMSKey([]byte("app-a"))
+	require.NoError(t, err)
+	require.True(t, keyA.IsEqual(keyA2))
+
+	keyB, err := DeriveNUMSKey([]byte("app-b"))
+	require.NoError(t, err)
+	require.False(t, keyA.IsEqual(keyB))
+	require.False(t, keyA.IsEqual(NUMSPubKey))
+
+	// The derivation must never change, otherwise outputs locked to a
+	// derived NUMS key can no longer be re-created.
+	require.Equal(
+		t, "839828f52b7d22d1026a0e6db083a69cb2ab0d16a0b0f9a04298ecd7"+
+			"df1cca35",
+		hex.EncodeToString(schnorr.SerializePubKey(keyA)),
+	)
+
+	require.True(t, IsNUMSKeyForDomain(keyA, []byte("app-a")))
+	require.False(t, IsNUMSKeyForDomain(keyA, []byte("app-b")))
+	require.False(t, IsNUMSKeyForDomain(nil, []byte("app-a")))
+}
@@ -36,6 +36,8 @@ const (
 	proofAtDepthName      = "proof_at_depth"
 	withPrevWitnessesName = "latest_proof"
 	withMetaRevealName    = "meta_reveal"
+
+	trustCheckpointName = "trust_checkpoint"
 )
 
 var verifyProofCommand = cli.Command{
@@ -55,6 +57,12 @@ var verifyProofCommand = cli.Command{
 			Usage: "the path to the proof file on disk; use the " +
 				"dash character (-) to read from stdin instead",
 		},
+		cli.BoolFlag{
+			Name: trustCheckpointName,
+			Usage: "if true, a proof file that doesn't start at " +
+				"the asset's genesis is accepted if its first " +
+				"proof is part of the local universe",
+		},
 	},
 	Action: verifyProof,
 }
@@ -75,7 +83,8 @@ func verifyProof(ctx *cli.Context) error {
 	defer cleanUp()
 
 	resp, err := client.VerifyProof(ctxc, &taprpc.ProofFile{
-		RawProofFile: rawFile,
+		RawProofFile:    rawFile,
+		TrustCheckpoint: ctx.Bool(trustCheckpointName),
 	})
 	if err != nil {
 		return fmt.Errorf("unable to verify proof file: %w", err)
@@ -254,6 +263,8 @@ func verifyOwnershipProof(ctx *cli.Context) error {
 
 const (
 	scriptKeyName = "script_key"
+
+	maxProofsName = "max_proofs"
 )
 
 var exportProofCommand = cli.Command{
@@ -283,6 +294,13 @@ var exportProofCommand = cli.Command{
 				"the raw binary proof to stdout instead of " +
 				"the default JSON format",
 		},
+		cli.UintFlag{
+			Name: maxProofsName,
+			Usage: "(optional) only export the last max_proofs " +
+				"proofs of the file; the receiver must trust " +
+				"the first exported proof, for example " +
+				"because it is part of their universe",
+		},
 	},
 	Action: exportProof,
 }
@@ -311,6 +329,7 @@ func exportProof(ctx *cli.Context) error {
 	resp, err := client.ExportProof(ctxc, &taprpc.ExportProofRequest{
 		AssetId:   assetID,
 		ScriptKey: scriptKeyBytes,
+		MaxProofs: uint32(ctx.Uint(maxProofsName)),
 	})
 	if err != nil {
 		return fmt.Errorf("unable to export proof file: %w", err)
@@ -274,3 +274,20 @@ type TestInputEntry struct {
 	PrevID *asset.TestPrevID `json:"prev_id"`
 	Asset  *asset.TestAsset  `json:"asset"`
 }
+
+// TapCommitmentTestVectors is a collection of test vectors for the creation of
+// Taproot Asset commitments.
+type TapCommitmentTestVectors struct {
+	ValidTestCases []*ValidTapCommitmentTestCase `json:"valid_test_cases"`
+}
+
+// ValidTapCommitmentTestCase is a test case that describes the expected tap
+// leaf and tapscript root of a Taproot Asset commitment over a set of assets.
+type ValidTapCommitmentTestCase struct {
+	Assets           []*asset.TestAsset `json:"assets"`
+	Version          uint8              `json:"version"`
+	TapscriptSibling string             `json:"tapscript_sibling"`
+	ExpectedLeaf     string             `json:"expected_tap_leaf"`
+	ExpectedRoot     string             `json:"expected_tapscript_root"`
+	Comment          string             `json:"comment"`
+}
@@ -2,8 +2,10 @@ package commitment
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/lightninglabs/taproot-assets/asset"
 	"github.com/lightninglabs/taproot-assets/fn"
 	"github.com/lightninglabs/taproot-assets/internal/test"
@@ -13,6 +15,12 @@ import (
 var (
 	// markerV2 is the marker tag for Taproot Assets used in V2 commitments.
 	markerV2 = []byte(taprootAssetsMarkerTag + ":194243")
+
+	tapCommitmentTestVectorName = "tap_commitment_generated.json"
+
+	allTapCommitmentTestVectorFiles = []string{
+		tapCommitmentTestVectorName,
+	}
 )
 
 // randTapCommitment generates a random Taproot commitment for the given
@@ -77,3 +85,191 @@ func TestTaprootAssetsMarkerV1(t *testing.T) {
 		require.Equal(t, byte(TapCommitmentV2), script[32])
 	}
 }
+
+// TestTapCommitmentEncoding tests that the tap leaf and tapscript root of a
+// Taproot Asset commitment are computed correctly and generates the test
+// vectors for other implementations.
+func TestTapCommitmentEncoding(t *testing.T) {
+	t.Parallel()
+
+	groupKey := &asset.GroupKey{
+		GroupPubKey: *test.RandPubKey(t),
+	}
+	normalGenesis := asset.RandGenesis(t, asset.Normal)
+	otherGenesis := asset.RandGenesis(t, asset.Normal)
+	collectibleGenesis := asset.RandGenesis(t, asset.Collectible)
+
+	siblingLeaf := txscript.NewBaseTapLeaf([]byte("not a commitment"))
+	sibling, err := NewPreimageFromLeaf(siblingLeaf)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		assets  func() []*asset.Asset
+		version TapCommitmentVersion
+		sibling *TapscriptPreimage
+	}{{
+		name: "single normal asset",
+		assets: func() []*asset.Asset {
+			return []*asset.Asset{
+				randAsset(t, normalGenesis, nil),
+			}
+		},
+		version: TapCommitmentV2,
+	}, {
+		name: "single collectible asset",
+		assets: func() []*asset.Asset {
+			return []*asset.Asset{
+				randAsset(t, collectibleGenesis, nil),
+			}
+		},
+		version: TapCommitmentV2,
+	}, {
+		name: "multiple assets of same ID",
+		assets: func() []*asset.Asset {
+			return []*asset.Asset{
+				randAsset(t, normalGenesis, nil),
+				randAsset(t, normalGenesis, nil),
+			}
+		},
+		version: TapCommitmentV2,
+	}, {
+		name: "grouped assets of different IDs",
+		assets: func() []*asset.Asset {
+			return []*asset.Asset{
+				randAsset(t, normalGenesis, groupKey),
+				randAsset(t, otherGenesis, groupKey),
+			}
+		},
+		version: TapCommitmentV2,
+	}, {
+		name: "legacy commitment version",
+		assets: func() []*asset.Asset {
+			return []*asset.Asset{
+				randAsset(t, normalGenesis, nil),
+			}
+		},
+		version: TapCommitmentV1,
+	}, {
+		name: "commitment with tapscript sibling",
+		assets: func() []*asset.Asset {
+			return []*asset.Asset{
+				randAsset(t, normalGenesis, nil),
+			}
+		},
+		version: TapCommitmentV2,
+		sibling: sibling,
+	}}
+
+	testVectors := &TapCommitmentTestVectors{}
+	for _, tc := range testCases {
+		assets := tc.assets()
+
+		t.Run(tc.name, func(tt *testing.T) {
+			tapCommitment, err := FromAssets(&tc.version, assets...)
+			require.NoError(tt, err)
+
+			tapLeaf := tapCommitment.TapLeaf()
+			require.True(tt, IsTaprootAssetCommitmentScript(
+				tapLeaf.Script,
+			))
+
+			_, siblingHash, err := MaybeEncodeTapscriptPreimage(
+				tc.sibling,
+			)
+			require.NoError(tt, err)
+			tapscriptRoot := tapCommitment.TapscriptRoot(
+				siblingHash,
+			)
+
+			leafHex := hex.EncodeToString(tapLeaf.Script)
+			rootHex := hex.EncodeToString(tapscriptRoot[:])
+			testAssets := fn.Map(
+				assets, func(a *asset.Asset) *asset.TestAsset {
+					return asset.NewTestFromAsset(tt, a)
+				},
+			)
+			testVectors.ValidTestCases = append(
+				testVectors.ValidTestCases,
+				&ValidTapCommitmentTestCase{
+					Assets:  testAssets,
+					Version: uint8(tc.version),
+					TapscriptSibling: HexTapscriptSibling(
+						tt, tc.sibling,
+					),
+					ExpectedLeaf: leafHex,
+					ExpectedRoot: rootHex,
+					Comment:      tc.name,
+				},
+			)
+		})
+	}
+
+	// Write test vectors to file. This is a no-op if the "gen_test_vectors"
+	// build tag is not set.
+	test.WriteTestVectors(t, tapCommitmentTestVectorName, testVectors)
+}
+
+// TestTapCommitmentBIPTestVectors tests that the BIP test vectors are passing.
+func TestTapCommitmentBIPTestVectors(t *testing.T) {
+	t.Parallel()
+
+	for idx := range allTapCommitmentTestVectorFiles {
+		var (
+			fileName    = allTapCommitmentTestVectorFiles[idx]
+			testVectors = &TapCommitmentTestVectors{}
+		)
+		test.ParseTestVectors(t, fileName, &testVectors)
+		t.Run(fileName, func(tt *testing.T) {
+			tt.Parallel()
+
+			runTapCommitmentBIPTestVector(tt, testVectors)
+		})
+	}
+}
+
+// runTapCommitmentBIPTestVector runs the tests in a single BIP test vector
+// file.
+func runTapCommitmentBIPTestVector(t *testing.T,
+	testVectors *TapCommitmentTestVectors) {
+
+	for _, validCase := range testVectors.ValidTestCases {
+		validCase := validCase
+
+		t.Run(validCase.Comment, func(tt *testing.T) {
+			tt.Parallel()
+
+			assets := fn.Map(
+				validCase.Assets,
+				func(ta *asset.TestAsset) *asset.Asset {
+					return ta.ToAsset(tt)
+				},
+			)
+			version := TapCommitmentVersion(validCase.Version)
+			tapCommitment, err := FromAssets(&version, assets...)
+			require.NoError(tt, err)
+
+			sibling := ParseTapscriptSibling(
+				tt, validCase.TapscriptSibling,
+			)
+			_, siblingHash, err := MaybeEncodeTapscriptPreimage(
+				sibling,
+			)
+			require.NoError(tt, err)
+
+			tapLeaf := tapCommitment.TapLeaf()
+			tapscriptRoot := tapCommitment.TapscriptRoot(
+				siblingHash,
+			)
+
+			require.Equal(
+				tt, validCase.ExpectedLeaf,
+				hex.EncodeToString(tapLeaf.Script),
+			)
+			require.Equal(
+				tt, validCase.ExpectedRoot,
+				hex.EncodeToString(tapscriptRoot[:]),
+			)
+		})
+	}
+}
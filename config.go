@@ -18,11 +18,13 @@ import (
 	"github.com/lightninglabs/taproot-assets/tapfeatures"
 	"github.com/lightninglabs/taproot-assets/tapfreighter"
 	"github.com/lightninglabs/taproot-assets/tapgarden"
+	"github.com/lightninglabs/taproot-assets/tapsend"
 	"github.com/lightninglabs/taproot-assets/universe"
 	"github.com/lightninglabs/taproot-assets/universe/supplycommit"
 	"github.com/lightninglabs/taproot-assets/universe/supplyverifier"
 	"github.com/lightningnetwork/lnd"
 	"github.com/lightningnetwork/lnd/build"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
 	"github.com/lightningnetwork/lnd/signal"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
@@ -171,6 +173,8 @@ type Config struct {
 
 	AssetCustodian *tapgarden.Custodian
 
+	InvoiceManager *tapgarden.InvoiceManager
+
 	ChainBridge tapgarden.ChainBridge
 
 	AddrBook *address.Book
@@ -184,6 +188,19 @@ type Config struct {
 
 	ProofArchive proof.Archiver
 
+	// ProofFileLimits are the resource limits enforced on proof files
+	// imported over RPC.
+	ProofFileLimits proof.FileLimits
+
+	// AnchorOutputAllowList is the optional allow list for the non-asset
+	// outputs of anchor transactions committed over RPC. If nil, any
+	// output script is accepted.
+	AnchorOutputAllowList *tapsend.AnchorOutputAllowList
+
+	// ChangeAddressType is the address type of the change output added by
+	// the wallet when funding PSBTs for asset transfers.
+	ChangeAddressType walletrpc.ChangeAddressType
+
 	AssetWallet tapfreighter.Wallet
 
 	CoinSelect *tapfreighter.CoinSelect
@@ -216,6 +233,30 @@ type Config struct {
 	// universe federation syncer should default to syncing all assets.
 	UniFedSyncAllAssets bool
 
+	// UniverseConnOpts is the set of options used when connecting to a
+	// remote universe server.
+	UniverseConnOpts []UniverseConnOption
+
+	// SendBlocklist is the set of destinations the chain porter refuses to
+	// send assets to.
+	SendBlocklist *tapfreighter.Blocklist
+
+	// DbSnapshotter is an optional service that periodically creates
+	// snapshots of the database and the proof directory.
+	DbSnapshotter *tapdb.Snapshotter
+
+	// InactivitySweeper is an optional service that sweeps assets to
+	// recovery addresses after a period without wallet activity.
+	InactivitySweeper *tapfreighter.InactivitySweeper
+
+	// HtlcWatcher watches the intermediate outputs of two-stage HTLC
+	// claims.
+	HtlcWatcher *tapfreighter.HtlcWatcher
+
+	// IssuanceMonitor is an optional service that cross-checks the issuance
+	// of asset groups against the universe servers of the federation.
+	IssuanceMonitor *universe.IssuanceMonitor
+
 	RfqManager *rfq.Manager
 
 	PriceOracle rfq.PriceOracle
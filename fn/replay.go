@@ -0,0 +1,188 @@
+package fn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrEventsEvicted is returned when a subscriber requests a replay
+	// starting at a sequence number that is no longer held in the replay
+	// buffer.
+	ErrEventsEvicted = errors.New("requested events were evicted from " +
+		"replay buffer")
+)
+
+// SequenceStore persists the sequence number of the next event of a named
+// event stream, so a ReplayEventDistributor can continue the sequence after a
+// restart.
+type SequenceStore interface {
+	// FetchNextSeq returns the sequence number of the next event of the
+	// given stream, or zero if no event was published yet.
+	FetchNextSeq(ctx context.Context, stream string) (uint64, error)
+
+	// StoreNextSeq stores the sequence number of the next event of the
+	// given stream.
+	StoreNextSeq(ctx context.Context, stream string, nextSeq uint64) error
+}
+
+// SequencedEvent is an event that was assigned a monotonically increasing
+// sequence number by a ReplayEventDistributor.
+type SequencedEvent[T any] struct {
+	// Seq is the sequence number of the event.
+	Seq uint64
+
+	// Event is the actual event.
+	Event T
+}
+
+// ReplayEventDistributor is an event distributor that assigns a sequence
+// number to each event and keeps the most recent events in a bounded replay
+// buffer. A subscriber that fell behind or re-connects can register again with
+// the sequence number of the next event it wants to receive, which is one past
+// the last event it processed, and is guaranteed to receive all events from
+// there on, as long as they are still in the buffer. The buffer is only kept
+// in memory, so it is empty after a restart.
+type ReplayEventDistributor[T any] struct {
+	// bufferSize is the maximum number of events kept in the replay
+	// buffer.
+	bufferSize int
+
+	// buffer is the replay buffer, ordered by sequence number.
+	buffer []SequencedEvent[T]
+
+	// nextSeq is the sequence number the next event will be assigned.
+	nextSeq uint64
+
+	// subscribers is a map of components that want to be notified on new
+	// events, keyed by their subscription ID.
+	subscribers map[uint64]*EventReceiver[SequencedEvent[T]]
+
+	// mtx guards all the fields above.
+	mtx sync.Mutex
+}
+
+// NewReplayEventDistributor creates a new replay event distributor that keeps
+// up to bufferSize events for replay. The first event will be assigned the
+// given start sequence number, which allows a caller that persists the
+// sequence numbers of the events it publishes to continue the sequence after a
+// restart.
+func NewReplayEventDistributor[T any](bufferSize int,
+	startSeq uint64) *ReplayEventDistributor[T] {
+
+	if bufferSize <= 0 {
+		bufferSize = DefaultQueueSize
+	}
+
+	return &ReplayEventDistributor[T]{
+		bufferSize:  bufferSize,
+		buffer:      make([]SequencedEvent[T], 0, bufferSize),
+		nextSeq:     startSeq,
+		subscribers: make(map[uint64]*EventReceiver[SequencedEvent[T]]),
+	}
+}
+
+// NextSeq returns the sequence number that will be assigned to the next event.
+func (d *ReplayEventDistributor[T]) NextSeq() uint64 {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	return d.nextSeq
+}
+
+// RegisterSubscriber adds a new subscriber for receiving events. If fromSeq is
+// set, it is the sequence number of the next event the subscriber wants to
+// receive. All buffered events with a sequence number equal to or greater than
+// fromSeq are then delivered to the subscriber before any new events. If the
+// oldest requested event was already evicted from the replay buffer,
+// ErrEventsEvicted is returned and the subscriber is not registered. The
+// subscriber can then decide to re-sync its state from a different source and
+// register again without a replay.
+func (d *ReplayEventDistributor[T]) RegisterSubscriber(
+	subscriber *EventReceiver[SequencedEvent[T]],
+	fromSeq Option[uint64]) error {
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var replay []SequencedEvent[T]
+	if fromSeq.IsSome() {
+		seq := fromSeq.UnwrapOr(0)
+
+		// A sequence number from the future means the subscriber and
+		// the publisher disagree about the sequence, which we can't
+		// recover from.
+		if seq > d.nextSeq {
+			return fmt.Errorf("requested sequence %d is greater "+
+				"than next sequence %d", seq, d.nextSeq)
+		}
+
+		oldestSeq := d.nextSeq - uint64(len(d.buffer))
+		if seq < oldestSeq {
+			return fmt.Errorf("%w: requested sequence %d, oldest "+
+				"buffered sequence %d", ErrEventsEvicted, seq,
+				oldestSeq)
+		}
+
+		replay = d.buffer[seq-oldestSeq:]
+	}
+
+	// We hold the lock while replaying, so no new events can be published
+	// in between the replayed and the live events. The queue of the
+	// receiver is unbounded, so this won't block on a slow consumer.
+	for _, event := range replay {
+		subscriber.NewItemCreated.ChanIn() <- event
+	}
+
+	d.subscribers[subscriber.ID()] = subscriber
+
+	return nil
+}
+
+// RemoveSubscriber removes the given subscriber and also stops it from
+// processing events.
+func (d *ReplayEventDistributor[T]) RemoveSubscriber(
+	subscriber *EventReceiver[SequencedEvent[T]]) error {
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	_, ok := d.subscribers[subscriber.ID()]
+	if !ok {
+		return fmt.Errorf("subscriber with ID %d not found",
+			subscriber.ID())
+	}
+
+	subscriber.Stop()
+	delete(d.subscribers, subscriber.ID())
+
+	return nil
+}
+
+// NotifySubscribers assigns a sequence number to each of the given events, adds
+// them to the replay buffer and sends them to all subscribers.
+func (d *ReplayEventDistributor[T]) NotifySubscribers(events ...T) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for i := range events {
+		event := SequencedEvent[T]{
+			Seq:   d.nextSeq,
+			Event: events[i],
+		}
+		d.nextSeq++
+
+		// Evict the oldest event if the buffer is full.
+		if len(d.buffer) == d.bufferSize {
+			copy(d.buffer, d.buffer[1:])
+			d.buffer = d.buffer[:len(d.buffer)-1]
+		}
+		d.buffer = append(d.buffer, event)
+
+		for id := range d.subscribers {
+			d.subscribers[id].NewItemCreated.ChanIn() <- event
+		}
+	}
+}
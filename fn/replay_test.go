@@ -0,0 +1,74 @@
+package fn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// receiveSeqs reads the given number of events from the receiver and returns
+// their sequence numbers.
+func receiveSeqs(t *testing.T, r *EventReceiver[SequencedEvent[int]],
+	num int) []uint64 {
+
+	seqs := make([]uint64, 0, num)
+	for i := 0; i < num; i++ {
+		select {
+		case event := <-r.NewItemCreated.ChanOut():
+			require.EqualValues(t, event.Seq*10, event.Event)
+			seqs = append(seqs, event.Seq)
+
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for event %d", i)
+		}
+	}
+
+	return seqs
+}
+
+// TestReplayEventDistributor tests that events are assigned sequence numbers
+// and that subscribers can catch up from the replay buffer.
+func TestReplayEventDistributor(t *testing.T) {
+	t.Parallel()
+
+	d := NewReplayEventDistributor[int](3, 5)
+	require.EqualValues(t, 5, d.NextSeq())
+
+	live := NewEventReceiver[SequencedEvent[int]](1)
+	require.NoError(t, d.RegisterSubscriber(live, None[uint64]()))
+
+	// The live subscriber doesn't read anything while the events are
+	// published, but must still receive all of them in order.
+	d.NotifySubscribers(50, 60, 70, 80)
+	require.EqualValues(t, 9, d.NextSeq())
+	require.Equal(t, []uint64{5, 6, 7, 8}, receiveSeqs(t, live, 4))
+
+	// Only the last three events are still buffered, so replaying from
+	// sequence 5 must fail.
+	late := NewEventReceiver[SequencedEvent[int]](1)
+	err := d.RegisterSubscriber(late, Some[uint64](5))
+	require.ErrorIs(t, err, ErrEventsEvicted)
+
+	// A sequence number from the future is rejected as well.
+	err = d.RegisterSubscriber(late, Some[uint64](10))
+	require.ErrorContains(t, err, "greater than next sequence")
+
+	// Replaying from a buffered sequence delivers the buffered events
+	// followed by the live ones.
+	require.NoError(t, d.RegisterSubscriber(late, Some[uint64](7)))
+	d.NotifySubscribers(90)
+	require.Equal(t, []uint64{7, 8, 9}, receiveSeqs(t, late, 3))
+	require.Equal(t, []uint64{9}, receiveSeqs(t, live, 1))
+
+	// Replaying from the next sequence only delivers new events.
+	upToDate := NewEventReceiver[SequencedEvent[int]](1)
+	require.NoError(t, d.RegisterSubscriber(upToDate, Some[uint64](10)))
+	d.NotifySubscribers(100)
+	require.Equal(t, []uint64{10}, receiveSeqs(t, upToDate, 1))
+
+	require.NoError(t, d.RemoveSubscriber(live))
+	require.NoError(t, d.RemoveSubscriber(late))
+	require.NoError(t, d.RemoveSubscriber(upToDate))
+	require.Error(t, d.RemoveSubscriber(live))
+}
@@ -59,10 +59,14 @@ func simpleSyncer(t *testing.T, ctx context.Context, cfg *Config, id int,
 
 	syncer := universe.NewSimpleSyncer(
 		universe.SimpleSyncCfg{
-			LocalDiffEngine:     noopBaseUni{},
-			NewRemoteDiffEngine: tap.NewRpcUniverseDiff,
-			LocalRegistrar:      noopBaseUni{},
-			SyncBatchSize:       512,
+			LocalDiffEngine: noopBaseUni{},
+			NewRemoteDiffEngine: func(addr universe.ServerAddr) (
+				universe.DiffEngine, error) {
+
+				return tap.NewRpcUniverseDiff(addr)
+			},
+			LocalRegistrar: noopBaseUni{},
+			SyncBatchSize:  512,
 		},
 	)
 
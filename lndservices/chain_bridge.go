@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -18,6 +19,8 @@ import (
 	"github.com/lightninglabs/taproot-assets/tapgarden"
 	"github.com/lightningnetwork/lnd/chainntnfs"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -36,8 +39,56 @@ var (
 	// errTxNotfound is an error that is returned when a transaction
 	// couldn't be found in the proof file.
 	errTxNotFound = fmt.Errorf("transaction not found in proof file")
+
+	// blockUnavailableErrs are the (lower case) error messages the chain
+	// backends of lnd return if they don't know about a block or can't
+	// serve it because it was pruned.
+	blockUnavailableErrs = []string{
+		// Returned by btcd and bitcoind for unknown block hashes.
+		"block not found",
+
+		// Returned by bitcoind for blocks that were pruned.
+		"pruned data",
+
+		// Returned by bitcoind and btcd for heights beyond the tip.
+		"block height out of range",
+		"block number out of range",
+	}
 )
 
+// isBlockUnavailableErr returns true if the given error signals that the chain
+// backend either doesn't know about a block or no longer has it available.
+func isBlockUnavailableErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if status.Code(err) == codes.NotFound {
+		return true
+	}
+
+	errStr := strings.ToLower(err.Error())
+	for _, candidate := range blockUnavailableErrs {
+		if strings.Contains(errStr, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blockLookupErr wraps the given block lookup error. Errors that signal that
+// the block is unknown or pruned are additionally marked with
+// proof.ErrBlockUnavailable, all other errors are returned unchanged.
+func blockLookupErr(err error, format string, args ...any) error {
+	if !isBlockUnavailableErr(err) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %s: %w", proof.ErrBlockUnavailable,
+		fmt.Sprintf(format, args...), err)
+}
+
 // cacheableTimestamp is a wrapper around an uint32 that can be used as a value
 // in an LRU cache.
 type cacheableTimestamp uint32
@@ -227,14 +278,22 @@ func (l *LndRpcChainBridge) VerifyBlock(ctx context.Context,
 	// with unset (zero) block heights.
 	if height == 0 {
 		_, err := l.GetBlock(ctx, header.BlockHash())
-		return err
+		if err != nil {
+			return blockLookupErr(
+				err, "block %v", header.BlockHash(),
+			)
+		}
+
+		return nil
 	}
 
 	// Ensure that the block hash matches the hash of the block
-	// found at the given height.
+	// found at the given height. If the backend doesn't know the block
+	// (for example because it is pruned), we report the exact height
+	// that is missing.
 	hash, err := l.GetBlockHash(ctx, int64(height))
 	if err != nil {
-		return err
+		return blockLookupErr(err, "height %d", height)
 	}
 
 	expectedHash := header.BlockHash()
@@ -248,7 +307,13 @@ func (l *LndRpcChainBridge) VerifyBlock(ctx context.Context,
 	// only the corresponding block header and not the entire block if
 	// supported.
 	_, err = l.GetBlockHeader(ctx, header.BlockHash())
-	return err
+	if err != nil {
+		return blockLookupErr(
+			err, "height %d, block %v", height, expectedHash,
+		)
+	}
+
+	return nil
 }
 
 // CurrentHeight return the current height of the main chain.
@@ -0,0 +1,189 @@
+package lndservices
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/proof"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mockChainKit is a mock implementation of the lndclient.ChainKitClient
+// interface that returns the configured errors.
+type mockChainKit struct {
+	lndclient.ChainKitClient
+
+	blockHash    chainhash.Hash
+	blockErr     error
+	blockHashErr error
+	headerErr    error
+}
+
+// GetBlock returns the configured block error.
+func (m *mockChainKit) GetBlock(context.Context,
+	chainhash.Hash) (*wire.MsgBlock, error) {
+
+	if m.blockErr != nil {
+		return nil, m.blockErr
+	}
+
+	return &wire.MsgBlock{}, nil
+}
+
+// GetBlockHeader returns the configured header error.
+func (m *mockChainKit) GetBlockHeader(context.Context,
+	chainhash.Hash) (*wire.BlockHeader, error) {
+
+	if m.headerErr != nil {
+		return nil, m.headerErr
+	}
+
+	return &wire.BlockHeader{}, nil
+}
+
+// GetBlockHash returns the configured block hash or error.
+func (m *mockChainKit) GetBlockHash(context.Context,
+	int64) (chainhash.Hash, error) {
+
+	return m.blockHash, m.blockHashErr
+}
+
+// TestVerifyBlockErrors tests that only errors signaling an unknown or pruned
+// block are reported as proof.ErrBlockUnavailable by VerifyBlock.
+func TestVerifyBlockErrors(t *testing.T) {
+	t.Parallel()
+
+	header := wire.BlockHeader{Nonce: 1234}
+	errConnection := errors.New("connection refused")
+	errPruned := errors.New("Block not available (pruned data)")
+	errNotFound := errors.New("Block not found")
+	errOutOfRange := errors.New("Block height out of range")
+	errGrpcNotFound := status.Error(codes.NotFound, "no such block")
+
+	testCases := []struct {
+		name        string
+		height      uint32
+		chainKit    *mockChainKit
+		unavailable bool
+		expectedErr error
+		errContains string
+	}{{
+		name:   "valid block",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHash: header.BlockHash(),
+		},
+	}, {
+		name:     "valid block without height",
+		chainKit: &mockChainKit{},
+	}, {
+		name: "pruned block without height",
+		chainKit: &mockChainKit{
+			blockErr: errPruned,
+		},
+		unavailable: true,
+		expectedErr: errPruned,
+	}, {
+		name: "connection error without height",
+		chainKit: &mockChainKit{
+			blockErr: errConnection,
+		},
+		expectedErr: errConnection,
+	}, {
+		name:   "height out of range",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHashErr: errOutOfRange,
+		},
+		unavailable: true,
+		expectedErr: errOutOfRange,
+	}, {
+		name:   "grpc not found",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHashErr: errGrpcNotFound,
+		},
+		unavailable: true,
+	}, {
+		name:   "connection error for block hash",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHashErr: errConnection,
+		},
+		expectedErr: errConnection,
+	}, {
+		name:   "header not found",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHash: header.BlockHash(),
+			headerErr: errNotFound,
+		},
+		unavailable: true,
+		expectedErr: errNotFound,
+	}, {
+		name:   "connection error for header",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHash: header.BlockHash(),
+			headerErr: errConnection,
+		},
+		expectedErr: errConnection,
+	}, {
+		name:   "hash mismatch",
+		height: 100,
+		chainKit: &mockChainKit{
+			blockHash: chainhash.Hash{1},
+		},
+		errContains: "mismatch",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bridge := &LndRpcChainBridge{
+				lnd: &lndclient.LndServices{
+					ChainKit: tc.chainKit,
+				},
+				retryConfig: fn.RetryConfig{},
+			}
+
+			err := bridge.VerifyBlock(
+				context.Background(), header, tc.height,
+			)
+
+			if tc.expectedErr == nil && tc.errContains == "" &&
+				!tc.unavailable {
+
+				require.NoError(t, err)
+				return
+			}
+
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			}
+			if tc.errContains != "" {
+				require.ErrorContains(t, err, tc.errContains)
+			}
+
+			// Only unknown or pruned blocks should be reported as
+			// unavailable.
+			if tc.unavailable {
+				require.ErrorIs(
+					t, err, proof.ErrBlockUnavailable,
+				)
+			} else {
+				require.NotErrorIs(
+					t, err, proof.ErrBlockUnavailable,
+				)
+			}
+		})
+	}
+}
@@ -61,6 +61,23 @@ func (l *LndRpcKeyRing) DeriveNextTaprootAssetKey(
 	return *keyDesc, nil
 }
 
+// DeriveKey derives the key at the given key locator. This can be used to
+// re-derive the public key of a previously issued key descriptor.
+func (l *LndRpcKeyRing) DeriveKey(ctx context.Context,
+	keyLoc keychain.KeyLocator) (keychain.KeyDescriptor, error) {
+
+	log.Tracef("Deriving key for fam_family=%v, index=%v", keyLoc.Family,
+		keyLoc.Index)
+
+	keyDesc, err := l.lnd.WalletKit.DeriveKey(ctx, &keyLoc)
+	if err != nil {
+		return keychain.KeyDescriptor{}, fmt.Errorf("unable to "+
+			"derive key: %w", err)
+	}
+
+	return *keyDesc, nil
+}
+
 // IsLocalKey returns true if the key is under the control of the wallet
 // and can be derived by it.
 func (l *LndRpcKeyRing) IsLocalKey(ctx context.Context,
@@ -82,7 +99,7 @@ func (l *LndRpcKeyRing) IsLocalKey(ctx context.Context,
 
 	// Since we have a non-zero family or index, we should ask the lnd we
 	// are connected to, if it knows the key.
-	derived, err := l.lnd.WalletKit.DeriveKey(ctx, &desc.KeyLocator)
+	derived, err := l.DeriveKey(ctx, desc.KeyLocator)
 	if err != nil {
 		return false
 	}
@@ -38,6 +38,7 @@ type LndRpcWalletAnchor struct {
 // WalletAnchorConfig is a configuration for the wallet anchor.
 type WalletAnchorConfig struct {
 	psbtMaxFeeRatio float64
+	changeType      walletrpc.ChangeAddressType
 }
 
 // defaultWalletAnchorConfig returns the default configuration for the wallet
@@ -45,6 +46,7 @@ type WalletAnchorConfig struct {
 func defaultWalletAnchorConfig() *WalletAnchorConfig {
 	return &WalletAnchorConfig{
 		psbtMaxFeeRatio: DefaultPsbtMaxFeeRatio,
+		changeType:      defaultChangeType,
 	}
 }
 
@@ -60,6 +62,17 @@ func WithPsbtMaxFeeRatio(val float64) WalletAnchorOption {
 	}
 }
 
+// WithChangeAddressType is an optional argument that sets the address type of
+// the change output lnd adds when funding a PSBT. Taproot change is used by
+// default, as it keeps anchor transactions uniform and cheaper.
+func WithChangeAddressType(
+	changeType walletrpc.ChangeAddressType) WalletAnchorOption {
+
+	return func(cfg *WalletAnchorConfig) {
+		cfg.changeType = changeType
+	}
+}
+
 // NewLndRpcWalletAnchor returns a new wallet anchor instance using the passed
 // lnd node.
 func NewLndRpcWalletAnchor(lnd *lndclient.LndServices,
@@ -125,7 +138,7 @@ func (l *LndRpcWalletAnchor) FundPsbt(ctx context.Context, packet *psbt.Packet,
 				SatPerKw: uint64(feeRate),
 			},
 			MinConfs:    int32(minConfs),
-			ChangeType:  defaultChangeType,
+			ChangeType:  l.cfg.changeType,
 			MaxFeeRatio: l.cfg.psbtMaxFeeRatio,
 		},
 	)
@@ -259,6 +272,13 @@ func (l *LndRpcWalletAnchor) ListTransactions(ctx context.Context, startHeight,
 	)
 }
 
+// GetTransaction returns the wallet transaction with the given hash.
+func (l *LndRpcWalletAnchor) GetTransaction(ctx context.Context,
+	txid chainhash.Hash) (lndclient.Transaction, error) {
+
+	return l.lnd.WalletKit.GetTransaction(ctx, txid)
+}
+
 // ListChannels returns the list of active channels of the backing lnd node.
 func (l *LndRpcWalletAnchor) ListChannels(
 	ctx context.Context) ([]lndclient.ChannelInfo, error) {
@@ -3,8 +3,10 @@ package proof
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/sha512"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/url"
 	"sync"
@@ -102,6 +104,15 @@ type CourierCfg struct {
 	// LocalArchive is an archive that can be used to fetch proofs from the
 	// local archive.
 	LocalArchive Archiver
+
+	// FileLimits are the resource limits enforced on proof files received
+	// through any of the couriers.
+	FileLimits FileLimits
+
+	// FaultInjection is an optional config for injecting faults into all
+	// couriers created by the dispatcher. This is only meant to be used in
+	// tests.
+	FaultInjection *FaultInjectionCfg
 }
 
 // CourierConnStatus is an enum that represents the different states a courier
@@ -194,16 +205,41 @@ func NewCourierDispatch(cfg *CourierCfg) *URLDispatch {
 }
 
 // NewCourier instantiates a new courier service handle given a service URL
-// address.
+// address. If fault injection is configured, the courier is wrapped
+// accordingly.
 func (u *URLDispatch) NewCourier(ctx context.Context, addr *url.URL,
 	lazyConnect bool) (Courier, error) {
+
+	courier, err := u.newCourier(ctx, addr, lazyConnect)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.cfg.FaultInjection == nil {
+		return courier, nil
+	}
+
+	return NewFaultInjectingCourier(courier, u.cfg.FaultInjection)
+}
+
+// newCourier instantiates a new courier service handle given a service URL
+// address.
+func (u *URLDispatch) newCourier(ctx context.Context, addr *url.URL,
+	lazyConnect bool) (Courier, error) {
+
 	// Create new courier addr based on URL scheme.
 	switch addr.Scheme {
 	case HashmailCourierType:
-		return NewHashMailCourier(
+		courier, err := NewHashMailCourier(
 			ctx, u.cfg.HashMailCfg, u.cfg.TransferLog, addr,
 			lazyConnect,
 		)
+		if err != nil {
+			return nil, err
+		}
+		courier.fileLimits = u.cfg.FileLimits
+
+		return courier, nil
 
 	// The auth mailbox courier is a universe RPC courier that also
 	// interacts with an auth mailbox service for transferring send
@@ -211,10 +247,16 @@ func (u *URLDispatch) NewCourier(ctx context.Context, addr *url.URL,
 	// universe RPC service, so we instantiate the same courier and just
 	// handle the send fragment fetching differently.
 	case UniverseRpcCourierType, AuthMailboxUniRpcCourierType:
-		return NewUniverseRpcCourier(
+		courier, err := NewUniverseRpcCourier(
 			ctx, u.cfg.UniverseRpcCfg, u.cfg.TransferLog,
 			u.cfg.LocalArchive, addr, lazyConnect,
 		)
+		if err != nil {
+			return nil, err
+		}
+		courier.fileLimits = u.cfg.FileLimits
+
+		return courier, nil
 
 	case MockCourierType:
 		return NewMockProofCourier(), nil
@@ -306,13 +348,50 @@ type HashMailBox struct {
 	client hashmailrpc.HashMailClient
 }
 
+// VerifyPinnedSPKI returns a function that can be used as the
+// VerifyPeerCertificate callback of a TLS config. The callback only accepts
+// the connection if the leaf certificate presented by the peer has a public
+// key that matches one of the given pins. Any intermediate certificates are
+// ignored, as the peer could send an arbitrary certificate along with its own
+// leaf certificate.
+func VerifyPinnedSPKI(pins [][sha256.Size]byte) func([][]byte,
+	[][]*x509.Certificate) error {
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("peer didn't present a certificate")
+		}
+
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return fmt.Errorf("unable to parse peer certificate: %w",
+				err)
+		}
+
+		spkiHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if spkiHash == pin {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("peer certificate doesn't match any " +
+			"pinned public key")
+	}
+}
+
 // serverDialOpts returns the set of server options needed to connect to the
-// server using a TLS connection.
-func serverDialOpts() ([]grpc.DialOption, error) {
+// server using a TLS connection. If any SPKI pins are given, the server's
+// certificate must match one of them.
+func serverDialOpts(pins [][sha256.Size]byte) ([]grpc.DialOption, error) {
 	var opts []grpc.DialOption
 
-	// Skip TLS certificate verification.
+	// Skip TLS certificate verification, unless the server's public key
+	// is pinned.
 	tlsConfig := tls.Config{InsecureSkipVerify: true}
+	if len(pins) > 0 {
+		tlsConfig.VerifyPeerCertificate = VerifyPinnedSPKI(pins)
+	}
 	transportCredentials := credentials.NewTLS(&tlsConfig)
 	opts = append(opts, grpc.WithTransportCredentials(transportCredentials))
 
@@ -323,16 +402,17 @@ func serverDialOpts() ([]grpc.DialOption, error) {
 // address above.
 //
 // NOTE: The TLS certificate path argument (tlsCertPath) is optional. If unset,
-// then the system's TLS trust store is used.
-func NewHashMailBox(ctx context.Context, courierAddr *url.URL) (*HashMailBox,
-	error) {
+// then the system's TLS trust store is used. If any SPKI pins are given, the
+// server must present a certificate that matches one of them.
+func NewHashMailBox(ctx context.Context, courierAddr *url.URL,
+	pins [][sha256.Size]byte) (*HashMailBox, error) {
 
 	if courierAddr.Scheme != HashmailCourierType {
 		return nil, fmt.Errorf("unsupported courier protocol: %v",
 			courierAddr.Scheme)
 	}
 
-	dialOpts, err := serverDialOpts()
+	dialOpts, err := serverDialOpts(pins)
 	if err != nil {
 		return nil, err
 	}
@@ -789,6 +869,11 @@ type HashMailCourierCfg struct {
 	// BackoffCfg configures the behaviour of the proof delivery
 	// functionality.
 	BackoffCfg *BackoffCfg
+
+	// PinnedSPKIs is the set of SHA-256 hashes of the DER encoded
+	// SubjectPublicKeyInfo of the certificates we accept from hashmail
+	// servers. If empty, any certificate is accepted.
+	PinnedSPKIs [][sha256.Size]byte
 }
 
 // HashMailCourier is a hashmail proof courier service handle. It implements the
@@ -812,6 +897,9 @@ type HashMailCourier struct {
 	// with the hashmail server.
 	mailbox ProofMailbox
 
+	// fileLimits are the resource limits enforced on received proof files.
+	fileLimits FileLimits
+
 	// subscribers is a map of components that want to be notified on new
 	// events, keyed by their subscription ID.
 	subscribers map[uint64]*fn.EventReceiver[fn.Event]
@@ -879,7 +967,7 @@ func (h *HashMailCourier) ensureConnect(ctx context.Context) error {
 	}
 
 	// Instantiate a new connection to the mailbox service.
-	mailbox, err := NewHashMailBox(ctx, h.addr)
+	mailbox, err := NewHashMailBox(ctx, h.addr, h.cfg.PinnedSPKIs)
 	if err != nil {
 		return fmt.Errorf("unable to connect to hashmail server: %w",
 			err)
@@ -1157,6 +1245,16 @@ func (h *HashMailCourier) ReceiveProof(ctx context.Context, recipient Recipient,
 		return nil, err
 	}
 
+	// Make sure the proof file we received is within our resource limits
+	// before we acknowledge it and hand it off for verification.
+	if proof.IsFile() {
+		_, err = DecodeFileWithLimits(proof, h.fileLimits)
+		if err != nil {
+			return nil, fmt.Errorf("received invalid proof file: "+
+				"%w", err)
+		}
+	}
+
 	// Now that we've read the proof, we'll create our mailbox (which might
 	// already exist) to send an ACK back to the sender.
 	receiverStreamID := deriveReceiverStreamID(recipient)
@@ -1202,6 +1300,11 @@ type UniverseRpcCourierCfg struct {
 	// a courier service to handle our outgoing request during a connection
 	// attempt, or when delivering or retrieving a proof.
 	ServiceRequestTimeout time.Duration `long:"servicerequestimeout" description:"The maximum duration we'll wait for a courier service to handle our outgoing request during a connection attempt, or when delivering or retrieving a proof."`
+
+	// PinnedSPKIs is the set of SHA-256 hashes of the DER encoded
+	// SubjectPublicKeyInfo of the certificates we accept from courier
+	// services. If empty, any certificate is accepted.
+	PinnedSPKIs [][sha256.Size]byte
 }
 
 // UniverseRpcCourier is a universe RPC proof courier service handle. It
@@ -1233,6 +1336,9 @@ type UniverseRpcCourier struct {
 	// delivery.
 	backoffHandle *BackoffHandler
 
+	// fileLimits are the resource limits enforced on received proof files.
+	fileLimits FileLimits
+
 	// subscribers is a map of components that want to be notified on new
 	// events, keyed by their subscription ID.
 	subscribers map[uint64]*fn.EventReceiver[fn.Event]
@@ -1296,7 +1402,7 @@ func (c *UniverseRpcCourier) ensureConnect(ctx context.Context) error {
 
 	// At this point, we know that the connection is not ready. We'll now
 	// attempt to establish a new connection to the courier service.
-	dialOpts, err := serverDialOpts()
+	dialOpts, err := serverDialOpts(c.cfg.PinnedSPKIs)
 	if err != nil {
 		return err
 	}
@@ -1638,8 +1744,8 @@ func (c *UniverseRpcCourier) ReceiveProof(ctx context.Context,
 		return proofBlob, nil
 	}
 
-	proofFile, err := FetchProofProvenance(
-		ctx, c.localArchive, originLocator, fetchProof,
+	proofFile, err := FetchProofProvenanceWithLimits(
+		ctx, c.localArchive, originLocator, fetchProof, c.fileLimits,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching proof provenance: %w",
@@ -1731,6 +1837,20 @@ func FetchProofProvenance(ctx context.Context, localArchive Archiver,
 	fetchSingleProof func(context.Context, Locator) (Blob, error)) (*File,
 	error) {
 
+	return FetchProofProvenanceWithLimits(
+		ctx, localArchive, originLocator, fetchSingleProof,
+		DefaultFileLimits(),
+	)
+}
+
+// FetchProofProvenanceWithLimits is identical to FetchProofProvenance, but
+// aborts as soon as the provenance is found to exceed the given limits. This
+// avoids fetching an endless chain of proofs from a malicious courier.
+func FetchProofProvenanceWithLimits(ctx context.Context,
+	localArchive Archiver, originLocator Locator,
+	fetchSingleProof func(context.Context, Locator) (Blob, error),
+	limits FileLimits) (*File, error) {
+
 	// In order to reconstruct the proof file we must collect all the
 	// transition proofs that make up the main chain of proofs. That is
 	// accomplished by iterating backwards through the main chain of proofs
@@ -1746,7 +1866,10 @@ func FetchProofProvenance(ctx context.Context, localArchive Archiver,
 	// reversedProofs is a slice of transition proofs ordered from latest to
 	// earliest (the issuance proof comes last in the slice). This ordering
 	// is a reversal of that found in the proof file.
-	var reversedProofs []Blob
+	var (
+		reversedProofs []Blob
+		fetchedBytes   uint64
+	)
 	for {
 		// Before we attempt to fetch the proof from the potentially
 		// remote universe, we'll check our local archive to see if we
@@ -1796,6 +1919,10 @@ func FetchProofProvenance(ctx context.Context, localArchive Archiver,
 				}
 			}
 
+			if err := limits.CheckFile(proofFile); err != nil {
+				return nil, err
+			}
+
 			return proofFile, nil
 		}
 
@@ -1818,6 +1945,17 @@ func FetchProofProvenance(ctx context.Context, localArchive Archiver,
 
 		reversedProofs = append(reversedProofs, proofBlob)
 
+		// Abort early if the chain of proofs is getting too long, so a
+		// malicious courier can't make us fetch proofs forever.
+		fetchedBytes += uint64(len(proofBlob)) + sha256.Size
+		err = limits.checkNumProofs(uint64(len(reversedProofs)))
+		if err != nil {
+			return nil, err
+		}
+		if err := limits.checkDecodeMemory(fetchedBytes); err != nil {
+			return nil, err
+		}
+
 		// Break if we've reached the genesis point (the asset is the
 		// genesis asset).
 		if proofAsset.IsGenesisAsset() {
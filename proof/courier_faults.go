@@ -0,0 +1,179 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/lightninglabs/taproot-assets/fn"
+)
+
+// ErrInjectedFault is the error returned by a fault injecting courier for an
+// injected transient failure.
+var ErrInjectedFault = errors.New("injected proof courier fault")
+
+// FaultInjectionCfg configures the faults a FaultInjectingCourier injects into
+// the proof deliveries and receives of the courier it wraps. This is only
+// meant to be used in tests, to validate the retry and resume logic of the
+// courier users under adverse conditions.
+type FaultInjectionCfg struct {
+	// Latency is an additional delay that is added before each proof
+	// delivery and receive.
+	Latency time.Duration
+
+	// FailureRate is the probability in the range [0, 1] with which a
+	// proof delivery or receive fails with a transient error before it is
+	// forwarded to the wrapped courier.
+	FailureRate float64
+
+	// FailureErr is the error that is returned for an injected failure,
+	// for example a rate limit error of the courier service. If this is
+	// nil, ErrInjectedFault is returned.
+	FailureErr error
+
+	// PartialResponseRate is the probability in the range [0, 1] with
+	// which a received proof is truncated, simulating a partial response
+	// of the courier service.
+	PartialResponseRate float64
+
+	// Rand is the source of randomness used to decide which faults to
+	// inject. If this is nil, a random source is used.
+	Rand *rand.Rand
+
+	// randMtx guards the source of randomness, which isn't safe for
+	// concurrent use and is shared by all couriers using this config.
+	randMtx sync.Mutex
+}
+
+// Validate makes sure the fault injection config is sane.
+func (c *FaultInjectionCfg) Validate() error {
+	if c.Latency < 0 {
+		return fmt.Errorf("latency must not be negative")
+	}
+
+	if c.FailureRate < 0 || c.FailureRate > 1 {
+		return fmt.Errorf("failure rate must be between 0 and 1")
+	}
+
+	if c.PartialResponseRate < 0 || c.PartialResponseRate > 1 {
+		return fmt.Errorf("partial response rate must be between 0 " +
+			"and 1")
+	}
+
+	return nil
+}
+
+// FaultInjectingCourier is a proof courier that wraps another courier and
+// injects latency, transient failures and partial responses into its proof
+// deliveries and receives.
+type FaultInjectingCourier struct {
+	Courier
+
+	cfg *FaultInjectionCfg
+}
+
+// NewFaultInjectingCourier creates a new fault injecting courier that wraps
+// the given courier.
+func NewFaultInjectingCourier(courier Courier,
+	cfg *FaultInjectionCfg) (*FaultInjectingCourier, error) {
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fault injection config: %w",
+			err)
+	}
+
+	return &FaultInjectingCourier{
+		Courier: courier,
+		cfg:     cfg,
+	}, nil
+}
+
+// DeliverProof attempts to deliver a proof to the receiver through the wrapped
+// courier, after injecting the configured latency and failures.
+func (c *FaultInjectingCourier) DeliverProof(ctx context.Context,
+	recipient Recipient, proof *AnnotatedProof,
+	manifest *SendManifest) error {
+
+	if err := c.injectFaults(ctx); err != nil {
+		return err
+	}
+
+	return c.Courier.DeliverProof(ctx, recipient, proof, manifest)
+}
+
+// ReceiveProof attempts to obtain a proof through the wrapped courier, after
+// injecting the configured latency and failures. The received proof might be
+// truncated, depending on the configured partial response rate.
+func (c *FaultInjectingCourier) ReceiveProof(ctx context.Context,
+	recipient Recipient, loc Locator) (*AnnotatedProof, error) {
+
+	if err := c.injectFaults(ctx); err != nil {
+		return nil, err
+	}
+
+	proof, err := c.Courier.ReceiveProof(ctx, recipient, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(proof.Blob) == 0 || !c.chance(c.cfg.PartialResponseRate) {
+		return proof, nil
+	}
+
+	log.Debugf("Injecting partial proof response for script key %x",
+		loc.ScriptKey.SerializeCompressed())
+
+	partialProof := *proof
+	partialProof.Blob = fn.CopySlice(proof.Blob[:len(proof.Blob)/2])
+
+	return &partialProof, nil
+}
+
+// injectFaults waits for the configured latency and then returns an error
+// with the configured failure rate.
+func (c *FaultInjectingCourier) injectFaults(ctx context.Context) error {
+	if c.cfg.Latency > 0 {
+		select {
+		case <-time.After(c.cfg.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if !c.chance(c.cfg.FailureRate) {
+		return nil
+	}
+
+	if c.cfg.FailureErr != nil {
+		return c.cfg.FailureErr
+	}
+
+	return ErrInjectedFault
+}
+
+// chance returns true with the given probability.
+func (c *FaultInjectingCourier) chance(probability float64) bool {
+	switch {
+	case probability <= 0:
+		return false
+
+	case probability >= 1:
+		return true
+	}
+
+	if c.cfg.Rand != nil {
+		c.cfg.randMtx.Lock()
+		defer c.cfg.randMtx.Unlock()
+
+		return c.cfg.Rand.Float64() < probability
+	}
+
+	return rand.Float64() < probability
+}
+
+// A compile-time assertion to ensure that the FaultInjectingCourier meets the
+// Courier interface.
+var _ Courier = (*FaultInjectingCourier)(nil)
@@ -0,0 +1,112 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFaultInjectingCourier tests that the fault injecting courier injects the
+// configured faults into the wrapped courier's deliveries and receives.
+func TestFaultInjectingCourier(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	courierAddr := &url.URL{Scheme: MockCourierType, Host: "localhost:1"}
+	scriptKey := test.RandPubKey(t)
+	proof := &AnnotatedProof{
+		Locator: Locator{
+			ScriptKey: *scriptKey,
+		},
+		Blob:          test.RandBytes(100),
+		AssetSnapshot: &AssetSnapshot{},
+	}
+
+	newCourier := func(cfg *FaultInjectionCfg) Courier {
+		dispatch := NewCourierDispatch(&CourierCfg{
+			FaultInjection: cfg,
+		})
+		courier, err := dispatch.NewCourier(ctx, courierAddr, true)
+		require.NoError(t, err)
+
+		return courier
+	}
+
+	// Without any faults configured, the courier behaves like the wrapped
+	// one, except for the added latency.
+	latency := 20 * time.Millisecond
+	courier := newCourier(&FaultInjectionCfg{
+		Latency: latency,
+	})
+	require.IsType(t, &FaultInjectingCourier{}, courier)
+
+	start := time.Now()
+	require.NoError(t, courier.DeliverProof(ctx, Recipient{}, proof, nil))
+	received, err := courier.ReceiveProof(ctx, Recipient{}, proof.Locator)
+	require.NoError(t, err)
+	require.Equal(t, proof.Blob, received.Blob)
+	require.GreaterOrEqual(t, time.Since(start), 2*latency)
+
+	// The latency is aborted if the context is canceled.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = newCourier(&FaultInjectionCfg{
+		Latency: time.Hour,
+	}).ReceiveProof(cancelCtx, Recipient{}, proof.Locator)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// Failures are injected with the default or a custom error.
+	courier = newCourier(&FaultInjectionCfg{
+		FailureRate: 1,
+	})
+	err = courier.DeliverProof(ctx, Recipient{}, proof, nil)
+	require.ErrorIs(t, err, ErrInjectedFault)
+
+	errRateLimited := errors.New("too many requests")
+	courier = newCourier(&FaultInjectionCfg{
+		FailureRate: 1,
+		FailureErr:  errRateLimited,
+	})
+	_, err = courier.ReceiveProof(ctx, Recipient{}, proof.Locator)
+	require.ErrorIs(t, err, errRateLimited)
+
+	// Partial responses truncate the received proof.
+	courier = newCourier(&FaultInjectionCfg{
+		PartialResponseRate: 1,
+	})
+	require.NoError(t, courier.DeliverProof(ctx, Recipient{}, proof, nil))
+	received, err = courier.ReceiveProof(ctx, Recipient{}, proof.Locator)
+	require.NoError(t, err)
+	require.Equal(t, proof.Blob[:len(proof.Blob)/2], received.Blob)
+
+	// With a seeded source of randomness, only some of the requests fail.
+	courier = newCourier(&FaultInjectionCfg{
+		FailureRate: 0.5,
+		Rand:        rand.New(rand.NewPCG(1, 2)),
+	})
+	var numFailures int
+	for i := 0; i < 100; i++ {
+		err := courier.DeliverProof(ctx, Recipient{}, proof, nil)
+		if err != nil {
+			require.ErrorIs(t, err, ErrInjectedFault)
+			numFailures++
+		}
+	}
+	require.Greater(t, numFailures, 0)
+	require.Less(t, numFailures, 100)
+
+	// Invalid configs are rejected.
+	dispatch := NewCourierDispatch(&CourierCfg{
+		FaultInjection: &FaultInjectionCfg{
+			FailureRate: 2,
+		},
+	})
+	_, err = dispatch.NewCourier(ctx, courierAddr, true)
+	require.ErrorContains(t, err, "failure rate")
+}
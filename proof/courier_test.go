@@ -3,9 +3,16 @@ package proof
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"fmt"
+	"math/big"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/lightninglabs/taproot-assets/asset"
 	"github.com/lightninglabs/taproot-assets/fn"
@@ -146,3 +153,58 @@ func TestCheckUniverseRpcCourierConnection(t *testing.T) {
 		})
 	}
 }
+
+// newTestCert creates a self-signed DER encoded certificate and returns it
+// together with the SHA-256 hash of its SubjectPublicKeyInfo.
+func newTestCert(t *testing.T) ([]byte, [sha256.Size]byte) {
+	t.Helper()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(
+		rand.Reader, template, template, &privKey.PublicKey, privKey,
+	)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(certBytes)
+	require.NoError(t, err)
+
+	return certBytes, sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+}
+
+// TestVerifyPinnedSPKI tests that only the leaf certificate presented by a
+// peer is checked against the pinned public keys.
+func TestVerifyPinnedSPKI(t *testing.T) {
+	t.Parallel()
+
+	pinnedCert, pin := newTestCert(t)
+	otherCert, _ := newTestCert(t)
+
+	verify := VerifyPinnedSPKI([][sha256.Size]byte{pin})
+
+	// The pinned leaf certificate is accepted.
+	require.NoError(t, verify([][]byte{pinnedCert}, nil))
+	require.NoError(t, verify([][]byte{pinnedCert, otherCert}, nil))
+
+	// A leaf certificate with a different public key is rejected.
+	require.ErrorContains(
+		t, verify([][]byte{otherCert}, nil), "doesn't match any",
+	)
+
+	// A different leaf certificate is also rejected if the pinned
+	// certificate is sent along with it, as the peer doesn't own the
+	// pinned key.
+	require.ErrorContains(
+		t, verify([][]byte{otherCert, pinnedCert}, nil),
+		"doesn't match any",
+	)
+
+	// A peer without a certificate is rejected.
+	require.ErrorContains(t, verify(nil, nil), "didn't present")
+}
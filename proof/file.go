@@ -174,6 +174,12 @@ func (f *File) Encode(w io.Writer) error {
 
 // Decode decodes a proof file from `r`.
 func (f *File) Decode(r io.Reader) error {
+	return f.DecodeWithLimits(r, DefaultFileLimits())
+}
+
+// DecodeWithLimits decodes a proof file from `r`, aborting as soon as the
+// file is found to exceed the given limits.
+func (f *File) DecodeWithLimits(r io.Reader, limits FileLimits) error {
 	var prefixMagicBytes [PrefixMagicBytesLength]byte
 	num, err := r.Read(prefixMagicBytes[:])
 	if err != nil {
@@ -208,8 +214,14 @@ func (f *File) Decode(r io.Reader) error {
 		return fmt.Errorf("%w: too many proofs in file",
 			ErrProofFileInvalid)
 	}
+	if err := limits.checkNumProofs(numProofs); err != nil {
+		return err
+	}
 
-	var prevHash, currentHash, proofHash [sha256.Size]byte
+	var (
+		prevHash, currentHash, proofHash [sha256.Size]byte
+		decodedBytes                     uint64
+	)
 	f.proofs = make([]*hashedProof, numProofs)
 	for i := uint64(0); i < numProofs; i++ {
 		// We need to find out how many bytes we expect for the proof,
@@ -227,6 +239,13 @@ func (f *File) Decode(r io.Reader) error {
 				ErrProofFileInvalid)
 		}
 
+		// Before allocating the memory for the proof, we make sure the
+		// total amount of decoded data stays within the limits.
+		decodedBytes += numProofBytes + sha256.Size
+		if err := limits.checkDecodeMemory(decodedBytes); err != nil {
+			return err
+		}
+
 		// Read all bytes that belong to the proof. We don't decode the
 		// proof itself as we usually only need the last proof anyway.
 		proofBytes := make([]byte, numProofBytes)
@@ -369,6 +388,39 @@ func (f *File) RawLastProof() ([]byte, error) {
 	return f.RawProofAt(uint32(len(f.proofs)) - 1)
 }
 
+// Suffix returns a new proof file that only contains the last numProofs proofs
+// of this file, with a freshly computed hash chain. Since the first proof of
+// the suffix is usually not a genesis proof, the resulting file can't be
+// verified with Verify. Instead, the receiver must opt into trusting the first
+// proof as a checkpoint and use VerifyFromCheckpoint. If numProofs is equal to
+// or greater than the number of proofs in the file, a copy of the full file is
+// returned.
+func (f *File) Suffix(numProofs uint32) (*File, error) {
+	if err := f.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if numProofs == 0 {
+		return nil, fmt.Errorf("proof suffix must contain at least " +
+			"one proof")
+	}
+
+	start := 0
+	if int(numProofs) < len(f.proofs) {
+		start = len(f.proofs) - int(numProofs)
+	}
+
+	suffix := NewEmptyFile(f.Version)
+	for idx := start; idx < len(f.proofs); idx++ {
+		err := suffix.AppendProofRaw(f.proofs[idx].proofBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return suffix, nil
+}
+
 // AppendProof appends a proof to the file and calculates its chained hash.
 func (f *File) AppendProof(proof Proof) error {
 	var prevHash [sha256.Size]byte
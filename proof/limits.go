@@ -0,0 +1,146 @@
+package proof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// ErrFileLimitExceeded is returned when a proof file exceeds one of the
+	// configured resource limits.
+	ErrFileLimitExceeded = errors.New("proof file exceeds limit")
+)
+
+// FileLimits are the resource limits enforced when decoding or fetching a
+// proof file from an untrusted source. They can be used to protect resource
+// constrained devices against maliciously long provenance chains. A zero
+// value for any of the limits means the protocol maximum is used.
+type FileLimits struct {
+	// MaxNumProofs is the maximum number of proofs within a single proof
+	// file.
+	MaxNumProofs uint64
+
+	// MaxFileSize is the maximum size of an encoded proof file in bytes.
+	MaxFileSize uint64
+
+	// MaxDecodeMemory is the maximum number of bytes allocated for the
+	// proofs of a file while decoding it.
+	MaxDecodeMemory uint64
+}
+
+// DefaultFileLimits returns the protocol maximum limits of a proof file.
+func DefaultFileLimits() FileLimits {
+	return FileLimits{
+		MaxNumProofs:    FileMaxNumProofs,
+		MaxFileSize:     FileMaxSizeBytes,
+		MaxDecodeMemory: FileMaxSizeBytes,
+	}
+}
+
+// withDefaults returns a copy of the limits with all zero or out of range
+// values replaced by the protocol maximum.
+func (l FileLimits) withDefaults() FileLimits {
+	defaults := DefaultFileLimits()
+	if l.MaxNumProofs == 0 || l.MaxNumProofs > defaults.MaxNumProofs {
+		l.MaxNumProofs = defaults.MaxNumProofs
+	}
+	if l.MaxFileSize == 0 || l.MaxFileSize > defaults.MaxFileSize {
+		l.MaxFileSize = defaults.MaxFileSize
+	}
+	if l.MaxDecodeMemory == 0 ||
+		l.MaxDecodeMemory > defaults.MaxDecodeMemory {
+
+		l.MaxDecodeMemory = defaults.MaxDecodeMemory
+	}
+
+	return l
+}
+
+// checkNumProofs returns an error if the given number of proofs exceeds the
+// limit.
+func (l FileLimits) checkNumProofs(numProofs uint64) error {
+	maxNumProofs := l.withDefaults().MaxNumProofs
+	if numProofs > maxNumProofs {
+		return fmt.Errorf("%w: %d proofs, maximum is %d",
+			ErrFileLimitExceeded, numProofs, maxNumProofs)
+	}
+
+	return nil
+}
+
+// checkDecodeMemory returns an error if the given number of decoded bytes
+// exceeds the limit.
+func (l FileLimits) checkDecodeMemory(numBytes uint64) error {
+	maxMemory := l.withDefaults().MaxDecodeMemory
+	if numBytes > maxMemory {
+		return fmt.Errorf("%w: decoding requires more than %d bytes",
+			ErrFileLimitExceeded, maxMemory)
+	}
+
+	return nil
+}
+
+// CheckBlobSize returns an error if the given encoded proof file is larger
+// than the maximum file size.
+func (l FileLimits) CheckBlobSize(blob Blob) error {
+	maxFileSize := l.withDefaults().MaxFileSize
+	if uint64(len(blob)) > maxFileSize {
+		return fmt.Errorf("%w: file size %d bytes, maximum is %d",
+			ErrFileLimitExceeded, len(blob), maxFileSize)
+	}
+
+	return nil
+}
+
+// CheckFile returns an error if the given decoded proof file exceeds any of
+// the limits.
+func (l FileLimits) CheckFile(f *File) error {
+	if err := l.checkNumProofs(uint64(f.NumProofs())); err != nil {
+		return err
+	}
+
+	// The decode memory only accounts for the proofs and their hashes,
+	// while the encoded file also contains the header and a var int
+	// length prefix for each proof.
+	var (
+		numBytes = uint64(0)
+		fileSize = uint64(PrefixMagicBytesLength) + 4 +
+			tlv.VarIntSize(uint64(len(f.proofs)))
+	)
+	for _, p := range f.proofs {
+		proofLen := uint64(len(p.proofBytes))
+		numBytes += proofLen + sha256.Size
+		fileSize += tlv.VarIntSize(proofLen) + proofLen + sha256.Size
+	}
+	if err := l.checkDecodeMemory(numBytes); err != nil {
+		return err
+	}
+
+	maxFileSize := l.withDefaults().MaxFileSize
+	if fileSize > maxFileSize {
+		return fmt.Errorf("%w: file size %d bytes, maximum is %d",
+			ErrFileLimitExceeded, fileSize, maxFileSize)
+	}
+
+	return nil
+}
+
+// DecodeFileWithLimits decodes a proof file from a byte slice, enforcing the
+// given limits before and while decoding.
+func DecodeFileWithLimits(blob Blob, limits FileLimits) (*File, error) {
+	if err := limits.CheckBlobSize(blob); err != nil {
+		return nil, err
+	}
+
+	var f File
+	err := f.DecodeWithLimits(bytes.NewReader(blob), limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
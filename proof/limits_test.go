@@ -0,0 +1,90 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileLimits tests that the configured limits are enforced when decoding
+// a proof file.
+func TestFileLimits(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numProofs = 10
+		proofSize = 100
+	)
+
+	f := NewEmptyFile(V0)
+	for i := 0; i < numProofs; i++ {
+		require.NoError(t, f.AppendProofRaw(test.RandBytes(proofSize)))
+	}
+	blob, err := EncodeFile(f)
+	require.NoError(t, err)
+
+	// With the zero value and the default limits, the file is decoded
+	// just fine.
+	for _, limits := range []FileLimits{{}, DefaultFileLimits()} {
+		decoded, err := DecodeFileWithLimits(blob, limits)
+		require.NoError(t, err)
+		require.Equal(t, numProofs, decoded.NumProofs())
+		require.NoError(t, limits.CheckFile(decoded))
+	}
+
+	// Each of the limits is enforced individually.
+	tooStrict := []FileLimits{
+		{MaxNumProofs: numProofs - 1},
+		{MaxFileSize: uint64(len(blob)) - 1},
+		{MaxDecodeMemory: numProofs * proofSize},
+	}
+	for _, limits := range tooStrict {
+		_, err := DecodeFileWithLimits(blob, limits)
+		require.ErrorIs(t, err, ErrFileLimitExceeded)
+		require.ErrorIs(t, limits.CheckFile(f), ErrFileLimitExceeded)
+	}
+}
+
+// TestFetchProofProvenanceLimits tests that fetching the provenance of a proof
+// is aborted once the chain of proofs exceeds the limits.
+func TestFetchProofProvenanceLimits(t *testing.T) {
+	t.Parallel()
+
+	// We create a proof for a transferred asset, so the provenance fetch
+	// never reaches a genesis proof and would continue forever without a
+	// limit.
+	transferAsset := asset.RandAsset(t, asset.Normal)
+	transferAsset.PrevWitnesses = []asset.Witness{{
+		PrevID: &asset.PrevID{
+			OutPoint:  test.RandOp(t),
+			ID:        transferAsset.ID(),
+			ScriptKey: asset.RandSerializedKey(t),
+		},
+	}}
+	require.False(t, transferAsset.IsGenesisAsset())
+
+	var buf bytes.Buffer
+	buf.Write(PrefixMagicBytes[:])
+	stream, err := tlv.NewStream(AssetLeafRecord(transferAsset))
+	require.NoError(t, err)
+	require.NoError(t, stream.Encode(&buf))
+	proofBlob := buf.Bytes()
+
+	numFetched := 0
+	fetchProof := func(context.Context, Locator) (Blob, error) {
+		numFetched++
+		return proofBlob, nil
+	}
+
+	_, err = FetchProofProvenanceWithLimits(
+		context.Background(), nil, Locator{}, fetchProof,
+		FileLimits{MaxNumProofs: 5},
+	)
+	require.ErrorIs(t, err, ErrFileLimitExceeded)
+	require.Equal(t, 6, numFetched)
+}
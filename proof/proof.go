@@ -15,6 +15,11 @@ import (
 )
 
 var (
+	// ErrBlockUnavailable is an error returned if the chain backend cannot
+	// serve the block a proof is anchored in, for example because it is a
+	// pruned node that no longer has the block.
+	ErrBlockUnavailable = errors.New("block unavailable from chain backend")
+
 	// ErrInvalidTxMerkleProof is an error returned upon verifying an
 	// invalid on-chain transaction merkle proof.
 	ErrInvalidTxMerkleProof = errors.New("invalid transaction merkle proof")
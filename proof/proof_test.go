@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -927,6 +928,92 @@ func TestProofFileVerification(t *testing.T) {
 	require.ErrorIs(t, err, ErrUnknownVersion)
 }
 
+// TestProofFileSuffixVerification tests that a suffix of a proof file can be
+// verified when its first proof is trusted as a checkpoint.
+func TestProofFileSuffixVerification(t *testing.T) {
+	proofHex, err := os.ReadFile(proofFileHexFileName)
+	require.NoError(t, err)
+
+	proofBytes, err := hex.DecodeString(
+		strings.Trim(string(proofHex), "\n"),
+	)
+	require.NoError(t, err)
+
+	f := &File{}
+	err = f.Decode(bytes.NewReader(proofBytes))
+	require.NoError(t, err)
+
+	numProofs := f.NumProofs()
+	require.Greater(t, numProofs, 1)
+
+	ctx := context.Background()
+	fullSnapshot, err := f.Verify(ctx, MockVerifierCtx)
+	require.NoError(t, err)
+
+	_, err = f.Suffix(0)
+	require.ErrorContains(t, err, "at least one proof")
+
+	// Asking for more proofs than available returns the full file.
+	fullSuffix, err := f.Suffix(uint32(numProofs + 1))
+	require.NoError(t, err)
+	require.Equal(t, f, fullSuffix)
+
+	suffix, err := f.Suffix(uint32(numProofs - 1))
+	require.NoError(t, err)
+	require.Equal(t, numProofs-1, suffix.NumProofs())
+
+	// The suffix must survive an encoding round trip.
+	var buf bytes.Buffer
+	require.NoError(t, suffix.Encode(&buf))
+	decodedSuffix := &File{}
+	require.NoError(t, decodedSuffix.Decode(&buf))
+	require.Equal(t, suffix, decodedSuffix)
+
+	lastProof, err := f.RawLastProof()
+	require.NoError(t, err)
+	suffixLastProof, err := suffix.RawLastProof()
+	require.NoError(t, err)
+	require.Equal(t, lastProof, suffixLastProof)
+
+	// The checkpoint verifier is asked about the first proof of the
+	// suffix, which is the second proof of the full file.
+	expectedCheckpoint, err := f.ProofAt(1)
+	require.NoError(t, err)
+
+	errUntrusted := errors.New("untrusted")
+	rejectAll := func(context.Context, *Proof) error {
+		return errUntrusted
+	}
+	_, err = suffix.VerifyFromCheckpoint(ctx, MockVerifierCtx, rejectAll)
+	require.ErrorIs(t, err, errUntrusted)
+
+	acceptAll := func(_ context.Context, p *Proof) error {
+		require.Equal(t, expectedCheckpoint.OutPoint(), p.OutPoint())
+		return nil
+	}
+	snapshot, err := suffix.VerifyFromCheckpoint(
+		ctx, MockVerifierCtx, acceptAll,
+	)
+	require.NoError(t, err)
+	require.Equal(t, fullSnapshot.OutPoint, snapshot.OutPoint)
+	require.Equal(t, fullSnapshot.Asset, snapshot.Asset)
+
+	// An ignored checkpoint is rejected, even if the checkpoint verifier
+	// accepts it.
+	ignoreCtx := MockVerifierCtx
+	ignoreCtx.IgnoreChecker = lfn.Some[IgnoreChecker](
+		newMockIgnoreChecker(false, AssetPoint{
+			OutPoint: expectedCheckpoint.OutPoint(),
+			ID:       expectedCheckpoint.Asset.ID(),
+			ScriptKey: asset.ToSerialized(
+				expectedCheckpoint.Asset.ScriptKey.PubKey,
+			),
+		}),
+	)
+	_, err = suffix.VerifyFromCheckpoint(ctx, ignoreCtx, acceptAll)
+	require.ErrorIs(t, err, ErrProofInvalid)
+}
+
 // TestProofVerification ensures that the proof encoding and decoding works as
 // expected.
 func TestProofVerification(t *testing.T) {
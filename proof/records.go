@@ -78,6 +78,11 @@ const (
 	// SendFragmentTaprootAssetRootType is the TLV type of the send
 	// fragment's Taproot Asset root. This is used to
 	SendFragmentTaprootAssetRootType tlv.Type = 10
+
+	// SendFragmentTransferMetadataType is the TLV type of the send
+	// fragment's optional transfer metadata. The type is odd, so receivers
+	// that don't know it will ignore it.
+	SendFragmentTransferMetadataType tlv.Type = 11
 )
 
 // KnownProofTypes is a set of all known proof TLV types. This set is asserted
@@ -128,6 +133,7 @@ var KnownSendFragmentTypes = fn.NewSet(
 	SendFragmentVersionType, SendFragmentBlockHeaderType,
 	SendFragmentBlockHeightType, SendFragmentOutPointType,
 	SendFragmentOutputsType, SendFragmentTaprootAssetRootType,
+	SendFragmentTransferMetadataType,
 )
 
 func VersionRecord(version *TransitionVersion) tlv.Record {
@@ -597,3 +603,9 @@ func FragmentTaprootAssetRootRecord(root *[sha256.Size]byte) tlv.Record {
 		tlv.EBytes32, tlv.DBytes32,
 	)
 }
+
+func FragmentTransferMetadataRecord(metadata *[]byte) tlv.Record {
+	return tlv.MakePrimitiveRecord(
+		SendFragmentTransferMetadataType, metadata,
+	)
+}
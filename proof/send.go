@@ -3,6 +3,7 @@ package proof
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
@@ -21,6 +22,10 @@ const (
 	// different asset tranches, which should not really ever be a limiting
 	// factor in practice.
 	MaxSendFragmentOutputs = 256
+
+	// MaxTransferMetadataSize is the maximum size in bytes of the optional
+	// transfer metadata that can be attached to a send fragment.
+	MaxTransferMetadataSize = 16 * 1024
 )
 
 // SendFragmentVersion is the version of the send fragment.
@@ -88,6 +93,13 @@ type SendFragment struct {
 	// TaprootAssetRoot is the root of the Taproot Asset commitment tree.
 	TaprootAssetRoot [sha256.Size]byte
 
+	// TransferMetadata is optional, structured metadata about the transfer
+	// encoded as a JSON object, for example the originator and beneficiary
+	// information required by travel rule regulations. It is only ever
+	// transmitted to the receiver as part of the encrypted fragment and is
+	// never committed to on chain or in any proof.
+	TransferMetadata []byte
+
 	// UnknownOddTypes is a map of unknown odd types that were encountered
 	// during decoding. This map is used to preserve unknown types that we
 	// don't know of yet, so we can still encode them back when serializing.
@@ -118,6 +130,23 @@ func (f *SendFragment) Validate() error {
 	return nil
 }
 
+// ValidateTransferMetadata makes sure the given transfer metadata is a JSON
+// object that doesn't exceed the maximum size.
+func ValidateTransferMetadata(metadata []byte) error {
+	if len(metadata) > MaxTransferMetadataSize {
+		return fmt.Errorf("transfer metadata too large: %d bytes, "+
+			"maximum is %d", len(metadata), MaxTransferMetadataSize)
+	}
+
+	var jsonObject map[string]any
+	if err := json.Unmarshal(metadata, &jsonObject); err != nil {
+		return fmt.Errorf("transfer metadata must be a JSON object: "+
+			"%w", err)
+	}
+
+	return nil
+}
+
 // EncodeRecords returns the encoding records for the SendFragment.
 func (f *SendFragment) EncodeRecords() []tlv.Record {
 	records := []tlv.Record{
@@ -129,6 +158,13 @@ func (f *SendFragment) EncodeRecords() []tlv.Record {
 		FragmentTaprootAssetRootRecord(&f.TaprootAssetRoot),
 	}
 
+	if len(f.TransferMetadata) > 0 {
+		records = append(
+			records,
+			FragmentTransferMetadataRecord(&f.TransferMetadata),
+		)
+	}
+
 	// Add any unknown odd types that were encountered during decoding.
 	return asset.CombineRecords(records, f.UnknownOddTypes)
 }
@@ -142,6 +178,7 @@ func (f *SendFragment) DecodeRecords() []tlv.Record {
 		FragmentOutPointRecord(&f.OutPoint),
 		FragmentOutputsRecord(&f.Outputs),
 		FragmentTaprootAssetRootRecord(&f.TaprootAssetRoot),
+		FragmentTransferMetadataRecord(&f.TransferMetadata),
 	}
 }
 
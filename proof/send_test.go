@@ -89,3 +89,51 @@ func TestSendFragmentEncodeDecode(t *testing.T) {
 		})
 	}
 }
+
+// TestSendFragmentTransferMetadata tests that the optional transfer metadata
+// of a send fragment is encoded, decoded and validated correctly.
+func TestSendFragmentTransferMetadata(t *testing.T) {
+	t.Parallel()
+
+	fragment := SendFragment{
+		Version: SendFragmentV1,
+		BlockHeader: wire.BlockHeader{
+			Timestamp: time.Unix(1234567890, 0),
+		},
+		Outputs: map[asset.ID]SendOutput{
+			{0x01}: {
+				Amount:    100,
+				ScriptKey: asset.SerializedKey{0x02},
+			},
+		},
+		TransferMetadata: []byte(
+			`{"originator":{"name":"Alice"},` +
+				`"beneficiary":{"name":"Bob"}}`,
+		),
+	}
+	require.NoError(t, fragment.Validate())
+	require.NoError(t, ValidateTransferMetadata(fragment.TransferMetadata))
+
+	var buf bytes.Buffer
+	require.NoError(t, fragment.Encode(&buf))
+
+	decoded, err := DecodeSendFragment(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, fragment.TransferMetadata, decoded.TransferMetadata)
+	require.Empty(t, decoded.UnknownOddTypes)
+
+	// Metadata that isn't a JSON object or is too large is rejected, but
+	// doesn't invalidate the fragment itself.
+	fragment.TransferMetadata = []byte(`["not", "an", "object"]`)
+	require.NoError(t, fragment.Validate())
+	require.ErrorContains(
+		t, ValidateTransferMetadata(fragment.TransferMetadata),
+		"must be a JSON object",
+	)
+
+	fragment.TransferMetadata = make([]byte, MaxTransferMetadataSize+1)
+	require.ErrorContains(
+		t, ValidateTransferMetadata(fragment.TransferMetadata),
+		"too large",
+	)
+}
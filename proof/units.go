@@ -0,0 +1,153 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrAmountOverflow is returned when an amount cannot be represented
+	// in asset base units without overflowing a uint64.
+	ErrAmountOverflow = errors.New("amount overflows uint64 base units")
+
+	// ErrInvalidDisplayAmount is returned when a display amount string
+	// cannot be parsed.
+	ErrInvalidDisplayAmount = errors.New("invalid display amount")
+)
+
+// DecDisplayMultiplier returns the number of asset base units that make up a
+// single display unit for the given decimal display value, which is
+// 10^decDisplay.
+func DecDisplayMultiplier(decDisplay uint32) (uint64, error) {
+	if err := IsValidDecDisplay(decDisplay); err != nil {
+		return 0, err
+	}
+
+	multiplier := uint64(1)
+	for i := uint32(0); i < decDisplay; i++ {
+		multiplier *= 10
+	}
+
+	return multiplier, nil
+}
+
+// DisplayToBaseUnits converts a whole number of display units into asset base
+// units using the given decimal display value. An error is returned if the
+// result would overflow.
+func DisplayToBaseUnits(displayUnits uint64, decDisplay uint32) (uint64,
+	error) {
+
+	multiplier, err := DecDisplayMultiplier(decDisplay)
+	if err != nil {
+		return 0, err
+	}
+
+	if displayUnits > math.MaxUint64/multiplier {
+		return 0, fmt.Errorf("%w: %d display units with decimal "+
+			"display %d", ErrAmountOverflow, displayUnits,
+			decDisplay)
+	}
+
+	return displayUnits * multiplier, nil
+}
+
+// BaseToDisplayUnits splits an amount of asset base units into the whole
+// number of display units and the remaining fractional base units using the
+// given decimal display value.
+func BaseToDisplayUnits(baseUnits uint64, decDisplay uint32) (uint64, uint64,
+	error) {
+
+	multiplier, err := DecDisplayMultiplier(decDisplay)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return baseUnits / multiplier, baseUnits % multiplier, nil
+}
+
+// FormatDisplayAmount formats an amount of asset base units as a decimal
+// string in display units. The fractional part is always padded to the full
+// number of decimal places, so 1500 with a decimal display of 3 is formatted
+// as "1.500".
+func FormatDisplayAmount(baseUnits uint64, decDisplay uint32) (string,
+	error) {
+
+	whole, frac, err := BaseToDisplayUnits(baseUnits, decDisplay)
+	if err != nil {
+		return "", err
+	}
+
+	if decDisplay == 0 {
+		return strconv.FormatUint(whole, 10), nil
+	}
+
+	return fmt.Sprintf("%d.%0*d", whole, int(decDisplay), frac), nil
+}
+
+// ParseDisplayAmount parses a decimal string in display units, for example
+// "1.5", into asset base units using the given decimal display value. An
+// error is returned if the string has more decimal places than the decimal
+// display allows, is not a plain non-negative decimal number or would
+// overflow a uint64 in base units.
+func ParseDisplayAmount(amount string, decDisplay uint32) (uint64, error) {
+	multiplier, err := DecDisplayMultiplier(decDisplay)
+	if err != nil {
+		return 0, err
+	}
+
+	wholeStr, fracStr, hasFrac := strings.Cut(amount, ".")
+	if wholeStr == "" || (hasFrac && fracStr == "") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDisplayAmount, amount)
+	}
+	if !isDecimalDigits(wholeStr) || !isDecimalDigits(fracStr) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDisplayAmount, amount)
+	}
+	if uint32(len(fracStr)) > decDisplay {
+		return 0, fmt.Errorf("%w: %q has more than %d decimal places",
+			ErrInvalidDisplayAmount, amount, decDisplay)
+	}
+
+	whole, err := strconv.ParseUint(wholeStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrAmountOverflow, amount)
+	}
+
+	baseUnits, err := DisplayToBaseUnits(whole, decDisplay)
+	if err != nil {
+		return 0, err
+	}
+
+	if fracStr == "" {
+		return baseUnits, nil
+	}
+
+	// Right-pad the fractional part to the full number of decimal places
+	// so it is expressed in base units. It is always smaller than the
+	// multiplier, so it fits into a uint64.
+	fracStr += strings.Repeat("0", int(decDisplay)-len(fracStr))
+	frac, err := strconv.ParseUint(fracStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDisplayAmount, amount)
+	}
+
+	if frac >= multiplier || baseUnits > math.MaxUint64-frac {
+		return 0, fmt.Errorf("%w: %q", ErrAmountOverflow, amount)
+	}
+
+	return baseUnits + frac, nil
+}
+
+// isDecimalDigits returns true if the given string only consists of the
+// ASCII digits 0-9.
+func isDecimalDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package proof
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDisplayAmountConversion tests the conversion between asset base units
+// and display units.
+func TestDisplayAmountConversion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		baseUnits  uint64
+		decDisplay uint32
+		formatted  string
+	}{{
+		name:       "no decimals",
+		baseUnits:  1234,
+		decDisplay: 0,
+		formatted:  "1234",
+	}, {
+		name:       "fractional amount",
+		baseUnits:  1500,
+		decDisplay: 3,
+		formatted:  "1.500",
+	}, {
+		name:       "only fraction",
+		baseUnits:  5,
+		decDisplay: 6,
+		formatted:  "0.000005",
+	}, {
+		name:       "max amount",
+		baseUnits:  math.MaxUint64,
+		decDisplay: MaxDecDisplay,
+		formatted:  "18446744.073709551615",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, err := FormatDisplayAmount(
+				tc.baseUnits, tc.decDisplay,
+			)
+			require.NoError(t, err)
+			require.Equal(t, tc.formatted, formatted)
+
+			parsed, err := ParseDisplayAmount(
+				formatted, tc.decDisplay,
+			)
+			require.NoError(t, err)
+			require.Equal(t, tc.baseUnits, parsed)
+		})
+	}
+
+	// Amounts with fewer decimal places than the decimal display are
+	// padded correctly.
+	parsed, err := ParseDisplayAmount("1.5", 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 1500, parsed)
+
+	base, err := DisplayToBaseUnits(21, 8)
+	require.NoError(t, err)
+	require.EqualValues(t, 2_100_000_000, base)
+
+	whole, frac, err := BaseToDisplayUnits(2_100_000_001, 8)
+	require.NoError(t, err)
+	require.EqualValues(t, 21, whole)
+	require.EqualValues(t, 1, frac)
+}
+
+// TestDisplayAmountErrors tests that invalid or overflowing display amounts
+// are rejected.
+func TestDisplayAmountErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecDisplayMultiplier(MaxDecDisplay + 1)
+	require.ErrorIs(t, err, ErrDecDisplayTooLarge)
+
+	_, err = DisplayToBaseUnits(math.MaxUint64/10+1, 1)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = ParseDisplayAmount("18446744.073709551616", MaxDecDisplay)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = ParseDisplayAmount("99999999999999999999", 0)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	invalid := []string{
+		"", ".", "1.", ".5", "-1", "+1", "1e3", "1.2.3", " 1", "1,5",
+	}
+	for _, amount := range invalid {
+		_, err := ParseDisplayAmount(amount, 2)
+		require.ErrorIs(t, err, ErrInvalidDisplayAmount, amount)
+	}
+
+	// Too many decimal places would silently lose precision.
+	_, err = ParseDisplayAmount("1.234", 2)
+	require.ErrorIs(t, err, ErrInvalidDisplayAmount)
+}
@@ -62,6 +62,12 @@ type VerifierCtx struct {
 	ChainLookupGen ChainLookupGenerator
 
 	IgnoreChecker lfn.Option[IgnoreChecker]
+
+	// CacheID optionally identifies the verification logic of this
+	// context for a CachingVerifier. Contexts with the same ID must accept
+	// exactly the same proofs, so they can share cached results. Results
+	// of contexts without an ID are never cached.
+	CacheID lfn.Option[string]
 }
 
 // Verifier abstracts away from the task of verifying a proof file blob.
@@ -955,7 +961,7 @@ func (p *Proof) VerifyProofIntegrity(ctx context.Context, vCtx VerifierCtx,
 	err = vCtx.HeaderVerifier(p.BlockHeader, p.BlockHeight)
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate proof block "+
-			"header: %w", err)
+			"header at height %d: %w", p.BlockHeight, err)
 	}
 
 	// Assert that the transaction is in the block via the merkle proof.
@@ -1109,10 +1115,91 @@ func (f *File) Verify(ctx context.Context,
 		return nil, ErrUnknownVersion
 	}
 
+	return f.verifyProofsFrom(ctx, vCtx, nil, 0)
+}
+
+// CheckpointVerifier is a function that makes sure the given proof is a
+// trusted checkpoint, for example by confirming that it is included in a
+// universe the verifier trusts. It must return an error if the proof isn't a
+// trusted checkpoint.
+type CheckpointVerifier func(ctx context.Context, p *Proof) error
+
+// VerifyFromCheckpoint verifies a proof file that doesn't necessarily start at
+// the asset's genesis, such as a file created with Suffix. The first proof of
+// the file is treated as a trusted checkpoint: its integrity is verified and
+// the given checkpoint verifier must accept it, but the state transition that
+// led to it is not verified, as the proofs before it are not part of the file.
+// All following proofs are verified in full.
+func (f *File) VerifyFromCheckpoint(ctx context.Context, vCtx VerifierCtx,
+	checkpointVerifier CheckpointVerifier) (*AssetSnapshot, error) {
+
+	if err := f.IsValid(); err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := f.ProofAt(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkpointVerifier(ctx, checkpoint); err != nil {
+		return nil, fmt.Errorf("checkpoint proof not trusted: %w", err)
+	}
+
+	// The checkpoint isn't verified through Proof.Verify, so we need to
+	// check ourselves that it wasn't marked as ignored.
+	checkpointKey := checkpoint.Asset.ScriptKey.PubKey
+	assetPoint := AssetPoint{
+		OutPoint:  checkpoint.OutPoint(),
+		ID:        checkpoint.Asset.ID(),
+		ScriptKey: asset.ToSerialized(checkpointKey),
+	}
+	ignored, err := lfn.MapOptionZ(
+		vCtx.IgnoreChecker, func(c IgnoreChecker) lfn.Result[bool] {
+			return c.IsIgnored(ctx, assetPoint)
+		},
+	).Unpack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if checkpoint proof "+
+			"is ignored: %w", err)
+	}
+	if ignored {
+		return nil, fmt.Errorf("%w: checkpoint asset_point=%v is "+
+			"ignored", ErrProofInvalid, assetPoint)
+	}
+
+	tapCommitment, err := checkpoint.VerifyProofIntegrity(ctx, vCtx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint proof: %w", err)
+	}
+
+	tapscriptPreimage :=
+		checkpoint.InclusionProof.CommitmentProof.TapSiblingPreimage
+	prev := &AssetSnapshot{
+		Asset:             &checkpoint.Asset,
+		OutPoint:          checkpoint.OutPoint(),
+		AnchorBlockHash:   checkpoint.BlockHeader.BlockHash(),
+		AnchorBlockHeight: checkpoint.BlockHeight,
+		AnchorTx:          &checkpoint.AnchorTx,
+		OutputIndex:       checkpoint.InclusionProof.OutputIndex,
+		InternalKey:       checkpoint.InclusionProof.InternalKey,
+		ScriptRoot:        tapCommitment,
+		TapscriptSibling:  tapscriptPreimage,
+		SplitAsset:        checkpoint.Asset.HasSplitCommitmentWitness(),
+		MetaReveal:        checkpoint.MetaReveal,
+	}
+
+	return f.verifyProofsFrom(ctx, vCtx, prev, 1)
+}
+
+// verifyProofsFrom verifies all proofs of the file starting at the given index,
+// using the given snapshot as the result of the proof before that index.
+func (f *File) verifyProofsFrom(ctx context.Context, vCtx VerifierCtx,
+	prev *AssetSnapshot, startIdx int) (*AssetSnapshot, error) {
+
 	chainLookup := vCtx.ChainLookupGen.GenFileChainLookup(f)
 
-	var prev *AssetSnapshot
-	for idx := range f.proofs {
+	for idx := startIdx; idx < len(f.proofs); idx++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
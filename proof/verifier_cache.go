@@ -0,0 +1,262 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/neutrino/cache/lru"
+	"github.com/lightninglabs/taproot-assets/asset"
+)
+
+const (
+	// DefaultVerifierCacheSize is the default number of verification
+	// results kept by the caching verifier.
+	DefaultVerifierCacheSize = 10_000
+
+	// DefaultVerifierCacheID is the cache ID of the verifier contexts that
+	// verify block headers against the chain backend, use the default
+	// merkle verifier, verify group keys against the local database and
+	// don't have a group anchor verifier.
+	DefaultVerifierCacheID = "default"
+)
+
+// verifierCacheKey is the key of a cached verification result. It commits to
+// the proof file and the cache ID of the verifier context the file was
+// verified with.
+type verifierCacheKey struct {
+	fileHash [sha256.Size]byte
+	ctxID    string
+}
+
+// anchorBlock is the block a proof of a cached proof file was anchored in.
+type anchorBlock struct {
+	header wire.BlockHeader
+	height uint32
+}
+
+// cachedVerdict is a successful verification result of a proof file.
+type cachedVerdict struct {
+	snapshot AssetSnapshot
+
+	// anchors are the blocks all proofs of the file were anchored in.
+	anchors []anchorBlock
+}
+
+// Size returns the size of the cached verdict. Since we scale the cache by
+// the number of items and not the total memory size, we can simply return 1
+// here to count each verdict as 1 item.
+func (c *cachedVerdict) Size() (uint64, error) {
+	return 1, nil
+}
+
+// CachingVerifier is a Verifier that caches successful verification results
+// of another Verifier, keyed by the hash of the proof file and the cache ID of
+// the verifier context. Verifier contexts without a cache ID are passed
+// through to the wrapped Verifier. Before a cached result is reused, the anchor
+// blocks of all proofs that aren't buried by at least the re-org safe depth
+// yet are verified again, so a result is dropped as soon as one of those
+// blocks is re-orged out of the chain.
+//
+// Failed verifications are never cached, as they might be caused by
+// temporary errors, such as an unreachable chain backend.
+type CachingVerifier struct {
+	verifier Verifier
+
+	currentHeight func(context.Context) (uint32, error)
+
+	reOrgSafeDepth uint32
+
+	cache *lru.Cache[verifierCacheKey, *cachedVerdict]
+}
+
+// A compile-time assertion to ensure CachingVerifier implements the Verifier
+// interface.
+var _ Verifier = (*CachingVerifier)(nil)
+
+// NewCachingVerifier creates a new caching verifier that wraps the given
+// verifier. The current height function is used to determine which anchor
+// blocks of a cached result need to be verified again on a cache hit.
+func NewCachingVerifier(verifier Verifier,
+	currentHeight func(context.Context) (uint32, error),
+	reOrgSafeDepth uint32, cacheSize uint64) *CachingVerifier {
+
+	return &CachingVerifier{
+		verifier:       verifier,
+		currentHeight:  currentHeight,
+		reOrgSafeDepth: reOrgSafeDepth,
+		cache: lru.NewCache[verifierCacheKey, *cachedVerdict](
+			cacheSize,
+		),
+	}
+}
+
+// Verify takes the passed serialized proof file, and returns a nil error if
+// the proof file is valid. If the same file was already verified successfully
+// with a verifier context of the same cache ID and none of its anchor blocks
+// were re-orged out since, the cached result is returned.
+func (c *CachingVerifier) Verify(ctx context.Context, blobReader io.Reader,
+	vCtx VerifierCtx) (*AssetSnapshot, error) {
+
+	if vCtx.CacheID.IsNone() {
+		return c.verifier.Verify(ctx, blobReader, vCtx)
+	}
+
+	blob, err := io.ReadAll(blobReader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read proof file: %w", err)
+	}
+
+	key := verifierCacheKey{
+		fileHash: sha256.Sum256(blob),
+		ctxID:    vCtx.CacheID.UnwrapOr(""),
+	}
+
+	verdict, err := c.cache.Get(key)
+	if err == nil {
+		valid, err := c.anchorsValid(ctx, verdict, vCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Proofs can be added to the ignore list at any time, so we
+		// can't rely on the cached result for those checks.
+		if valid {
+			err := checkFileIgnored(ctx, blob, vCtx)
+			if err != nil {
+				c.cache.Delete(key)
+				return nil, err
+			}
+
+			snapshot := verdict.snapshot
+			return &snapshot, nil
+		}
+
+		c.cache.Delete(key)
+	}
+
+	snapshot, err := c.verifier.Verify(ctx, bytes.NewReader(blob), vCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	anchors, err := fileAnchorBlocks(blob)
+	if err != nil {
+		log.Warnf("Unable to cache proof verification result: %v", err)
+		return snapshot, nil
+	}
+
+	_, err = c.cache.Put(key, &cachedVerdict{
+		snapshot: *snapshot,
+		anchors:  anchors,
+	})
+	if err != nil {
+		log.Warnf("Unable to cache proof verification result: %v", err)
+	}
+
+	return snapshot, nil
+}
+
+// anchorsValid returns true if all anchor blocks of the cached verdict that
+// aren't buried by at least the re-org safe depth are still part of the main
+// chain.
+func (c *CachingVerifier) anchorsValid(ctx context.Context,
+	verdict *cachedVerdict, vCtx VerifierCtx) (bool, error) {
+
+	height, err := c.currentHeight(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to fetch current height: %w",
+			err)
+	}
+
+	// Without a header verifier we can't tell whether the anchor blocks
+	// are still part of the chain, so we'll need to verify the file again.
+	if vCtx.HeaderVerifier == nil {
+		return false, nil
+	}
+
+	for _, anchor := range verdict.anchors {
+		if uint64(anchor.height)+uint64(c.reOrgSafeDepth) <
+			uint64(height) {
+
+			continue
+		}
+
+		err := vCtx.HeaderVerifier(anchor.header, anchor.height)
+		if err != nil {
+			log.Debugf("Anchor block %v at height %d of cached "+
+				"proof no longer valid: %v",
+				anchor.header.BlockHash(), anchor.height, err)
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// fileAnchorBlocks returns the anchor blocks of all proofs of the given proof
+// file.
+func fileAnchorBlocks(blob []byte) ([]anchorBlock, error) {
+	f, err := DecodeFile(blob)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse proof: %w", err)
+	}
+
+	anchors := make([]anchorBlock, 0, f.NumProofs())
+	for idx := 0; idx < f.NumProofs(); idx++ {
+		p, err := f.ProofAt(uint32(idx))
+		if err != nil {
+			return nil, err
+		}
+
+		anchors = append(anchors, anchorBlock{
+			header: p.BlockHeader,
+			height: p.BlockHeight,
+		})
+	}
+
+	return anchors, nil
+}
+
+// checkFileIgnored returns ErrProofFileInvalid if any of the proofs of the
+// given proof file is on the ignore list of the verifier context.
+func checkFileIgnored(ctx context.Context, blob []byte,
+	vCtx VerifierCtx) error {
+
+	checker := vCtx.IgnoreChecker.UnwrapOr(nil)
+	if checker == nil {
+		return nil
+	}
+
+	f, err := DecodeFile(blob)
+	if err != nil {
+		return fmt.Errorf("unable to parse proof: %w", err)
+	}
+
+	for idx := 0; idx < f.NumProofs(); idx++ {
+		p, err := f.ProofAt(uint32(idx))
+		if err != nil {
+			return err
+		}
+
+		assetPoint := AssetPoint{
+			OutPoint:  p.OutPoint(),
+			ID:        p.Asset.ID(),
+			ScriptKey: asset.ToSerialized(p.Asset.ScriptKey.PubKey),
+		}
+		ignored, err := checker.IsIgnored(ctx, assetPoint).Unpack()
+		if err != nil {
+			return fmt.Errorf("failed to check if proof file is "+
+				"ignored: %w", err)
+		}
+		if ignored {
+			return ErrProofFileInvalid
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/asset"
+	lfn "github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// countingVerifier is a mock Verifier that counts the number of verifications
+// and returns a configurable error.
+type countingVerifier struct {
+	numCalls int
+	err      error
+}
+
+// Verify counts the call and returns an empty snapshot or the configured
+// error.
+func (c *countingVerifier) Verify(_ context.Context, blobReader io.Reader,
+	_ VerifierCtx) (*AssetSnapshot, error) {
+
+	c.numCalls++
+
+	if _, err := io.ReadAll(blobReader); err != nil {
+		return nil, err
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &AssetSnapshot{AnchorBlockHeight: 123}, nil
+}
+
+// TestCachingVerifier tests that the caching verifier only re-verifies proof
+// files if they're verified with a verifier context of a different or no cache
+// ID, one of their recent anchor blocks was re-orged out or a previous
+// verification failed, and that the ignore checker is consulted on cache hits.
+func TestCachingVerifier(t *testing.T) {
+	t.Parallel()
+
+	proofHex, err := os.ReadFile(proofFileHexFileName)
+	require.NoError(t, err)
+
+	blob, err := hex.DecodeString(strings.Trim(string(proofHex), "\n"))
+	require.NoError(t, err)
+
+	proofFile, err := DecodeFile(blob)
+	require.NoError(t, err)
+	lastProof, err := proofFile.LastProof()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	height := lastProof.BlockHeight
+	currentHeight := func(context.Context) (uint32, error) {
+		return height, nil
+	}
+
+	errReorg := errors.New("block re-orged out")
+	reorged := false
+	vCtx := MockVerifierCtx
+	vCtx.CacheID = lfn.Some("test")
+	vCtx.HeaderVerifier = func(wire.BlockHeader, uint32) error {
+		if reorged {
+			return errReorg
+		}
+
+		return nil
+	}
+
+	inner := &countingVerifier{}
+	verifier := NewCachingVerifier(inner, currentHeight, 6, 10)
+
+	verify := func(vCtx VerifierCtx) (*AssetSnapshot, error) {
+		return verifier.Verify(ctx, bytes.NewReader(blob), vCtx)
+	}
+
+	// The first verification is a cache miss, the second one with the
+	// same verifier context is a hit.
+	snapshot, err := verify(vCtx)
+	require.NoError(t, err)
+	require.EqualValues(t, 123, snapshot.AnchorBlockHeight)
+
+	snapshot, err = verify(vCtx)
+	require.NoError(t, err)
+	require.EqualValues(t, 123, snapshot.AnchorBlockHeight)
+	require.Equal(t, 1, inner.numCalls)
+
+	// A verifier context with a different cache ID doesn't use the cached
+	// result, and one without a cache ID is never cached.
+	otherCtx := vCtx
+	otherCtx.CacheID = lfn.Some("other")
+	_, err = verify(otherCtx)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.numCalls)
+
+	_, err = verify(MockVerifierCtx)
+	require.NoError(t, err)
+	_, err = verify(MockVerifierCtx)
+	require.NoError(t, err)
+	require.Equal(t, 4, inner.numCalls)
+
+	// A different file is verified again.
+	_, err = verifier.Verify(
+		ctx, strings.NewReader("other file"), vCtx,
+	)
+	require.NoError(t, err)
+	require.Equal(t, 5, inner.numCalls)
+
+	// Blocks buried by more than the re-org safe depth aren't checked
+	// again, so the result is still used.
+	reorged = true
+	height += 100
+	_, err = verify(vCtx)
+	require.NoError(t, err)
+	require.Equal(t, 5, inner.numCalls)
+
+	// Once a recent anchor block is re-orged out, the file is verified
+	// again.
+	height -= 100
+	_, err = verify(vCtx)
+	require.NoError(t, err)
+	require.Equal(t, 6, inner.numCalls)
+
+	reorged = false
+	_, err = verify(vCtx)
+	require.NoError(t, err)
+	require.Equal(t, 6, inner.numCalls)
+
+	// A proof on the ignore list is rejected, even on a cache hit.
+	ignoredPoint := AssetPoint{
+		OutPoint:  lastProof.OutPoint(),
+		ID:        lastProof.Asset.ID(),
+		ScriptKey: asset.ToSerialized(lastProof.Asset.ScriptKey.PubKey),
+	}
+	ignoreCtx := vCtx
+	ignoreCtx.IgnoreChecker = lfn.Some[IgnoreChecker](
+		newMockIgnoreChecker(false, ignoredPoint),
+	)
+	_, err = verify(ignoreCtx)
+	require.ErrorIs(t, err, ErrProofFileInvalid)
+	require.Equal(t, 6, inner.numCalls)
+
+	// Failed verifications are never cached.
+	inner.err = errors.New("chain backend unavailable")
+	_, err = verify(vCtx)
+	require.ErrorIs(t, err, inner.err)
+
+	inner.err = nil
+	_, err = verify(vCtx)
+	require.NoError(t, err)
+	require.Equal(t, 8, inner.numCalls)
+
+	_, err = verify(vCtx)
+	require.NoError(t, err)
+	require.Equal(t, 8, inner.numCalls)
+}
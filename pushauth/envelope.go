@@ -0,0 +1,255 @@
+// Package pushauth implements signed envelopes for push based integrations
+// (such as webhooks or an HTTPS proof courier) and a verification helper for
+// the receivers of such pushes. Every envelope carries its audience, a
+// timestamp and a random nonce that are covered by the signature of the
+// sender, which allows receivers to reject messages meant for a different
+// endpoint as well as stale and replayed messages.
+package pushauth
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// Version is the version of the envelope format.
+type Version uint8
+
+const (
+	// V0 is the initial version of the envelope format.
+	V0 Version = 0
+
+	// NonceSize is the size of the random nonce of an envelope in bytes.
+	NonceSize = 32
+
+	// MaxAudienceLen is the maximum length of the audience of an envelope
+	// in bytes.
+	MaxAudienceLen = math.MaxUint16
+)
+
+var (
+	// envelopeTag is the BIP-0340 tag used for the signature digest of an
+	// envelope, so the signature can't be reused in a different context.
+	envelopeTag = []byte("taproot-assets/push-envelope")
+
+	// ErrUnknownVersion is returned when an envelope has an unknown
+	// version.
+	ErrUnknownVersion = errors.New("pushauth: unknown envelope version")
+
+	// ErrInvalidSignature is returned when the signature of an envelope
+	// doesn't match its content and sender key.
+	ErrInvalidSignature = errors.New("pushauth: invalid envelope signature")
+)
+
+// Envelope is a signed container for the payload of a single push message.
+type Envelope struct {
+	// Version is the version of the envelope format.
+	Version Version
+
+	// Timestamp is the time the envelope was created at, with second
+	// precision.
+	Timestamp time.Time
+
+	// Nonce is a random value that is unique for every envelope.
+	Nonce [NonceSize]byte
+
+	// Audience identifies the endpoint the envelope is meant for, for
+	// example the URL of the receiver. Binding it into the signature
+	// prevents an envelope from being replayed to a different receiver.
+	Audience string
+
+	// SenderKey is the public key of the sender that signed the envelope.
+	SenderKey *btcec.PublicKey
+
+	// Payload is the opaque payload of the push message.
+	Payload []byte
+
+	// Signature is the Schnorr signature of the sender over the envelope
+	// digest.
+	Signature *schnorr.Signature
+}
+
+// NewEnvelope creates a new, unsigned envelope for the given payload and
+// audience with a fresh random nonce and the given creation time.
+func NewEnvelope(payload []byte, audience string, senderKey *btcec.PublicKey,
+	now time.Time) (*Envelope, error) {
+
+	if senderKey == nil {
+		return nil, fmt.Errorf("sender key must be set")
+	}
+	if audience == "" {
+		return nil, fmt.Errorf("audience must be set")
+	}
+	if len(audience) > MaxAudienceLen {
+		return nil, fmt.Errorf("audience exceeds %d bytes",
+			MaxAudienceLen)
+	}
+
+	env := &Envelope{
+		Version:   V0,
+		Timestamp: time.Unix(now.Unix(), 0),
+		Audience:  audience,
+		SenderKey: senderKey,
+		Payload:   payload,
+	}
+	if _, err := crand.Read(env.Nonce[:]); err != nil {
+		return nil, fmt.Errorf("unable to create nonce: %w", err)
+	}
+
+	return env, nil
+}
+
+// Digest returns the tagged hash of the envelope that is signed by the sender.
+// It commits to all fields of the envelope except the signature itself.
+func (e *Envelope) Digest() [32]byte {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(e.Version))
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(e.Timestamp.Unix()))
+	buf.Write(timestamp[:])
+	buf.Write(e.Nonce[:])
+
+	// The audience is length prefixed, so it can't be confused with the
+	// fields that follow it.
+	var audienceLen [2]byte
+	binary.BigEndian.PutUint16(audienceLen[:], uint16(len(e.Audience)))
+	buf.Write(audienceLen[:])
+	buf.WriteString(e.Audience)
+
+	buf.Write(schnorr.SerializePubKey(e.SenderKey))
+	buf.Write(e.Payload)
+
+	return *chainhash.TaggedHash(envelopeTag, buf.Bytes())
+}
+
+// Sign signs the envelope with the given private key, which must belong to
+// the sender key of the envelope.
+func (e *Envelope) Sign(privKey *btcec.PrivateKey) error {
+	if !bytes.Equal(
+		schnorr.SerializePubKey(privKey.PubKey()),
+		schnorr.SerializePubKey(e.SenderKey),
+	) {
+
+		return fmt.Errorf("private key doesn't match sender key")
+	}
+
+	digest := e.Digest()
+	sig, err := schnorr.Sign(privKey, digest[:])
+	if err != nil {
+		return fmt.Errorf("unable to sign envelope: %w", err)
+	}
+	e.Signature = sig
+
+	return nil
+}
+
+// VerifySignature verifies the signature of the envelope against its sender
+// key. It doesn't check the timestamp or nonce, which is done by the
+// Verifier.
+func (e *Envelope) VerifySignature() error {
+	if e.Version != V0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, e.Version)
+	}
+	if e.SenderKey == nil || e.Signature == nil {
+		return ErrInvalidSignature
+	}
+	if len(e.Audience) > MaxAudienceLen {
+		return ErrInvalidSignature
+	}
+
+	digest := e.Digest()
+	if !e.Signature.Verify(digest[:], e.SenderKey) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// jsonEnvelope is the JSON representation of an envelope.
+type jsonEnvelope struct {
+	Version   uint8  `json:"version"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Audience  string `json:"audience"`
+	SenderKey string `json:"sender_key"`
+	Payload   []byte `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// MarshalJSON encodes the envelope as JSON, with the keys and signature
+// encoded as hex and the payload encoded as base64.
+func (e *Envelope) MarshalJSON() ([]byte, error) {
+	if e.SenderKey == nil || e.Signature == nil {
+		return nil, fmt.Errorf("envelope must be signed")
+	}
+
+	return json.Marshal(&jsonEnvelope{
+		Version:   uint8(e.Version),
+		Timestamp: e.Timestamp.Unix(),
+		Nonce:     hex.EncodeToString(e.Nonce[:]),
+		Audience:  e.Audience,
+		SenderKey: hex.EncodeToString(
+			schnorr.SerializePubKey(e.SenderKey),
+		),
+		Payload:   e.Payload,
+		Signature: hex.EncodeToString(e.Signature.Serialize()),
+	})
+}
+
+// UnmarshalJSON decodes an envelope from its JSON representation. The
+// signature is not verified.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var j jsonEnvelope
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	nonce, err := hex.DecodeString(j.Nonce)
+	if err != nil {
+		return fmt.Errorf("invalid nonce: %w", err)
+	}
+	if len(nonce) != NonceSize {
+		return fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+
+	keyBytes, err := hex.DecodeString(j.SenderKey)
+	if err != nil {
+		return fmt.Errorf("invalid sender key: %w", err)
+	}
+	senderKey, err := schnorr.ParsePubKey(keyBytes)
+	if err != nil {
+		return fmt.Errorf("invalid sender key: %w", err)
+	}
+
+	sigBytes, err := hex.DecodeString(j.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	sig, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	*e = Envelope{
+		Version:   Version(j.Version),
+		Timestamp: time.Unix(j.Timestamp, 0),
+		Audience:  j.Audience,
+		SenderKey: senderKey,
+		Payload:   j.Payload,
+		Signature: sig,
+	}
+	copy(e.Nonce[:], nonce)
+
+	return nil
+}
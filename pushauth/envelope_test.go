@@ -0,0 +1,159 @@
+package pushauth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEnvelopeRoundTrip tests that a signed envelope survives a JSON round
+// trip and that any modification invalidates its signature.
+func TestEnvelopeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	const audience = "https://example.com/hook"
+
+	privKey := test.RandPrivKey()
+	env, err := NewEnvelope(
+		[]byte(`{"event":"receive"}`), audience, privKey.PubKey(),
+		time.Now(),
+	)
+	require.NoError(t, err)
+
+	// An envelope without an audience can't be created.
+	_, err = NewEnvelope(
+		[]byte("payload"), "", privKey.PubKey(), time.Now(),
+	)
+	require.Error(t, err)
+
+	// An unsigned envelope can't be encoded or verified.
+	_, err = json.Marshal(env)
+	require.Error(t, err)
+	require.ErrorIs(t, env.VerifySignature(), ErrInvalidSignature)
+
+	// Signing with a different key isn't possible.
+	require.Error(t, env.Sign(test.RandPrivKey()))
+
+	require.NoError(t, env.Sign(privKey))
+	require.NoError(t, env.VerifySignature())
+
+	envJSON, err := json.Marshal(env)
+	require.NoError(t, err)
+
+	var decoded Envelope
+	require.NoError(t, json.Unmarshal(envJSON, &decoded))
+	require.NoError(t, decoded.VerifySignature())
+	require.Equal(t, env.Payload, decoded.Payload)
+	require.Equal(t, env.Nonce, decoded.Nonce)
+	require.Equal(t, env.Timestamp, decoded.Timestamp)
+	require.Equal(t, env.Audience, decoded.Audience)
+
+	// Changing the payload, nonce, timestamp or audience invalidates the
+	// signature.
+	tampered := decoded
+	tampered.Payload = []byte(`{"event":"send"}`)
+	require.ErrorIs(t, tampered.VerifySignature(), ErrInvalidSignature)
+
+	tampered = decoded
+	tampered.Nonce[0] ^= 0x01
+	require.ErrorIs(t, tampered.VerifySignature(), ErrInvalidSignature)
+
+	tampered = decoded
+	tampered.Timestamp = tampered.Timestamp.Add(time.Second)
+	require.ErrorIs(t, tampered.VerifySignature(), ErrInvalidSignature)
+
+	tampered = decoded
+	tampered.Audience = "https://attacker.example.com/hook"
+	require.ErrorIs(t, tampered.VerifySignature(), ErrInvalidSignature)
+}
+
+// TestVerifier tests that the verifier rejects stale, replayed and foreign
+// envelopes as well as envelopes meant for a different audience.
+func TestVerifier(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time {
+		return now
+	}
+
+	const audience = "https://example.com/hook"
+
+	privKey := test.RandPrivKey()
+	senders := []*btcec.PublicKey{privKey.PubKey()}
+	newEnvelope := func(created time.Time) *Envelope {
+		env, err := NewEnvelope(
+			[]byte("payload"), audience, privKey.PubKey(), created,
+		)
+		require.NoError(t, err)
+		require.NoError(t, env.Sign(privKey))
+
+		return env
+	}
+
+	// A verifier must be configured with an audience and at least one
+	// trusted sender.
+	_, err := NewVerifier(audience, nil)
+	require.Error(t, err)
+	_, err = NewVerifier("", senders)
+	require.Error(t, err)
+
+	verifier, err := NewVerifier(
+		audience, senders, withClock(clock),
+		WithMaxClockSkew(time.Minute),
+	)
+	require.NoError(t, err)
+
+	// A fresh envelope is accepted exactly once.
+	env := newEnvelope(now)
+	require.NoError(t, verifier.Verify(env))
+	require.ErrorIs(t, verifier.Verify(env), ErrReplayedNonce)
+
+	// Envelopes outside the clock skew window are rejected.
+	require.ErrorIs(
+		t, verifier.Verify(newEnvelope(now.Add(-2*time.Minute))),
+		ErrStaleEnvelope,
+	)
+	require.ErrorIs(
+		t, verifier.Verify(newEnvelope(now.Add(2*time.Minute))),
+		ErrStaleEnvelope,
+	)
+
+	// Envelopes of other senders are rejected.
+	otherKey := test.RandPrivKey()
+	otherEnv, err := NewEnvelope(
+		[]byte("payload"), audience, otherKey.PubKey(), now,
+	)
+	require.NoError(t, err)
+	require.NoError(t, otherEnv.Sign(otherKey))
+	require.ErrorIs(t, verifier.Verify(otherEnv), ErrUnknownSender)
+
+	// Envelopes meant for a different endpoint are rejected.
+	otherEnv, err = NewEnvelope(
+		[]byte("payload"), "https://other.example.com/hook",
+		privKey.PubKey(), now,
+	)
+	require.NoError(t, err)
+	require.NoError(t, otherEnv.Sign(privKey))
+	require.ErrorIs(t, verifier.Verify(otherEnv), ErrWrongAudience)
+
+	// Once the replayed envelope is stale, its nonce is pruned from the
+	// in-memory store.
+	store := NewMemNonceStore()
+	store.now = clock
+	verifier, err = NewVerifier(
+		audience, senders, withClock(clock),
+		WithMaxClockSkew(time.Minute), WithNonceStore(store),
+	)
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify(newEnvelope(now)))
+	require.Len(t, store.nonces, 1)
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, verifier.Verify(newEnvelope(now)))
+	require.Len(t, store.nonces, 1)
+}
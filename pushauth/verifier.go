@@ -0,0 +1,228 @@
+package pushauth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+const (
+	// DefaultMaxClockSkew is the default maximum difference between the
+	// timestamp of an envelope and the local time of the receiver.
+	DefaultMaxClockSkew = 5 * time.Minute
+)
+
+var (
+	// ErrStaleEnvelope is returned when the timestamp of an envelope is
+	// outside the accepted time window.
+	ErrStaleEnvelope = errors.New("pushauth: envelope timestamp outside " +
+		"of accepted window")
+
+	// ErrReplayedNonce is returned when the nonce of an envelope was
+	// already seen within the accepted time window.
+	ErrReplayedNonce = errors.New("pushauth: replayed envelope nonce")
+
+	// ErrUnknownSender is returned when an envelope is signed by a key
+	// that isn't in the set of trusted sender keys.
+	ErrUnknownSender = errors.New("pushauth: unknown envelope sender")
+
+	// ErrWrongAudience is returned when an envelope is meant for a
+	// different endpoint than the one of the verifier.
+	ErrWrongAudience = errors.New("pushauth: envelope meant for a " +
+		"different audience")
+)
+
+// NonceStore keeps track of the nonces of envelopes that were already
+// accepted.
+type NonceStore interface {
+	// CheckAndStore atomically checks whether the given nonce of the given
+	// sender was seen before and stores it if not. The nonce only needs to
+	// be remembered until the given expiry time, after which envelopes
+	// carrying it are rejected as stale anyway. True is returned if the
+	// nonce is new.
+	CheckAndStore(sender [32]byte, nonce [NonceSize]byte,
+		expiry time.Time) (bool, error)
+}
+
+// nonceKey is the key of a nonce in the in-memory nonce store.
+type nonceKey struct {
+	sender [32]byte
+	nonce  [NonceSize]byte
+}
+
+// MemNonceStore is an in-memory NonceStore that forgets nonces once they
+// expired.
+type MemNonceStore struct {
+	mu     sync.Mutex
+	nonces map[nonceKey]time.Time
+	now    func() time.Time
+}
+
+// A compile-time assertion to ensure MemNonceStore implements NonceStore.
+var _ NonceStore = (*MemNonceStore)(nil)
+
+// NewMemNonceStore creates a new, empty in-memory nonce store.
+func NewMemNonceStore() *MemNonceStore {
+	return &MemNonceStore{
+		nonces: make(map[nonceKey]time.Time),
+		now:    time.Now,
+	}
+}
+
+// CheckAndStore atomically checks whether the given nonce of the given sender
+// was seen before and stores it if not.
+func (m *MemNonceStore) CheckAndStore(sender [32]byte, nonce [NonceSize]byte,
+	expiry time.Time) (bool, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Prune all expired nonces first, so the store doesn't grow without
+	// bounds.
+	now := m.now()
+	for key, keyExpiry := range m.nonces {
+		if now.After(keyExpiry) {
+			delete(m.nonces, key)
+		}
+	}
+
+	key := nonceKey{sender: sender, nonce: nonce}
+	if _, ok := m.nonces[key]; ok {
+		return false, nil
+	}
+	m.nonces[key] = expiry
+
+	return true, nil
+}
+
+// VerifierOption is a functional option for the envelope verifier.
+type VerifierOption func(*Verifier)
+
+// WithMaxClockSkew sets the maximum accepted difference between the
+// timestamp of an envelope and the local time.
+func WithMaxClockSkew(skew time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.maxClockSkew = skew
+	}
+}
+
+// WithNonceStore sets the nonce store used for replay protection. This can
+// be used to share the seen nonces between multiple receiver instances.
+func WithNonceStore(store NonceStore) VerifierOption {
+	return func(v *Verifier) {
+		v.nonceStore = store
+	}
+}
+
+// withClock sets the clock used by the verifier, which is used in tests.
+func withClock(now func() time.Time) VerifierOption {
+	return func(v *Verifier) {
+		v.now = now
+	}
+}
+
+// Verifier verifies envelopes received by a push endpoint. An envelope is
+// only accepted if it is signed by one of the trusted senders, it is meant
+// for the audience of the verifier, its timestamp is within the accepted
+// clock skew and its nonce wasn't seen before.
+type Verifier struct {
+	audience       string
+	maxClockSkew   time.Duration
+	nonceStore     NonceStore
+	trustedSenders map[[32]byte]struct{}
+	now            func() time.Time
+}
+
+// NewVerifier creates a new envelope verifier for the given audience that
+// only accepts envelopes signed by one of the given trusted sender keys. By
+// default, the clock skew is limited to DefaultMaxClockSkew and nonces are
+// tracked in memory.
+func NewVerifier(audience string, trustedSenders []*btcec.PublicKey,
+	opts ...VerifierOption) (*Verifier, error) {
+
+	if audience == "" {
+		return nil, fmt.Errorf("audience must be set")
+	}
+	if len(trustedSenders) == 0 {
+		return nil, fmt.Errorf("at least one trusted sender key must " +
+			"be set")
+	}
+
+	v := &Verifier{
+		audience:     audience,
+		maxClockSkew: DefaultMaxClockSkew,
+		trustedSenders: make(
+			map[[32]byte]struct{}, len(trustedSenders),
+		),
+		now: time.Now,
+	}
+	for _, key := range trustedSenders {
+		if key == nil {
+			return nil, fmt.Errorf("trusted sender key must not " +
+				"be nil")
+		}
+
+		v.trustedSenders[xOnlyKey(key)] = struct{}{}
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.nonceStore == nil {
+		store := NewMemNonceStore()
+		store.now = v.now
+		v.nonceStore = store
+	}
+
+	return v, nil
+}
+
+// Verify verifies the given envelope and records its nonce. The payload of an
+// envelope must only be processed if nil is returned.
+func (v *Verifier) Verify(env *Envelope) error {
+	if err := env.VerifySignature(); err != nil {
+		return err
+	}
+
+	sender := xOnlyKey(env.SenderKey)
+	if _, ok := v.trustedSenders[sender]; !ok {
+		return fmt.Errorf("%w: %x", ErrUnknownSender, sender)
+	}
+
+	if env.Audience != v.audience {
+		return fmt.Errorf("%w: %q", ErrWrongAudience, env.Audience)
+	}
+
+	now := v.now()
+	minTime := now.Add(-v.maxClockSkew)
+	maxTime := now.Add(v.maxClockSkew)
+	if env.Timestamp.Before(minTime) || env.Timestamp.After(maxTime) {
+		return fmt.Errorf("%w: timestamp %v, local time %v",
+			ErrStaleEnvelope, env.Timestamp.Unix(), now.Unix())
+	}
+
+	// The nonce needs to be remembered for as long as the envelope would
+	// pass the timestamp check above.
+	expiry := env.Timestamp.Add(v.maxClockSkew)
+	isNew, err := v.nonceStore.CheckAndStore(sender, env.Nonce, expiry)
+	if err != nil {
+		return fmt.Errorf("unable to check nonce: %w", err)
+	}
+	if !isNew {
+		return fmt.Errorf("%w: %x", ErrReplayedNonce, env.Nonce[:])
+	}
+
+	return nil
+}
+
+// xOnlyKey returns the x-only serialization of the given public key.
+func xOnlyKey(key *btcec.PublicKey) [32]byte {
+	var result [32]byte
+	copy(result[:], schnorr.SerializePubKey(key))
+
+	return result
+}
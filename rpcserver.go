@@ -86,10 +86,6 @@ var (
 	// ServerMaxMsgReceiveSize is the largest message our server will
 	// receive.
 	ServerMaxMsgReceiveSize = grpc.MaxRecvMsgSize(lnrpc.MaxGrpcMsgSize)
-
-	// P2TRChangeType is the type of change address that should be used for
-	// funding PSBTs, as we'll always want to use P2TR change addresses.
-	P2TRChangeType = walletrpc.ChangeAddressType_CHANGE_ADDRESS_TYPE_P2TR
 )
 
 const (
@@ -1864,7 +1860,30 @@ func (r *rpcServer) VerifyProof(ctx context.Context,
 		return nil, fmt.Errorf("unable to decode proof file: %w", err)
 	}
 
-	_, err = proofFile.Verify(ctx, r.ProofVerifierCtx(ctx))
+	// A file that doesn't start at the asset's genesis was exported with
+	// only the last proofs of its history. We only verify it if the caller
+	// explicitly opted into trusting its first proof as a checkpoint, and
+	// only if that proof is part of our local universe.
+	firstProof, err := proofFile.ProofAt(0)
+	if err != nil {
+		return nil, err
+	}
+
+	vCtx := r.ProofVerifierCtx(ctx)
+	switch {
+	case firstProof.Asset.IsGenesisAsset():
+		_, err = proofFile.Verify(ctx, vCtx)
+
+	case !req.TrustCheckpoint:
+		return nil, fmt.Errorf("proof file doesn't start at the " +
+			"asset's genesis, set trust_checkpoint to verify it " +
+			"from a universe checkpoint")
+
+	default:
+		_, err = proofFile.VerifyFromCheckpoint(
+			ctx, vCtx, r.universeCheckpointVerifier,
+		)
+	}
 	if err != nil {
 		// We don't want to fail the RPC request because of a proof
 		// verification error, but we do want to log it for easier
@@ -1891,6 +1910,43 @@ func (r *rpcServer) VerifyProof(ctx context.Context,
 	}, nil
 }
 
+// universeCheckpointVerifier only accepts proofs that are part of the local
+// universe as trusted checkpoints.
+func (r *rpcServer) universeCheckpointVerifier(ctx context.Context,
+	p *proof.Proof) error {
+
+	rawProof, err := p.Bytes()
+	if err != nil {
+		return fmt.Errorf("unable to encode proof: %w", err)
+	}
+
+	uniID := universe.NewUniIDFromAsset(p.Asset)
+	leafKey := universe.BaseLeafKey{
+		OutPoint:  p.OutPoint(),
+		ScriptKey: &p.Asset.ScriptKey,
+	}
+	uniProofs, err := r.cfg.UniverseArchive.FetchProofLeaf(
+		ctx, uniID, leafKey,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to fetch proof from local universe: "+
+			"%w", err)
+	}
+
+	for _, uniProof := range uniProofs {
+		if uniProof.Leaf == nil {
+			continue
+		}
+
+		if bytes.Equal(uniProof.Leaf.RawProof, rawProof) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("proof for %v not found in local universe",
+		p.OutPoint())
+}
+
 // DecodeProof attempts to decode a given proof file that claims to be anchored
 // at the specified genesis point.
 func (r *rpcServer) DecodeProof(ctx context.Context,
@@ -2186,6 +2242,29 @@ func (r *rpcServer) ExportProof(ctx context.Context,
 		return nil, err
 	}
 
+	// If requested, we only export the last proofs of the file. The
+	// receiver then needs to verify the file from its first proof as a
+	// trusted checkpoint.
+	if req.MaxProofs > 0 {
+		proofFile, err := proofBlob.AsFile()
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode proof "+
+				"file: %w", err)
+		}
+
+		suffix, err := proofFile.Suffix(req.MaxProofs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create proof file "+
+				"suffix: %w", err)
+		}
+
+		proofBlob, err = proof.EncodeFile(suffix)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode proof file "+
+				"suffix: %w", err)
+		}
+	}
+
 	return &taprpc.ProofFile{
 		RawProofFile: proofBlob,
 	}, nil
@@ -2204,8 +2283,11 @@ func (r *rpcServer) ImportProof(ctx context.Context,
 	}
 
 	// We need to parse the proof file and extract the last proof, so we can
-	// get the locator that is required for storage.
-	proofFile, err := proof.DecodeFile(req.ProofFile)
+	// get the locator that is required for storage. The file may come from
+	// an untrusted source, so we enforce the configured resource limits.
+	proofFile, err := proof.DecodeFileWithLimits(
+		req.ProofFile, r.cfg.ProofFileLimits,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("unable to decode proof file: %w", err)
 	}
@@ -2668,7 +2750,10 @@ func (r *rpcServer) CommitVirtualPsbts(ctx context.Context,
 		return nil, fmt.Errorf("no virtual PSBTs specified")
 	}
 
-	pkt, err := psbt.NewFromRawBytes(bytes.NewReader(req.AnchorPsbt), false)
+	// The anchor packet can either be a version 0 or a version 2 PSBT.
+	// Version 2 packets are converted to version 0, which is what the
+	// wallet uses.
+	pkt, err := tapsend.DecodeAnchorPsbt(req.AnchorPsbt)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding packet: %w", err)
 	}
@@ -2704,6 +2789,7 @@ func (r *rpcServer) CommitVirtualPsbts(ctx context.Context,
 		lockedOutpoints []wire.OutPoint
 		fundedPacket    *psbt.Packet = pkt
 		changeIndex     int32        = -1
+		addedChange     bool
 		success         bool
 	)
 
@@ -2719,7 +2805,7 @@ func (r *rpcServer) CommitVirtualPsbts(ctx context.Context,
 				CoinSelect: coinSelect,
 			},
 			MinConfs:              1,
-			ChangeType:            P2TRChangeType,
+			ChangeType:            r.cfg.ChangeAddressType,
 			CustomLockId:          req.CustomLockId,
 			LockExpirationSeconds: req.LockExpirationSeconds,
 		}
@@ -2737,6 +2823,7 @@ func (r *rpcServer) CommitVirtualPsbts(ctx context.Context,
 			coinSelect.ChangeOutput = &walletrpc.PsbtCoinSelect_Add{
 				Add: change.Add,
 			}
+			addedChange = change.Add
 
 		default:
 			return nil, fmt.Errorf("unknown change output type")
@@ -2799,6 +2886,27 @@ func (r *rpcServer) CommitVirtualPsbts(ctx context.Context,
 		}()
 	}
 
+	// If an allow list for the non-asset outputs is configured, we make
+	// sure the anchor transaction doesn't pay to any other script than the
+	// change output our wallet just added or the allowed ones. An existing
+	// output used as the change output comes from the caller's template,
+	// so it needs to be on the allow list like any other output.
+	if r.cfg.AnchorOutputAllowList != nil {
+		var walletOutputs []int
+		if addedChange && changeIndex >= 0 {
+			walletOutputs = append(walletOutputs, int(changeIndex))
+		}
+
+		err = tapsend.ValidateNonAssetOutputs(
+			fundedPacket, allPackets, r.cfg.AnchorOutputAllowList,
+			walletOutputs...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anchor transaction: %w",
+				err)
+		}
+	}
+
 	// We can now update the anchor outputs as we have the final
 	// commitments.
 	outputCommitments, err := tapsend.CreateOutputCommitments(allPackets)
@@ -2843,7 +2951,13 @@ func (r *rpcServer) CommitVirtualPsbts(ctx context.Context,
 		ChangeOutputIndex: changeIndex,
 	}
 
-	response.AnchorPsbt, err = fn.Serialize(fundedPacket)
+	// We return the anchor packet in the same PSBT version it was given
+	// to us.
+	if tapsend.IsPsbtV2(req.AnchorPsbt) {
+		response.AnchorPsbt, err = tapsend.ConvertToPsbtV2(fundedPacket)
+	} else {
+		response.AnchorPsbt, err = fn.Serialize(fundedPacket)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error serializing packet: %w", err)
 	}
@@ -3052,7 +3166,10 @@ func (r *rpcServer) PublishAndLogTransfer(ctx context.Context,
 		return nil, fmt.Errorf("no virtual PSBTs specified")
 	}
 
-	pkt, err := psbt.NewFromRawBytes(bytes.NewReader(req.AnchorPsbt), false)
+	// The anchor packet can either be a version 0 or a version 2 PSBT.
+	// Version 2 packets are converted to version 0, which is what the
+	// wallet uses.
+	pkt, err := tapsend.DecodeAnchorPsbt(req.AnchorPsbt)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding packet: %w", err)
 	}
@@ -7184,12 +7301,15 @@ func (r *rpcServer) PushProof(ctx context.Context,
 	// then attempt to push the proof.
 	err = CheckFederationServer(
 		r.cfg.RuntimeID, universe.DefaultTimeout, remoteUniAddr,
+		r.cfg.UniverseConnOpts...,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	remoteUni, err := NewRpcUniverseRegistrar(remoteUniAddr)
+	remoteUni, err := NewRpcUniverseRegistrar(
+		remoteUniAddr, r.cfg.UniverseConnOpts...,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -7408,6 +7528,7 @@ func (r *rpcServer) AddFederationServer(ctx context.Context,
 		// ourselves.
 		err := CheckFederationServer(
 			r.cfg.RuntimeID, universe.DefaultTimeout, server,
+			r.cfg.UniverseConnOpts...,
 		)
 		if err != nil {
 			return nil, err
@@ -10391,5 +10512,6 @@ func (r *rpcServer) ProofVerifierCtx(ctx context.Context) proof.VerifierCtx {
 		GroupVerifier:  groupVerifier,
 		ChainLookupGen: r.cfg.ChainBridge,
 		IgnoreChecker:  lfn.Some(ignoreChecker),
+		CacheID:        lfn.Some(proof.DefaultVerifierCacheID),
 	}
 }
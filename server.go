@@ -22,7 +22,9 @@ import (
 	"github.com/lightninglabs/taproot-assets/rpcperms"
 	"github.com/lightninglabs/taproot-assets/tapchannel"
 	cmsg "github.com/lightninglabs/taproot-assets/tapchannelmsg"
+	"github.com/lightninglabs/taproot-assets/tapfreighter"
 	"github.com/lightninglabs/taproot-assets/taprpc"
+	"github.com/lightninglabs/taproot-assets/universe"
 	"github.com/lightningnetwork/lnd"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/channeldb"
@@ -89,6 +91,29 @@ func (s *Server) UpdateConfig(cfg *Config) {
 	s.cfg = cfg
 }
 
+// SendBlocklist returns the set of destinations the server refuses to send
+// assets to. Applications embedding tapd can use it to block destinations, to
+// allow a blocked destination for a single transfer or to set the auditor that
+// is called whenever such an override is used.
+func (s *Server) SendBlocklist() *tapfreighter.Blocklist {
+	return s.cfg.SendBlocklist
+}
+
+// HtlcWatcher returns the watcher of the intermediate outputs of two-stage
+// HTLC claims. Applications embedding tapd can use it to watch the outputs
+// they create and to get notified once they can be swept or were revoked.
+func (s *Server) HtlcWatcher() *tapfreighter.HtlcWatcher {
+	return s.cfg.HtlcWatcher
+}
+
+// IssuanceMonitor returns the monitor that cross-checks the issuance of the
+// configured asset groups against the universe servers of the federation, or
+// nil if no groups are configured. Applications embedding tapd can subscribe
+// to its alerts.
+func (s *Server) IssuanceMonitor() *universe.IssuanceMonitor {
+	return s.cfg.IssuanceMonitor
+}
+
 // initialize creates and initializes an instance of the macaroon service and
 // rpc server based on the server configuration. This method ensures that
 // everything is cleaned up in case there is an error while initializing any of
@@ -187,6 +212,10 @@ func (s *Server) initialize(interceptorChain *rpcperms.InterceptorChain) error {
 		return fmt.Errorf("unable to start asset custodian: %w", err)
 	}
 
+	if err := s.cfg.InvoiceManager.Start(); err != nil {
+		return fmt.Errorf("unable to start invoice manager: %w", err)
+	}
+
 	if err := s.cfg.ReOrgWatcher.Start(); err != nil {
 		return fmt.Errorf("unable to start re-org watcher: %w", err)
 	}
@@ -241,6 +270,31 @@ func (s *Server) initialize(interceptorChain *rpcperms.InterceptorChain) error {
 		return fmt.Errorf("unable to start aux sweeper mgr: %w", err)
 	}
 
+	if s.cfg.DbSnapshotter != nil {
+		if err := s.cfg.DbSnapshotter.Start(); err != nil {
+			return fmt.Errorf("unable to start database "+
+				"snapshotter: %w", err)
+		}
+	}
+
+	if s.cfg.InactivitySweeper != nil {
+		if err := s.cfg.InactivitySweeper.Start(); err != nil {
+			return fmt.Errorf("unable to start inactivity "+
+				"sweeper: %w", err)
+		}
+	}
+
+	if err := s.cfg.HtlcWatcher.Start(); err != nil {
+		return fmt.Errorf("unable to start HTLC watcher: %w", err)
+	}
+
+	if s.cfg.IssuanceMonitor != nil {
+		if err := s.cfg.IssuanceMonitor.Start(); err != nil {
+			return fmt.Errorf("unable to start issuance monitor: "+
+				"%w", err)
+		}
+	}
+
 	// If the server is configured to sync all assets by default, we'll set
 	// the universe federation to allow public access.
 	if s.cfg.UniFedSyncAllAssets {
@@ -744,6 +798,9 @@ func (s *Server) Stop() error {
 	if err := s.cfg.AssetMinter.Stop(); err != nil {
 		return err
 	}
+	if err := s.cfg.InvoiceManager.Stop(); err != nil {
+		return err
+	}
 	if err := s.cfg.AssetCustodian.Stop(); err != nil {
 		return err
 	}
@@ -752,6 +809,23 @@ func (s *Server) Stop() error {
 		return err
 	}
 
+	if err := s.cfg.HtlcWatcher.Stop(); err != nil {
+		return err
+	}
+
+	if s.cfg.IssuanceMonitor != nil {
+		if err := s.cfg.IssuanceMonitor.Stop(); err != nil {
+			return err
+		}
+	}
+
+	// The inactivity sweeper uses the chain porter, so we stop it first.
+	if s.cfg.InactivitySweeper != nil {
+		if err := s.cfg.InactivitySweeper.Stop(); err != nil {
+			return err
+		}
+	}
+
 	if err := s.cfg.ChainPorter.Stop(); err != nil {
 		return err
 	}
@@ -798,6 +872,12 @@ func (s *Server) Stop() error {
 		return err
 	}
 
+	if s.cfg.DbSnapshotter != nil {
+		if err := s.cfg.DbSnapshotter.Stop(); err != nil {
+			return err
+		}
+	}
+
 	if s.macaroonService != nil {
 		err := s.macaroonService.Stop()
 		if err != nil {
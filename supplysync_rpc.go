@@ -29,10 +29,10 @@ type RpcSupplySync struct {
 
 // NewRpcSupplySync creates a new RpcSupplySync instance that dials out to
 // the target remote universe server address.
-func NewRpcSupplySync(
-	serverAddr universe.ServerAddr) (supplyverifier.UniverseClient, error) {
+func NewRpcSupplySync(serverAddr universe.ServerAddr,
+	opts ...UniverseConnOption) (supplyverifier.UniverseClient, error) {
 
-	conn, err := ConnectUniverse(serverAddr)
+	conn, err := ConnectUniverse(serverAddr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to universe RPC "+
 			"server: %w", err)
@@ -44,8 +44,13 @@ func NewRpcSupplySync(
 	}, nil
 }
 
-// Ensure NewRpcSupplySync is of type UniverseClientFactory.
-var _ supplyverifier.UniverseClientFactory = NewRpcSupplySync
+// Ensure NewRpcSupplySync without connection options is of type
+// UniverseClientFactory.
+var _ supplyverifier.UniverseClientFactory = func(
+	serverAddr universe.ServerAddr) (supplyverifier.UniverseClient, error) {
+
+	return NewRpcSupplySync(serverAddr)
+}
 
 // InsertSupplyCommit inserts a supply commitment for a specific asset
 // group into the remote universe server.
@@ -16,6 +16,7 @@ import (
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btclog/v2"
 	"github.com/caddyserver/certmagic"
 	"github.com/jessevdk/go-flags"
@@ -27,11 +28,15 @@ import (
 	"github.com/lightninglabs/taproot-assets/proof"
 	"github.com/lightninglabs/taproot-assets/rfq"
 	"github.com/lightninglabs/taproot-assets/tapdb"
+	"github.com/lightninglabs/taproot-assets/tapfreighter"
+	"github.com/lightninglabs/taproot-assets/tapsend"
+	"github.com/lightninglabs/taproot-assets/universe"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/cert"
 	"github.com/lightningnetwork/lnd/lncfg"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/verrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
 	"github.com/lightningnetwork/lnd/signal"
 	"github.com/lightningnetwork/lnd/tor"
 	"golang.org/x/net/http2"
@@ -148,6 +153,14 @@ const (
 	// DefaultPsbtMaxFeeRatio is the default maximum for fees to total
 	// output amount ratio to use when funding PSBTs.
 	DefaultPsbtMaxFeeRatio = lndservices.DefaultPsbtMaxFeeRatio
+
+	// changeTypeP2TR is the change type for taproot (BIP-0086) change
+	// outputs.
+	changeTypeP2TR = "p2tr"
+
+	// changeTypeP2WKH is the change type for native SegWit v0 (BIP-0084)
+	// change outputs.
+	changeTypeP2WKH = "p2wkh"
 )
 
 var (
@@ -173,6 +186,10 @@ var (
 
 	defaultSqliteDatabaseFileName = "tapd.db"
 
+	// defaultSnapshotDirName is the name of the directory within the
+	// network directory that database snapshots are stored in by default.
+	defaultSnapshotDirName = "snapshots"
+
 	// defaultLndMacaroon is the default macaroon file we use if the old,
 	// deprecated --lnd.macaroondir config option is used.
 	defaultLndMacaroon = "admin.macaroon"
@@ -294,6 +311,76 @@ type WalletConfig struct {
 	// high fee environments the total fees paid may outweigh the anchor
 	// amount. The allowed values for this argument range from 0.00 to 1.00.
 	PsbtMaxFeeRatio float64 `long:"psbt-max-fee-ratio" description:"The maximum fees to total output amount ratio to use when funding PSBTs for asset transfers. Value must be between 0.00 and 1.00"`
+
+	// ChangeType is the address type of the change output added by the
+	// wallet when funding PSBTs for asset transfers.
+	ChangeType string `long:"change-type" description:"The address type of the change output added by the wallet when funding PSBTs for asset transfers. Taproot change keeps anchor transactions uniform and cheaper." choice:"p2tr" choice:"p2wkh"`
+
+	// EnforceAnchorOutputAllowList enforces that all non-asset outputs of
+	// anchor transactions committed over RPC either are the change output
+	// added by the wallet or pay to one of the allowed addresses.
+	EnforceAnchorOutputAllowList bool `long:"enforce-anchor-output-allowlist" description:"If set, all non-asset outputs of anchor transactions committed over RPC must either be the change output added by the wallet or pay to one of the addresses specified with anchor-output-allow-addr."`
+
+	// AnchorOutputAllowAddrs is the list of addresses the non-asset
+	// outputs of anchor transactions are allowed to pay to, if the allow
+	// list is enforced.
+	AnchorOutputAllowAddrs []string `long:"anchor-output-allow-addr" description:"A BTC address that non-asset outputs of anchor transactions are allowed to pay to if enforce-anchor-output-allowlist is set. Can be specified multiple times."`
+
+	InactivitySweep *tapfreighter.InactivitySweepConfig `group:"inactivity-sweep" namespace:"inactivity-sweep"`
+}
+
+// ChangeAddressType returns the lnd change address type that corresponds to
+// the configured change type.
+func (c *WalletConfig) ChangeAddressType() (walletrpc.ChangeAddressType,
+	error) {
+
+	switch c.ChangeType {
+	case changeTypeP2TR:
+		return walletrpc.ChangeAddressType_CHANGE_ADDRESS_TYPE_P2TR, nil
+
+	// lnd uses P2WKH (BIP-0084) change if no change type is specified.
+	case changeTypeP2WKH:
+		unspecified :=
+			walletrpc.ChangeAddressType_CHANGE_ADDRESS_TYPE_UNSPECIFIED
+
+		return unspecified, nil
+
+	default:
+		return 0, fmt.Errorf("unknown change type: %v", c.ChangeType)
+	}
+}
+
+// AnchorOutputAllowList returns the allow list for the non-asset outputs of
+// anchor transactions, or nil if the allow list isn't enforced.
+func (c *WalletConfig) AnchorOutputAllowList(
+	params *chaincfg.Params) (*tapsend.AnchorOutputAllowList, error) {
+
+	if !c.EnforceAnchorOutputAllowList {
+		return nil, nil
+	}
+
+	pkScripts := make([][]byte, 0, len(c.AnchorOutputAllowAddrs))
+	for _, addrStr := range c.AnchorOutputAllowAddrs {
+		addr, err := btcutil.DecodeAddress(addrStr, params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anchor output allow "+
+				"address %v: %w", addrStr, err)
+		}
+		if !addr.IsForNet(params) {
+			return nil, fmt.Errorf("anchor output allow address "+
+				"%v is not for network %v", addrStr,
+				params.Name)
+		}
+
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create script for "+
+				"address %v: %w", addrStr, err)
+		}
+		pkScripts = append(pkScripts, pkScript)
+	}
+
+	return tapsend.NewAnchorOutputAllowList(pkScripts...), nil
 }
 
 // UniverseConfig is the config that houses any Universe related config
@@ -321,6 +408,12 @@ type UniverseConfig struct {
 
 	SupplyIgnoreCacheSize uint64 `long:"supply-ignore-cache-size" description:"The maximum number of entries in the supply ignore checker's negative lookup LRU cache."`
 
+	PinnedSPKIs []string `long:"pinned-spki" description:"The hex encoded SHA-256 hash of the DER encoded SubjectPublicKeyInfo of a certificate that remote universe servers must present. If set, connections to universe servers that don't present a certificate with one of the pinned public keys are rejected. This includes universe RPC and hashmail proof couriers. Can be specified multiple times."`
+
+	IssuanceCheckGroups []string `long:"issuance-check-group" description:"The hex encoded group key of an asset group whose issuance is periodically cross-checked against the universe servers of the federation. An alert is logged if a server reports an issuance of the group that isn't known to the local universe. Can be specified multiple times."`
+
+	IssuanceCheckInterval time.Duration `long:"issuance-check-interval" description:"The interval at which the issuance of the groups specified with issuance-check-group is cross-checked. Valid time units are {s, m, h}."`
+
 	DisableSupplyVerifierChainWatch bool `long:"disable-supply-verifier-chain-watch" description:"Disable chain outpoint watching in supply verifier. If true, the supply verifier will not start state machines to watch on-chain outputs for spends. This option is intended for universe servers, where supply verification should only occur for commitments submitted by peers, not via on-chain spend detection."`
 }
 
@@ -330,6 +423,47 @@ type AddrBookConfig struct {
 	DisableSyncer bool `long:"disable-syncer" description:"If true, tapd will not try to sync issuance proofs for unknown assets when creating an address."`
 }
 
+// ProofLimitsConfig is the config that houses the resource limits enforced on
+// proof files received from untrusted sources.
+//
+// nolint:lll
+type ProofLimitsConfig struct {
+	MaxNumProofs uint64 `long:"max-num-proofs" description:"The maximum number of proofs a single proof file imported or retrieved from a proof courier may contain."`
+
+	MaxFileSize uint64 `long:"max-file-size" description:"The maximum size in bytes of a single proof file imported or retrieved from a proof courier."`
+
+	MaxDecodeMemory uint64 `long:"max-decode-memory" description:"The maximum number of bytes allocated for the proofs of a single proof file while decoding or retrieving it."`
+}
+
+// Validate returns an error if any of the limits exceeds the protocol maximum.
+func (c *ProofLimitsConfig) Validate() error {
+	maxLimits := proof.DefaultFileLimits()
+	switch {
+	case c.MaxNumProofs > maxLimits.MaxNumProofs:
+		return fmt.Errorf("max-num-proofs must not exceed %d",
+			maxLimits.MaxNumProofs)
+
+	case c.MaxFileSize > maxLimits.MaxFileSize:
+		return fmt.Errorf("max-file-size must not exceed %d",
+			maxLimits.MaxFileSize)
+
+	case c.MaxDecodeMemory > maxLimits.MaxDecodeMemory:
+		return fmt.Errorf("max-decode-memory must not exceed %d",
+			maxLimits.MaxDecodeMemory)
+	}
+
+	return nil
+}
+
+// FileLimits returns the configured proof file limits.
+func (c *ProofLimitsConfig) FileLimits() proof.FileLimits {
+	return proof.FileLimits{
+		MaxNumProofs:    c.MaxNumProofs,
+		MaxFileSize:     c.MaxFileSize,
+		MaxDecodeMemory: c.MaxDecodeMemory,
+	}
+}
+
 // ExperimentalConfig houses experimental tapd cli configuration options.
 type ExperimentalConfig struct {
 	Rfq rfq.CliConfig `group:"rfq" namespace:"rfq"`
@@ -383,6 +517,8 @@ type Config struct {
 
 	AddrBook *AddrBookConfig `group:"address" namespace:"address"`
 
+	ProofLimits *ProofLimitsConfig `group:"prooflimits" namespace:"prooflimits"`
+
 	Channel *ChannelConfig `group:"channel" namespace:"channel"`
 
 	Prometheus monitoring.PrometheusConfig `group:"prometheus" namespace:"prometheus"`
@@ -397,6 +533,11 @@ type Config struct {
 	// the daemon.
 	LogMgr *build.SubLoggerManager
 
+	// SendBlocklistAuditor is an optional auditor that is called whenever
+	// a blocked destination is sent to because of an override. It can
+	// only be set by applications that embed the daemon.
+	SendBlocklistAuditor tapfreighter.BlocklistAuditor
+
 	// networkDir is the path to the directory of the currently active
 	// network. This path will hold the files related to each different
 	// network.
@@ -445,6 +586,7 @@ func DefaultConfig() Config {
 		DatabaseBackend: DatabaseBackendSqlite,
 		Sqlite: &tapdb.SqliteConfig{
 			DatabaseFileName: defaultSqliteDatabasePath,
+			Snapshot:         tapdb.DefaultSnapshotConfig(),
 		},
 		Postgres: &tapdb.PostgresConfig{
 			Host:               "localhost",
@@ -490,13 +632,21 @@ func DefaultConfig() Config {
 			MboxAuthTimeout:                 defaultMailboxAuthTimeout,
 			SupplyIgnoreCacheSize:           tapdb.DefaultNegativeLookupCacheSize,
 			DisableSupplyVerifierChainWatch: false,
+			IssuanceCheckInterval:           universe.DefaultIssuanceCheckInterval,
 		},
 		Wallet: &WalletConfig{
 			PsbtMaxFeeRatio: DefaultPsbtMaxFeeRatio,
+			ChangeType:      changeTypeP2TR,
+			InactivitySweep: tapfreighter.DefaultInactivitySweepConfig(),
 		},
 		AddrBook: &AddrBookConfig{
 			DisableSyncer: false,
 		},
+		ProofLimits: &ProofLimitsConfig{
+			MaxNumProofs:    proof.FileMaxNumProofs,
+			MaxFileSize:     proof.FileMaxSizeBytes,
+			MaxDecodeMemory: proof.FileMaxSizeBytes,
+		},
 		Channel: &ChannelConfig{
 			NoopHTLCs: false,
 		},
@@ -793,6 +943,13 @@ func ValidateConfig(cfg Config, cfgLogger btclog.Logger) (*Config, error) {
 		)
 	}
 
+	// Database snapshots are stored in the network directory by default.
+	if cfg.Sqlite.Snapshot != nil && cfg.Sqlite.Snapshot.Dir == "" {
+		cfg.Sqlite.Snapshot.Dir = filepath.Join(
+			cfg.networkDir, defaultSnapshotDirName,
+		)
+	}
+
 	// If a custom macaroon directory wasn't specified and the data
 	// directory has changed from the default path, then we'll also update
 	// the path for the macaroons to be generated.
@@ -938,6 +1095,12 @@ func ValidateConfig(cfg Config, cfgLogger btclog.Logger) (*Config, error) {
 		}
 	}
 
+	// Validate the proof file limits.
+	err = cfg.ProofLimits.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("error in proof limits config: %w", err)
+	}
+
 	// Validate the experimental command line config.
 	err = cfg.Experimental.Validate()
 	if err != nil {
@@ -963,6 +1126,17 @@ func ValidateConfig(cfg Config, cfgLogger btclog.Logger) (*Config, error) {
 			"range of 0.00 to 1.00")
 	}
 
+	// Make sure the change type is known.
+	if _, err := cfg.Wallet.ChangeAddressType(); err != nil {
+		return nil, err
+	}
+
+	// Make sure all addresses of the anchor output allow list are valid.
+	_, err = cfg.Wallet.AnchorOutputAllowList(&cfg.ActiveNetParams)
+	if err != nil {
+		return nil, err
+	}
+
 	// All good, return the sanitized result.
 	return &cfg, nil
 }
@@ -3,10 +3,14 @@ package tapcfg
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"path/filepath"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btclog/v2"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/lightninglabs/lndclient"
@@ -42,9 +46,10 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 	mainErrChan chan<- error) (*tap.Config, error) {
 
 	var (
-		err    error
-		db     tapdb.DatabaseBackend
-		dbType sqlc.BackendType
+		err         error
+		db          tapdb.DatabaseBackend
+		dbType      sqlc.BackendType
+		sqliteStore *tapdb.SqliteStore
 	)
 
 	// Now that we know where the database will live, we'll go ahead and
@@ -53,9 +58,30 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 	case DatabaseBackendSqlite:
 		dbType = sqlc.BackendTypeSqlite
 
+		// If requested, we restore a snapshot before opening the
+		// database, so it's picked up like any other database file.
+		snapshotCfg := cfg.Sqlite.Snapshot
+		if snapshotCfg != nil && snapshotCfg.RestoreFrom != "" {
+			cfgLogger.Infof("Restoring snapshot from: %v",
+				snapshotCfg.RestoreFrom)
+
+			proofDir := filepath.Join(
+				cfg.networkDir, proof.ProofDirName,
+			)
+			err := tapdb.RestoreSnapshot(
+				snapshotCfg.RestoreFrom,
+				cfg.Sqlite.DatabaseFileName, proofDir,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("unable to restore "+
+					"snapshot: %w", err)
+			}
+		}
+
 		cfgLogger.Infof("Opening sqlite3 database at: %v",
 			cfg.Sqlite.DatabaseFileName)
-		db, err = tapdb.NewSqliteStore(cfg.Sqlite)
+		sqliteStore, err = tapdb.NewSqliteStore(cfg.Sqlite)
+		db = sqliteStore
 
 	case DatabaseBackendPostgres:
 		dbType = sqlc.BackendTypePostgres
@@ -109,9 +135,14 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 	assetStore := tapdb.NewAssetStore(assetDB, metaDB, defaultClock, dbType)
 
 	keyRing := lndservices.NewLndRpcKeyRing(lndServices)
+	changeType, err := cfg.Wallet.ChangeAddressType()
+	if err != nil {
+		return nil, err
+	}
 	walletAnchor := lndservices.NewLndRpcWalletAnchor(
 		lndServices,
 		lndservices.WithPsbtMaxFeeRatio(cfg.Wallet.PsbtMaxFeeRatio),
+		lndservices.WithChangeAddressType(changeType),
 	)
 	chainBridge := lndservices.NewLndRpcChainBridge(lndServices, assetStore)
 	msgTransportClient := lndservices.NewLndMsgTransportClient(lndServices)
@@ -213,11 +244,53 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 	if err != nil {
 		return nil, fmt.Errorf("unable to open disk archive: %w", err)
 	}
+
+	// Periodic snapshots are only supported for SQLite, Postgres users
+	// should rely on the backup tooling of their database instead.
+	var dbSnapshotter *tapdb.Snapshotter
+	snapshotCfg := cfg.Sqlite.Snapshot
+	snapshotsEnabled := snapshotCfg != nil && snapshotCfg.Interval > 0
+	if sqliteStore != nil && snapshotsEnabled {
+		dbSnapshotter = tapdb.NewSnapshotter(
+			snapshotCfg, sqliteStore,
+			filepath.Join(cfg.networkDir, proof.ProofDirName),
+		)
+	}
+	// Successful verifications of proof files are cached until one of the
+	// anchor blocks within the re-org safe depth is re-orged out.
+	proofVerifier := proof.NewCachingVerifier(
+		&proof.BaseVerifier{}, chainBridge.CurrentHeight,
+		uint32(cfg.ReOrgSafeDepth), proof.DefaultVerifierCacheSize,
+	)
 	proofArchive := proof.NewMultiArchiver(
-		&proof.BaseVerifier{}, tapdb.DefaultStoreTimeout,
+		proofVerifier, tapdb.DefaultStoreTimeout,
 		assetStore, proofFileStore,
 	)
 
+	var (
+		uniConnOpts []tap.UniverseConnOption
+		uniPins     [][sha256.Size]byte
+	)
+	for _, pinStr := range cfg.Universe.PinnedSPKIs {
+		pin, err := tap.ParseSPKIPin(pinStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid universe SPKI pin: %w",
+				err)
+		}
+
+		uniConnOpts = append(uniConnOpts, tap.WithPinnedSPKIs(pin))
+		uniPins = append(uniPins, pin)
+	}
+
+	// The proof couriers connect to universe servers as well, so the same
+	// pins apply to them.
+	if cfg.UniverseRpcCourier != nil {
+		cfg.UniverseRpcCourier.PinnedSPKIs = uniPins
+	}
+	if cfg.HashMailCourier != nil {
+		cfg.HashMailCourier.PinnedSPKIs = uniPins
+	}
+
 	federationMembers := cfg.Universe.FederationServers
 	switch cfg.ChainConf.Network {
 	case "mainnet":
@@ -368,11 +441,76 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 
 	uniArchive := universe.NewArchive(uniArchiveCfg)
 
+	newRemoteDiffEngine := func(
+		addr universe.ServerAddr) (universe.DiffEngine, error) {
+
+		return tap.NewRpcUniverseDiff(addr, uniConnOpts...)
+	}
+
+	// The issuance of the configured asset groups is cross-checked
+	// against the universe servers of the federation.
+	var issuanceMonitor *universe.IssuanceMonitor
+	if len(cfg.Universe.IssuanceCheckGroups) > 0 {
+		groupKeys := make(
+			[]*btcec.PublicKey, 0,
+			len(cfg.Universe.IssuanceCheckGroups),
+		)
+		for _, keyStr := range cfg.Universe.IssuanceCheckGroups {
+			groupKeyBytes, err := hex.DecodeString(keyStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid issuance "+
+					"check group key: %w", err)
+			}
+
+			groupKey, err := btcec.ParsePubKey(groupKeyBytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid issuance "+
+					"check group key: %w", err)
+			}
+
+			groupKeys = append(groupKeys, groupKey)
+		}
+
+		checkInterval := cfg.Universe.IssuanceCheckInterval
+		issuanceMonitor, err = universe.NewIssuanceMonitor(
+			&universe.IssuanceMonitorConfig{
+				Groups:              groupKeys,
+				CheckInterval:       checkInterval,
+				LocalIssuance:       uniArchive,
+				FederationDB:        federationDB,
+				NewRemoteDiffEngine: newRemoteDiffEngine,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create issuance "+
+				"monitor: %w", err)
+		}
+	}
+
+	// The sync diff events are numbered, so subscribers can catch up on
+	// events they missed while connected to this instance. We continue the
+	// sequence where we left off before the last restart.
+	eventSequences := tapdb.NewEventSequenceStore(
+		tapdb.NewTransactionExecutor(
+			db, func(tx *sql.Tx) tapdb.EventSequences {
+				return db.WithTx(tx)
+			},
+		),
+	)
+	syncEventSeq, err := eventSequences.FetchNextSeq(
+		context.Background(), universe.SyncEventStream,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	universeSyncer := universe.NewSimpleSyncer(universe.SimpleSyncCfg{
 		LocalDiffEngine:     uniArchive,
-		NewRemoteDiffEngine: tap.NewRpcUniverseDiff,
+		NewRemoteDiffEngine: newRemoteDiffEngine,
 		LocalRegistrar:      uniArchive,
 		SyncBatchSize:       defaultUniverseSyncBatchSize,
+		EventSequences:      eventSequences,
+		EventStartSeq:       syncEventSeq,
 	})
 
 	var runtimeIDBytes [8]byte
@@ -382,24 +520,36 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 	}
 
 	runtimeID := int64(binary.BigEndian.Uint64(runtimeIDBytes[:]))
+	newRemoteRegistrar := func(
+		addr universe.ServerAddr) (universe.Registrar, error) {
+
+		return tap.NewRpcUniverseRegistrar(addr, uniConnOpts...)
+	}
 	universeFederation := universe.NewFederationEnvoy(
 		universe.FederationConfig{
 			FederationDB:            federationDB,
 			UniverseSyncer:          universeSyncer,
 			LocalRegistrar:          uniArchive,
 			SyncInterval:            cfg.Universe.SyncInterval,
-			NewRemoteRegistrar:      tap.NewRpcUniverseRegistrar,
+			NewRemoteRegistrar:      newRemoteRegistrar,
 			StaticFederationMembers: federationMembers,
 			ServerChecker: func(addr universe.ServerAddr) error {
 				return tap.CheckFederationServer(
 					runtimeID, universe.DefaultTimeout,
-					addr,
+					addr, uniConnOpts...,
 				)
 			},
 			ErrChan: mainErrChan,
 		},
 	)
 
+	anchorOutputAllowList, err := cfg.Wallet.AnchorOutputAllowList(
+		&cfg.ActiveNetParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	addrBookConfig := address.BookConfig{
 		Store:        tapdbAddrBook,
 		Syncer:       universeFederation,
@@ -437,6 +587,7 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 		UniverseRpcCfg: cfg.UniverseRpcCourier,
 		TransferLog:    assetStore,
 		LocalArchive:   proofArchive,
+		FileLimits:     cfg.ProofLimits.FileLimits(),
 	})
 
 	multiNotifier := proof.NewMultiArchiveNotifier(assetStore, multiverse)
@@ -529,9 +680,14 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 
 	// Setup supply syncer.
 	supplySyncerStore := tapdb.NewSupplySyncerStore(uniDB)
+	newSupplySyncClient := func(addr universe.ServerAddr) (
+		supplyverifier.UniverseClient, error) {
+
+		return tap.NewRpcSupplySync(addr, uniConnOpts...)
+	}
 	supplySyncer := supplyverifier.NewSupplySyncer(
 		supplyverifier.SupplySyncerConfig{
-			ClientFactory:          tap.NewRpcSupplySync,
+			ClientFactory:          newSupplySyncClient,
 			Store:                  supplySyncerStore,
 			UniverseFederationView: federationDB,
 		},
@@ -585,6 +741,13 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 	porterProofReader := proof.NewMultiArchiveNotifier(
 		assetStore, multiverse, proofFileStore,
 	)
+	sendBlocklist := tapfreighter.NewBlocklist(cfg.SendBlocklistAuditor)
+	htlcWatcher := tapfreighter.NewHtlcWatcher(
+		&tapfreighter.HtlcWatcherConfig{
+			ChainBridge:   chainBridge,
+			SpendNotifier: lndFsmDaemonAdapters,
+		},
+	)
 	chainPorter := tapfreighter.NewChainPorter(
 		&tapfreighter.ChainPorterConfig{
 			ChainParams:            tapChainParams,
@@ -604,9 +767,61 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 			ErrChan:                mainErrChan,
 			BurnCommitter:          supplyCommitManager,
 			DelegationKeyChecker:   addrBook,
+			Blocklist:              sendBlocklist,
+			SpendNotifier:          lndFsmDaemonAdapters,
 		},
 	)
 
+	// The inactivity sweeper is an explicit opt-in, as it sends all assets
+	// of the configured asset IDs or groups away from this node. Its start
+	// time is persisted, so restarts don't postpone a sweep. If it is
+	// disabled, we clear the start time, so enabling it again starts a new
+	// inactivity period.
+	sweepStartStore := tapdb.NewInactivitySweeperStore(
+		tapdb.NewTransactionExecutor(
+			db, func(tx *sql.Tx) tapdb.InactivityStart {
+				return db.WithTx(tx)
+			},
+		),
+	)
+	var inactivitySweeper *tapfreighter.InactivitySweeper
+	sweepCfg := cfg.Wallet.InactivitySweep
+	if sweepCfg != nil && sweepCfg.Enable {
+		recoveryAddrs := make(
+			[]*address.Tap, len(sweepCfg.RecoveryAddrs),
+		)
+		for idx, addrStr := range sweepCfg.RecoveryAddrs {
+			recoveryAddrs[idx], err = address.DecodeAddress(
+				addrStr, &tapChainParams,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("invalid inactivity "+
+					"sweep recovery address: %w", err)
+			}
+		}
+
+		inactivitySweeper, err = tapfreighter.NewInactivitySweeper(
+			&tapfreighter.InactivitySweeperConfig{
+				RecoveryAddrs: recoveryAddrs,
+				Period:        sweepCfg.Period,
+				CheckInterval: sweepCfg.CheckInterval,
+				Porter:        chainPorter,
+				AddrEvents:    addrBook,
+				CoinLister:    assetStore,
+				StartStore:    sweepStartStore,
+				Clock:         defaultClock,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := sweepStartStore.ClearStartTime(context.Background())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	auxFundingController := tapchannel.NewFundingController(
 		tapchannel.FundingControllerCfg{
 			HeaderVerifier:     headerVerifier,
@@ -679,6 +894,44 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 		},
 	)
 
+	// Transfer metadata received with V2 address transfers is stored with
+	// the corresponding address event.
+	storeTransferMetadata := func(ctx context.Context,
+		event *address.Event, metadata []byte) error {
+
+		return addrBook.SetEventTransferMetadata(
+			ctx, event.ID, metadata,
+		)
+	}
+
+	assetCustodian := tapgarden.NewCustodian(&tapgarden.CustodianConfig{
+		ChainParams:             &tapChainParams,
+		WalletAnchor:            walletAnchor,
+		ChainBridge:             chainBridge,
+		GroupVerifier:           groupVerifier,
+		AddrBook:                addrBook,
+		Signer:                  lndServices.Signer,
+		ProofArchive:            proofArchive,
+		ProofNotifier:           multiNotifier,
+		ErrChan:                 mainErrChan,
+		ProofCourierDispatcher:  proofCourierDispatcher,
+		MboxBackoffCfg:          cfg.UniverseRpcCourier.BackoffCfg,
+		ProofRetrievalDelay:     cfg.CustodianProofRetrievalDelay,
+		ProofWatcher:            reOrgWatcher,
+		IgnoreChecker:           ignoreCheckerOpt,
+		TransferMetadataHandler: storeTransferMetadata,
+	})
+
+	invoiceManager := tapgarden.NewInvoiceManager(
+		&tapgarden.InvoiceManagerConfig{
+			InvoiceStore:  tapdbAddrBook,
+			AddrEvents:    addrBook,
+			ReceiveEvents: assetCustodian,
+			Clock:         defaultClock,
+			ErrChan:       mainErrChan,
+		},
+	)
+
 	// nolint: lll
 	return &tap.Config{
 		DebugLevel:            cfg.DebugLevel,
@@ -710,25 +963,14 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 			ProofUpdates: proofArchive,
 			ErrChan:      mainErrChan,
 		}),
-		AssetCustodian: tapgarden.NewCustodian(&tapgarden.CustodianConfig{
-			ChainParams:            &tapChainParams,
-			WalletAnchor:           walletAnchor,
-			ChainBridge:            chainBridge,
-			GroupVerifier:          groupVerifier,
-			AddrBook:               addrBook,
-			Signer:                 lndServices.Signer,
-			ProofArchive:           proofArchive,
-			ProofNotifier:          multiNotifier,
-			ErrChan:                mainErrChan,
-			ProofCourierDispatcher: proofCourierDispatcher,
-			MboxBackoffCfg:         cfg.UniverseRpcCourier.BackoffCfg,
-			ProofRetrievalDelay:    cfg.CustodianProofRetrievalDelay,
-			ProofWatcher:           reOrgWatcher,
-			IgnoreChecker:          ignoreCheckerOpt,
-		}),
+		AssetCustodian:           assetCustodian,
+		InvoiceManager:           invoiceManager,
 		ChainBridge:              chainBridge,
 		AddrBook:                 addrBook,
 		AddrBookDisableSyncer:    cfg.AddrBook.DisableSyncer,
+		ProofFileLimits:          cfg.ProofLimits.FileLimits(),
+		AnchorOutputAllowList:    anchorOutputAllowList,
+		ChangeAddressType:        changeType,
 		DefaultProofCourierAddr:  proofCourierAddr,
 		ProofArchive:             proofArchive,
 		AssetWallet:              assetWallet,
@@ -742,6 +984,12 @@ func genServerConfig(cfg *Config, cfgLogger btclog.Logger,
 		UniverseSyncer:           universeSyncer,
 		UniverseFederation:       universeFederation,
 		UniFedSyncAllAssets:      cfg.Universe.SyncAllAssets,
+		UniverseConnOpts:         uniConnOpts,
+		DbSnapshotter:            dbSnapshotter,
+		InactivitySweeper:        inactivitySweeper,
+		SendBlocklist:            sendBlocklist,
+		HtlcWatcher:              htlcWatcher,
+		IssuanceMonitor:          issuanceMonitor,
 		UniverseStats:            universeStats,
 		UniversePublicAccess:     universePublicAccess,
 		UniverseQueriesPerSecond: cfg.Universe.UniverseQueriesPerSecond,
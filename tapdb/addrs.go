@@ -65,6 +65,10 @@ type (
 	// AddrEvent is a type alias for fetching an address event row.
 	AddrEvent = sqlc.FetchAddrEventRow
 
+	// AddrEventTransferMetadata is a type alias for setting the transfer
+	// metadata of an address event.
+	AddrEventTransferMetadata = sqlc.SetAddrEventTransferMetadataParams
+
 	// AddrEventOutput is a type alias for fetching the outputs of an
 	// address event.
 	AddrEventOutput = sqlc.FetchAddrEventOutputsRow
@@ -105,6 +109,21 @@ type (
 	// AllAssetMetaRow is a type alias for fetching all asset metadata
 	// records.
 	AllAssetMetaRow = sqlc.FetchAllAssetMetaRow
+
+	// NewAssetInvoice is a type alias for the params to create a new asset
+	// invoice.
+	NewAssetInvoice = sqlc.InsertAssetInvoiceParams
+
+	// AssetInvoiceUpdate is a type alias for the params to update an asset
+	// invoice.
+	AssetInvoiceUpdate = sqlc.UpdateAssetInvoiceParams
+
+	// AssetInvoiceQuery is a type alias for a query into the set of asset
+	// invoices.
+	AssetInvoiceQuery = sqlc.QueryAssetInvoicesParams
+
+	// AssetInvoiceRow is a type alias for a single asset invoice row.
+	AssetInvoiceRow = sqlc.QueryAssetInvoicesRow
 )
 
 var (
@@ -184,6 +203,11 @@ type AddrBook interface {
 	// key.
 	FetchAddrEvent(ctx context.Context, id int64) (AddrEvent, error)
 
+	// SetAddrEventTransferMetadata sets the transfer metadata of an
+	// address event.
+	SetAddrEventTransferMetadata(ctx context.Context,
+		arg AddrEventTransferMetadata) error
+
 	// FetchAddrEventOutputs returns the outputs of an address event.
 	FetchAddrEventOutputs(ctx context.Context,
 		addrEventID int64) ([]AddrEventOutput, error)
@@ -240,6 +264,19 @@ type AddrBook interface {
 	// address version.
 	QueryLastEventHeight(ctx context.Context,
 		version int16) (int64, error)
+
+	// InsertAssetInvoice inserts a new asset invoice and returns its
+	// primary key.
+	InsertAssetInvoice(ctx context.Context, arg NewAssetInvoice) (int64,
+		error)
+
+	// UpdateAssetInvoice updates the status of an existing asset invoice.
+	UpdateAssetInvoice(ctx context.Context, arg AssetInvoiceUpdate) error
+
+	// QueryAssetInvoices returns all asset invoices matching the given
+	// query.
+	QueryAssetInvoices(ctx context.Context,
+		query AssetInvoiceQuery) ([]AssetInvoiceRow, error)
 }
 
 // AddrBookTxOptions defines the set of db txn options the AddrBook
@@ -772,6 +809,22 @@ func (t *TapAddressBook) SetAddrManaged(ctx context.Context,
 	})
 }
 
+// SetEventTransferMetadata stores the transfer metadata the sender attached to
+// the transfer of the address event with the given ID.
+func (t *TapAddressBook) SetEventTransferMetadata(ctx context.Context,
+	eventID int64, metadata []byte) error {
+
+	var writeTxOpts AddrBookTxOptions
+	return t.db.ExecTx(ctx, &writeTxOpts, func(db AddrBook) error {
+		return db.SetAddrEventTransferMetadata(
+			ctx, AddrEventTransferMetadata{
+				ID:               eventID,
+				TransferMetadata: metadata,
+			},
+		)
+	})
+}
+
 // InsertInternalKey inserts an internal key into the database to make sure it
 // is identified as a local key later on when importing proofs. The key can be
 // an internal key for an asset script key or the internal key of an anchor
@@ -1120,6 +1173,7 @@ func fetchEvent(ctx context.Context, db AddrBook, eventID int64,
 		ConfirmationHeight: uint32(dbEvent.ConfirmationHeight.Int32),
 		Outputs:            outputs,
 		HasAllProofs:       dbEvent.NumProofs == int64(len(outputs)),
+		TransferMetadata:   dbEvent.TransferMetadata,
 	}, nil
 }
 
@@ -1172,6 +1226,7 @@ func fetchEventByOutpoint(ctx context.Context, db AddrBook,
 		Outputs:            outputs,
 		ConfirmationHeight: uint32(dbEvent.ConfirmationHeight.Int32),
 		HasAllProofs:       dbEvent.NumProofs == int64(len(outputs)),
+		TransferMetadata:   dbEvent.TransferMetadata,
 	}, nil
 }
 
@@ -600,6 +600,31 @@ func TestAddrEventCreation(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, maxHeight, height)
 	}
+
+	// Transfer metadata can be stored for an event and is returned when
+	// querying the event.
+	metadata := []byte(`{"originator":"alice"}`)
+	err = addrBook.SetEventTransferMetadata(ctx, events[0].ID, metadata)
+	require.NoError(t, err)
+
+	dbEvent, err := addrBook.QueryEvent(
+		ctx, events[0].Addr, events[0].Outpoint,
+	)
+	require.NoError(t, err)
+	require.Equal(t, metadata, dbEvent.TransferMetadata)
+
+	allEvents, err := addrBook.QueryAddrEvents(
+		ctx, address.EventQueryParams{},
+	)
+	require.NoError(t, err)
+	for _, event := range allEvents {
+		if event.ID == events[0].ID {
+			require.Equal(t, metadata, event.TransferMetadata)
+			continue
+		}
+
+		require.Empty(t, event.TransferMetadata)
+	}
 }
 
 // TestAddressEventQuery tests that we're able to properly retrieve rows based
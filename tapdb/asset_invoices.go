@@ -0,0 +1,191 @@
+package tapdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/fn"
+)
+
+// A compile-time assertion to ensure TapAddressBook implements the
+// address.InvoiceStore interface.
+var _ address.InvoiceStore = (*TapAddressBook)(nil)
+
+// sqlInvoiceSettleTime returns the settle time of an invoice as a nullable SQL
+// time.
+func sqlInvoiceSettleTime(invoice *address.Invoice) sql.NullTime {
+	if invoice.SettleTime.IsZero() {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{
+		Time:  invoice.SettleTime.UTC(),
+		Valid: true,
+	}
+}
+
+// InsertInvoice inserts a new invoice and sets its ID. The invoice's address
+// must already be stored.
+func (t *TapAddressBook) InsertInvoice(ctx context.Context,
+	invoice *address.Invoice) error {
+
+	if invoice.Addr == nil {
+		return fmt.Errorf("invoice address is missing")
+	}
+
+	var writeTxOpts AddrBookTxOptions
+	return t.db.ExecTx(ctx, &writeTxOpts, func(db AddrBook) error {
+		row, err := db.QueryAddr(ctx, SingleAddrQuery{
+			TaprootOutputKey: schnorr.SerializePubKey(
+				&invoice.Addr.TaprootOutputKey,
+			),
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return address.ErrNoAddr
+
+		case err != nil:
+			return err
+		}
+
+		invoiceID, err := db.InsertAssetInvoice(ctx, NewAssetInvoice{
+			AddrID:         row.Addr.ID,
+			Amount:         int64(invoice.Amount),
+			Memo:           invoice.Memo,
+			CreationTime:   invoice.CreationTime.UTC(),
+			ExpiryTime:     invoice.Expiry.UTC(),
+			Status:         int16(invoice.Status),
+			AmountReceived: int64(invoice.AmountReceived),
+			SettleTime:     sqlInvoiceSettleTime(invoice),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to insert invoice: %w", err)
+		}
+
+		invoice.ID = invoiceID
+
+		return nil
+	})
+}
+
+// UpdateInvoice updates the status, received amount and settle time of an
+// existing invoice.
+func (t *TapAddressBook) UpdateInvoice(ctx context.Context,
+	invoice *address.Invoice) error {
+
+	var writeTxOpts AddrBookTxOptions
+	return t.db.ExecTx(ctx, &writeTxOpts, func(db AddrBook) error {
+		return db.UpdateAssetInvoice(ctx, AssetInvoiceUpdate{
+			Status:         int16(invoice.Status),
+			AmountReceived: int64(invoice.AmountReceived),
+			SettleTime:     sqlInvoiceSettleTime(invoice),
+			ID:             invoice.ID,
+		})
+	})
+}
+
+// QueryInvoices returns all invoices that match the given query, ordered by
+// their ID.
+func (t *TapAddressBook) QueryInvoices(ctx context.Context,
+	query address.InvoiceQuery) ([]*address.Invoice, error) {
+
+	sqlQuery := AssetInvoiceQuery{
+		ID: fn.MapOptionZ(query.ID, func(id int64) sql.NullInt64 {
+			return sqlInt64(id)
+		}),
+		Status: fn.MapOptionZ(
+			query.Status,
+			func(status address.InvoiceStatus) sql.NullInt16 {
+				return sqlInt16(status)
+			},
+		),
+		TaprootOutputKey: query.AddrTaprootOutputKey,
+	}
+
+	var (
+		invoices []*address.Invoice
+		readOpts = NewAddrBookReadTx()
+	)
+	err := t.db.ExecTx(ctx, &readOpts, func(db AddrBook) error {
+		rows, err := db.QueryAssetInvoices(ctx, sqlQuery)
+		if err != nil {
+			return fmt.Errorf("unable to query invoices: %w", err)
+		}
+
+		// Multiple invoices can be paid to the same address, so we
+		// only fetch each address once.
+		addrs := make(map[string]*address.AddrWithKeyInfo)
+		invoices = make([]*address.Invoice, 0, len(rows))
+		for _, row := range rows {
+			addrKey := string(row.TaprootOutputKey)
+			addr, ok := addrs[addrKey]
+			if !ok {
+				var err error
+				addr, err = fetchInvoiceAddr(
+					ctx, db, t.params, row.TaprootOutputKey,
+				)
+				if err != nil {
+					return err
+				}
+				addrs[addrKey] = addr
+			}
+
+			var settleTime time.Time
+			if row.SettleTime.Valid {
+				settleTime = row.SettleTime.Time.UTC()
+			}
+
+			status := address.InvoiceStatus(row.Status)
+
+			invoices = append(invoices, &address.Invoice{
+				ID:             row.ID,
+				Addr:           addr,
+				Amount:         uint64(row.Amount),
+				Memo:           row.Memo,
+				CreationTime:   row.CreationTime.UTC(),
+				Expiry:         row.ExpiryTime.UTC(),
+				Status:         status,
+				AmountReceived: uint64(row.AmountReceived),
+				SettleTime:     settleTime,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// fetchInvoiceAddr fetches and parses the address with the given Taproot
+// output key.
+func fetchInvoiceAddr(ctx context.Context, db AddrBook,
+	params *address.ChainParams,
+	taprootOutputKey []byte) (*address.AddrWithKeyInfo, error) {
+
+	row, err := db.QueryAddr(ctx, SingleAddrQuery{
+		TaprootOutputKey: taprootOutputKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query invoice address: %w",
+			err)
+	}
+
+	addr, err := parseAddr(
+		ctx, db, params, row.Addr, row.ScriptKey, row.InternalKey,
+		row.InternalKey_2,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse invoice address: %w",
+			err)
+	}
+
+	return addr, nil
+}
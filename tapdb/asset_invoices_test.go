@@ -0,0 +1,158 @@
+package tapdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAssetInvoices tests that asset invoices can be inserted, updated and
+// queried.
+func TestAssetInvoices(t *testing.T) {
+	t.Parallel()
+
+	testClock := clock.NewTestClock(time.Now())
+	addrBook, _ := newAddrBook(t, testClock)
+	ctx := context.Background()
+
+	var writeTxOpts AddrBookTxOptions
+
+	// We first need two addresses to create invoices for. Their amount
+	// must be large enough to allow for a partial payment.
+	const numAddrs = 2
+	addrVersion := address.RandVersion()
+	proofCourierAddr := address.RandProofCourierAddrForVersion(
+		t, addrVersion,
+	)
+	addrs := make([]address.AddrWithKeyInfo, numAddrs)
+	for i := 0; i < numAddrs; i++ {
+		addr, assetGen, assetGroup := address.RandAddrWithVersion(
+			t, chainParams, proofCourierAddr, addrVersion,
+		)
+		for addr.Amount < 2 {
+			addr, assetGen, assetGroup =
+				address.RandAddrWithVersion(
+					t, chainParams, proofCourierAddr,
+					addrVersion,
+				)
+		}
+
+		addrs[i] = *addr
+
+		err := addrBook.db.ExecTx(
+			ctx, &writeTxOpts,
+			insertFullAssetGen(ctx, assetGen, assetGroup),
+		)
+		require.NoError(t, err)
+	}
+	require.NoError(t, addrBook.InsertAddrs(ctx, addrs...))
+
+	// Invoices for unknown addresses are rejected.
+	unknownAddr, _, _ := address.RandAddrWithVersion(
+		t, chainParams, proofCourierAddr, addrVersion,
+	)
+	unknownInvoice, err := address.NewInvoice(
+		unknownAddr, 0, "", testClock.Now(), time.Hour,
+	)
+	require.NoError(t, err)
+	err = addrBook.InsertInvoice(ctx, unknownInvoice)
+	require.ErrorIs(t, err, address.ErrNoAddr)
+
+	// Create two invoices for the first address and one for the second.
+	now := testClock.Now()
+	invoices := make([]*address.Invoice, 3)
+	for idx, addr := range []*address.AddrWithKeyInfo{
+		&addrs[0], &addrs[0], &addrs[1],
+	} {
+		invoices[idx], err = address.NewInvoice(
+			addr, 0, "invoice memo", now, time.Hour,
+		)
+		require.NoError(t, err)
+		require.NoError(t, addrBook.InsertInvoice(ctx, invoices[idx]))
+		require.NotZero(t, invoices[idx].ID)
+	}
+
+	assertInvoices := func(query address.InvoiceQuery,
+		expected ...*address.Invoice) {
+
+		t.Helper()
+
+		dbInvoices, err := addrBook.QueryInvoices(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, dbInvoices, len(expected))
+
+		for idx := range expected {
+			assertEqualInvoice(t, expected[idx], dbInvoices[idx])
+		}
+	}
+
+	assertInvoices(address.InvoiceQuery{}, invoices...)
+	assertInvoices(address.InvoiceQuery{
+		ID: fn.Some(invoices[1].ID),
+	}, invoices[1])
+	assertInvoices(address.InvoiceQuery{
+		AddrTaprootOutputKey: schnorr.SerializePubKey(
+			&addrs[0].TaprootOutputKey,
+		),
+	}, invoices[0], invoices[1])
+
+	// Pay the first invoice and partially pay the second one.
+	require.True(t, invoices[0].UpdateAmountReceived(
+		invoices[0].Amount, now,
+	))
+	require.True(t, invoices[1].UpdateAmountReceived(
+		invoices[1].Amount-1, now,
+	))
+	require.NoError(t, addrBook.UpdateInvoice(ctx, invoices[0]))
+	require.NoError(t, addrBook.UpdateInvoice(ctx, invoices[1]))
+
+	// Let the last invoice expire.
+	require.True(t, invoices[2].UpdateExpiry(now.Add(time.Hour)))
+	require.NoError(t, addrBook.UpdateInvoice(ctx, invoices[2]))
+
+	assertInvoices(address.InvoiceQuery{}, invoices...)
+	assertInvoices(address.InvoiceQuery{
+		Status: fn.Some(address.InvoiceStatusPaid),
+	}, invoices[0])
+	assertInvoices(address.InvoiceQuery{
+		Status: fn.Some(address.InvoiceStatusUnderpaid),
+	}, invoices[1])
+	assertInvoices(address.InvoiceQuery{
+		Status: fn.Some(address.InvoiceStatusExpired),
+	}, invoices[2])
+	assertInvoices(address.InvoiceQuery{
+		Status: fn.Some(address.InvoiceStatusOpen),
+	})
+}
+
+// assertEqualInvoice makes sure the given actual invoice matches the expected
+// one.
+func assertEqualInvoice(t *testing.T, expected, actual *address.Invoice) {
+	t.Helper()
+
+	require.Equal(t, expected.ID, actual.ID)
+	require.Equal(t, expected.Amount, actual.Amount)
+	require.Equal(t, expected.Memo, actual.Memo)
+	require.Equal(t, expected.Status, actual.Status)
+	require.Equal(t, expected.AmountReceived, actual.AmountReceived)
+	require.Equal(
+		t, expected.CreationTime.Unix(), actual.CreationTime.Unix(),
+	)
+	require.Equal(t, expected.Expiry.Unix(), actual.Expiry.Unix())
+	require.Equal(
+		t, expected.SettleTime.IsZero(), actual.SettleTime.IsZero(),
+	)
+	if !expected.SettleTime.IsZero() {
+		require.Equal(
+			t, expected.SettleTime.Unix(), actual.SettleTime.Unix(),
+		)
+	}
+
+	assertEqualAddr(t, *expected.Addr, *actual.Addr)
+}
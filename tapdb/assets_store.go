@@ -2765,6 +2765,7 @@ func insertAssetTransferOutput(ctx context.Context, q ActiveAssetsStore,
 		ProofDeliveryComplete: proofDeliveryComplete,
 		Position:              position,
 		TapAddress:            sqlStr(output.TapAddress),
+		TransferMetadata:      output.TransferMetadata,
 	}
 
 	// There might not have been a split, so we can't rely on the split root
@@ -2895,6 +2896,7 @@ func fetchAssetTransferOutputs(ctx context.Context, q ActiveAssetsStore,
 			ProofDeliveryComplete: proofDeliveryComplete,
 			Position:              uint64(dbOut.Position),
 			TapAddress:            dbOut.TapAddress.String,
+			TransferMetadata:      dbOut.TransferMetadata,
 		}
 
 		err = readOutPoint(
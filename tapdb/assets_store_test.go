@@ -2417,8 +2417,9 @@ func TestTransferOutputProofDeliveryStatus(t *testing.T) {
 		SplitCommitment: nil,
 	}
 
-	// Mock proof courier address.
+	// Mock proof courier address and transfer metadata.
 	proofCourierAddrBytes := []byte("universerpc://localhost:10009")
+	transferMetadata := []byte(`{"beneficiary":"bob"}`)
 
 	// Fetch the asset that was previously generated.
 	allAssets, err := assetsStore.FetchAllAssets(ctx, true, false, nil)
@@ -2478,6 +2479,7 @@ func TestTransferOutputProofDeliveryStatus(t *testing.T) {
 			ProofCourierAddr:      proofCourierAddrBytes,
 			ProofDeliveryComplete: fn.Some[bool](false),
 			Position:              0,
+			TransferMetadata:      transferMetadata,
 		}, {
 			Anchor: tapfreighter.Anchor{
 				Value: 1000,
@@ -2566,6 +2568,10 @@ func TestTransferOutputProofDeliveryStatus(t *testing.T) {
 		t, sql.NullBool{}, transferOutputs[1].ProofDeliveryComplete,
 	)
 
+	// Only the first output carries transfer metadata.
+	require.Equal(t, transferMetadata, transferOutputs[0].TransferMetadata)
+	require.Empty(t, transferOutputs[1].TransferMetadata)
+
 	// We will now set the status of the transfer output proof to
 	// "delivered".
 	//
@@ -0,0 +1,102 @@
+package tapdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tapdb/sqlc"
+)
+
+// NewEventSequence is used to insert or update the next sequence number of an
+// event stream.
+type NewEventSequence = sqlc.UpsertEventSequenceParams
+
+// EventSequences is the set of queries used to persist the sequence numbers
+// of event streams.
+type EventSequences interface {
+	// FetchEventSequence returns the next sequence number of the given
+	// event stream.
+	FetchEventSequence(ctx context.Context, streamName string) (int64,
+		error)
+
+	// UpsertEventSequence inserts or updates the next sequence number of
+	// an event stream.
+	UpsertEventSequence(ctx context.Context, arg NewEventSequence) error
+}
+
+// BatchedEventSequences is a version of EventSequences that's capable of
+// batched database operations.
+type BatchedEventSequences interface {
+	EventSequences
+
+	BatchedTx[EventSequences]
+}
+
+// EventSequenceStore is a database backed implementation of the
+// fn.SequenceStore interface.
+type EventSequenceStore struct {
+	db BatchedEventSequences
+}
+
+// NewEventSequenceStore creates a new event sequence store from the given
+// database handle.
+func NewEventSequenceStore(db BatchedEventSequences) *EventSequenceStore {
+	return &EventSequenceStore{
+		db: db,
+	}
+}
+
+// A compile-time assertion to ensure EventSequenceStore implements the
+// fn.SequenceStore interface.
+var _ fn.SequenceStore = (*EventSequenceStore)(nil)
+
+// FetchNextSeq returns the sequence number of the next event of the given
+// stream, or zero if no event was published yet.
+//
+// NOTE: This is part of the fn.SequenceStore interface.
+func (e *EventSequenceStore) FetchNextSeq(ctx context.Context,
+	stream string) (uint64, error) {
+
+	var nextSeq int64
+	readOpts := ReadTxOption()
+	err := e.db.ExecTx(ctx, readOpts, func(q EventSequences) error {
+		var err error
+		nextSeq, err = q.FetchEventSequence(ctx, stream)
+		return err
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+
+	case err != nil:
+		return 0, fmt.Errorf("unable to fetch sequence of event "+
+			"stream %s: %w", stream, err)
+	}
+
+	return uint64(nextSeq), nil
+}
+
+// StoreNextSeq stores the sequence number of the next event of the given
+// stream.
+//
+// NOTE: This is part of the fn.SequenceStore interface.
+func (e *EventSequenceStore) StoreNextSeq(ctx context.Context, stream string,
+	nextSeq uint64) error {
+
+	writeOpts := WriteTxOption()
+	return e.db.ExecTx(ctx, writeOpts, func(q EventSequences) error {
+		err := q.UpsertEventSequence(ctx, NewEventSequence{
+			StreamName: stream,
+			NextSeq:    int64(nextSeq),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to store sequence of event "+
+				"stream %s: %w", stream, err)
+		}
+
+		return nil
+	})
+}
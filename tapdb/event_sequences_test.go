@@ -0,0 +1,41 @@
+package tapdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventSequenceStore tests that the next sequence number of an event
+// stream can be stored and updated.
+func TestEventSequenceStore(t *testing.T) {
+	t.Parallel()
+
+	db := NewTestDB(t)
+	store := NewEventSequenceStore(NewTransactionExecutor(
+		db, func(tx *sql.Tx) EventSequences {
+			return db.WithTx(tx)
+		},
+	))
+
+	ctx := context.Background()
+
+	// A stream without any events starts at zero.
+	nextSeq, err := store.FetchNextSeq(ctx, "stream")
+	require.NoError(t, err)
+	require.Zero(t, nextSeq)
+
+	require.NoError(t, store.StoreNextSeq(ctx, "stream", 5))
+	require.NoError(t, store.StoreNextSeq(ctx, "other", 2))
+	require.NoError(t, store.StoreNextSeq(ctx, "stream", 7))
+
+	nextSeq, err = store.FetchNextSeq(ctx, "stream")
+	require.NoError(t, err)
+	require.EqualValues(t, 7, nextSeq)
+
+	nextSeq, err = store.FetchNextSeq(ctx, "other")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, nextSeq)
+}
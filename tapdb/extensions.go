@@ -0,0 +1,123 @@
+package tapdb
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/lightninglabs/taproot-assets/tapdb/sqlc"
+)
+
+var (
+	// extensionNamespaceRegex is the regular expression a namespace of an
+	// extension must match. The namespace is used as a prefix for the
+	// migrations table name, so we only allow characters that are safe to
+	// use in an unquoted SQL identifier.
+	extensionNamespaceRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// Extension describes a set of custom tables that an application embedding
+// tapd wants to maintain in the same database as tapd itself. The migrations
+// of an extension are tracked in their own migrations table, so they never
+// interfere with the version of tapd's own schema.
+type Extension struct {
+	// Namespace is the unique name of the extension. The migrations table
+	// of the extension is called <namespace>_schema_migrations. It must
+	// start with a lowercase letter and only contain lowercase letters,
+	// digits and underscores.
+	Namespace string
+
+	// Migrations is the file system that contains the migration files of
+	// the extension. The files must follow the same naming scheme as
+	// tapd's own migrations (e.g. 000001_my_table.up.sql). The SQL should
+	// be written for SQLite, the same replacements as for tapd's own
+	// migrations are applied for Postgres.
+	Migrations fs.FS
+
+	// Path is the directory within the Migrations file system that
+	// contains the migration files.
+	Path string
+
+	// LatestVersion is the latest migration version of the extension. The
+	// migrations will refuse to run if the database was already migrated
+	// to a newer version, which prevents an older version of the
+	// embedding application from running against a newer schema.
+	LatestVersion uint
+}
+
+// Validate makes sure the extension is well-formed.
+func (e *Extension) Validate() error {
+	if !extensionNamespaceRegex.MatchString(e.Namespace) {
+		return fmt.Errorf("invalid extension namespace '%s'",
+			e.Namespace)
+	}
+
+	if e.Migrations == nil {
+		return fmt.Errorf("extension '%s' has no migrations",
+			e.Namespace)
+	}
+
+	if e.LatestVersion == 0 {
+		return fmt.Errorf("extension '%s' has no latest version",
+			e.Namespace)
+	}
+
+	return nil
+}
+
+// MigrationsTable returns the name of the table the migration versions of the
+// extension are tracked in.
+func (e *Extension) MigrationsTable() string {
+	return e.Namespace + "_schema_migrations"
+}
+
+// applyExtensionMigrations applies all migrations of the given extension to
+// the database using the given driver.
+func applyExtensionMigrations(ext Extension, driver database.Driver,
+	dbName string, replacements map[string]string) error {
+
+	opts := defaultMigrateOptions()
+	WithLatestVersion(ext.LatestVersion)(opts)
+
+	log.Infof("Applying migrations of extension '%s'", ext.Namespace)
+
+	extFS := newReplacerFS(ext.Migrations, replacements)
+	err := applyMigrations(
+		extFS, driver, ext.Path, dbName, TargetLatest, opts,
+	)
+	if err != nil {
+		return fmt.Errorf("error applying migrations of extension "+
+			"'%s': %w", ext.Namespace, err)
+	}
+
+	return nil
+}
+
+// ExtensionQueries gives an extension access to both tapd's own queries and the
+// raw database transaction they are executed in. This allows an extension to
+// run its own statements against its custom tables atomically with tapd's
+// queries.
+type ExtensionQueries struct {
+	*sqlc.Queries
+
+	// Tx is the database transaction all queries are executed in.
+	Tx *sql.Tx
+}
+
+// NewExtensionExecutor creates a transaction executor for an extension that
+// shares the database handle, transaction retry logic and isolation level of
+// the given tapd database.
+func NewExtensionExecutor(db DatabaseBackend,
+	opts ...TxExecutorOption) *TransactionExecutor[*ExtensionQueries] {
+
+	return NewTransactionExecutor(
+		db, func(tx *sql.Tx) *ExtensionQueries {
+			return &ExtensionQueries{
+				Queries: db.WithTx(tx),
+				Tx:      tx,
+			}
+		}, opts...,
+	)
+}
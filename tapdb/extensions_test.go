@@ -0,0 +1,137 @@
+package tapdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/tapdb/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtensionMigrations tests that the migrations of an extension are
+// tracked independently of tapd's own migrations and that the extension can
+// use its tables atomically with tapd's queries.
+func TestExtensionMigrations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := NewTestDB(t)
+
+	migrations := fstest.MapFS{
+		"migrations/000001_widgets.up.sql": {
+			Data: []byte(`
+			CREATE TABLE IF NOT EXISTS widgets (
+				id INTEGER PRIMARY KEY,
+				key_id BIGINT NOT NULL
+					REFERENCES internal_keys(key_id),
+				name TEXT NOT NULL
+			);`),
+		},
+		"migrations/000001_widgets.down.sql": {
+			Data: []byte(`DROP TABLE IF EXISTS widgets;`),
+		},
+	}
+	ext := Extension{
+		Namespace:     "widgets",
+		Migrations:    migrations,
+		Path:          "migrations",
+		LatestVersion: 1,
+	}
+
+	// An invalid namespace or missing version should be rejected.
+	invalidExt := ext
+	invalidExt.Namespace = "Widgets; DROP TABLE assets"
+	err := db.ExecuteExtensionMigrations(invalidExt)
+	require.ErrorContains(t, err, "invalid extension namespace")
+
+	invalidExt = ext
+	invalidExt.LatestVersion = 0
+	err = db.ExecuteExtensionMigrations(invalidExt)
+	require.ErrorContains(t, err, "has no latest version")
+
+	// Applying the migrations should work, and doing it again should be a
+	// no-op.
+	require.NoError(t, db.ExecuteExtensionMigrations(ext))
+	require.NoError(t, db.ExecuteExtensionMigrations(ext))
+
+	// The extension can now use tapd's queries and its own tables within
+	// the same transaction.
+	executor := NewExtensionExecutor(db)
+	insertWidget := func(name string, bodyErr error) error {
+		return executor.ExecTx(
+			ctx, WriteTxOption(), func(q *ExtensionQueries) error {
+				keyID, err := q.UpsertInternalKey(
+					ctx, sqlc.UpsertInternalKeyParams{
+						RawKey: test.RandPubKey(t).
+							SerializeCompressed(),
+					},
+				)
+				if err != nil {
+					return err
+				}
+
+				_, err = q.Tx.ExecContext(ctx, fmt.Sprintf(
+					"INSERT INTO widgets (key_id, name) "+
+						"VALUES (%d, '%s')", keyID,
+					name,
+				))
+				if err != nil {
+					return err
+				}
+
+				return bodyErr
+			},
+		)
+	}
+	require.NoError(t, insertWidget("foo", nil))
+
+	// If the transaction fails, neither the internal key nor the widget
+	// should be persisted.
+	errAbort := errors.New("abort")
+	require.ErrorIs(t, insertWidget("bar", errAbort), errAbort)
+
+	var numWidgets, numKeys int
+	err = executor.ExecTx(
+		ctx, ReadTxOption(), func(q *ExtensionQueries) error {
+			keys, err := q.AllInternalKeys(ctx)
+			if err != nil {
+				return err
+			}
+			numKeys = len(keys)
+
+			return q.Tx.QueryRowContext(
+				ctx, "SELECT COUNT(*) FROM widgets",
+			).Scan(&numWidgets)
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, numWidgets)
+	require.Equal(t, 1, numKeys)
+
+	// Adding a second migration should apply it without touching tapd's
+	// own migration version.
+	migrations["migrations/000002_widget_color.up.sql"] = &fstest.MapFile{
+		Data: []byte(`ALTER TABLE widgets ADD COLUMN color TEXT;`),
+	}
+	migrations["migrations/000002_widget_color.down.sql"] = &fstest.MapFile{
+		Data: []byte(`ALTER TABLE widgets DROP COLUMN color;`),
+	}
+	ext.LatestVersion = 2
+	require.NoError(t, db.ExecuteExtensionMigrations(ext))
+
+	_, err = db.ExecContext(ctx, "UPDATE widgets SET color = 'blue'")
+	require.NoError(t, err)
+
+	// An older version of the extension must refuse to run against the
+	// newer schema.
+	ext.LatestVersion = 1
+	err = db.ExecuteExtensionMigrations(ext)
+	require.ErrorIs(t, err, ErrMigrationDowngrade)
+
+	// Finally, tapd's own migrations must still be at the latest version.
+	require.NoError(t, db.ExecuteMigrations(TargetLatest))
+}
@@ -0,0 +1,96 @@
+package tapdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lightninglabs/taproot-assets/tapfreighter"
+)
+
+// InactivityStart is the set of queries used to persist the start time of the
+// inactivity sweeper.
+type InactivityStart interface {
+	// InsertInactivitySweeperStart stores the start time of the
+	// inactivity sweeper, unless one is already stored.
+	InsertInactivitySweeperStart(ctx context.Context,
+		startTime time.Time) error
+
+	// FetchInactivitySweeperStart returns the stored start time of the
+	// inactivity sweeper.
+	FetchInactivitySweeperStart(ctx context.Context) (time.Time, error)
+
+	// DeleteInactivitySweeperStart removes the stored start time of the
+	// inactivity sweeper.
+	DeleteInactivitySweeperStart(ctx context.Context) error
+}
+
+// BatchedInactivityStart is a version of InactivityStart that's capable of
+// batched database operations.
+type BatchedInactivityStart interface {
+	InactivityStart
+
+	BatchedTx[InactivityStart]
+}
+
+// InactivitySweeperStore is a database backed implementation of the
+// tapfreighter.InactivityStartStore interface.
+type InactivitySweeperStore struct {
+	db BatchedInactivityStart
+}
+
+// NewInactivitySweeperStore creates a new inactivity sweeper store from the
+// given database handle.
+func NewInactivitySweeperStore(
+	db BatchedInactivityStart) *InactivitySweeperStore {
+
+	return &InactivitySweeperStore{
+		db: db,
+	}
+}
+
+// A compile-time assertion to ensure InactivitySweeperStore implements the
+// tapfreighter.InactivityStartStore interface.
+var _ tapfreighter.InactivityStartStore = (*InactivitySweeperStore)(nil)
+
+// FetchOrStoreStartTime returns the time the inactivity sweeper was first
+// started. If no start time is stored yet, the given time is stored and
+// returned.
+//
+// NOTE: This is part of the tapfreighter.InactivityStartStore interface.
+func (s *InactivitySweeperStore) FetchOrStoreStartTime(ctx context.Context,
+	now time.Time) (time.Time, error) {
+
+	var startTime time.Time
+	writeOpts := WriteTxOption()
+	err := s.db.ExecTx(ctx, writeOpts, func(q InactivityStart) error {
+		err := q.InsertInactivitySweeperStart(ctx, now.UTC())
+		if err != nil {
+			return err
+		}
+
+		startTime, err = q.FetchInactivitySweeperStart(ctx)
+		return err
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to fetch inactivity "+
+			"sweeper start time: %w", err)
+	}
+
+	return startTime, nil
+}
+
+// ClearStartTime removes the stored start time, so the inactivity period is
+// measured from the next start of the sweeper.
+func (s *InactivitySweeperStore) ClearStartTime(ctx context.Context) error {
+	writeOpts := WriteTxOption()
+	err := s.db.ExecTx(ctx, writeOpts, func(q InactivityStart) error {
+		return q.DeleteInactivitySweeperStart(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to clear inactivity sweeper start "+
+			"time: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package tapdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestInactivitySweeperStore tests that the start time of the inactivity
+// sweeper is only stored once and can be cleared.
+func TestInactivitySweeperStore(t *testing.T) {
+	t.Parallel()
+
+	db := NewTestDB(t)
+	store := NewInactivitySweeperStore(NewTransactionExecutor(
+		db, func(tx *sql.Tx) InactivityStart {
+			return db.WithTx(tx)
+		},
+	))
+
+	ctx := context.Background()
+	firstStart := time.Unix(1700000000, 0).UTC()
+
+	startTime, err := store.FetchOrStoreStartTime(ctx, firstStart)
+	require.NoError(t, err)
+	require.True(t, firstStart.Equal(startTime))
+
+	// A later start returns the first start time.
+	startTime, err = store.FetchOrStoreStartTime(
+		ctx, firstStart.Add(time.Hour),
+	)
+	require.NoError(t, err)
+	require.True(t, firstStart.Equal(startTime))
+
+	// Once cleared, the next start time is stored.
+	require.NoError(t, store.ClearStartTime(ctx))
+	require.NoError(t, store.ClearStartTime(ctx))
+
+	secondStart := firstStart.Add(2 * time.Hour)
+	startTime, err = store.FetchOrStoreStartTime(ctx, secondStart)
+	require.NoError(t, err)
+	require.True(t, secondStart.Equal(startTime))
+}
@@ -169,6 +169,29 @@ func (s *PostgresStore) ExecuteMigrations(target MigrationTarget,
 	)
 }
 
+// ExecuteExtensionMigrations applies all migrations of the given extension to
+// the Postgres database. The migration versions of the extension are tracked
+// in a separate table, so this can be called independently of tapd's own
+// migrations.
+func (s *PostgresStore) ExecuteExtensionMigrations(ext Extension) error {
+	if err := ext.Validate(); err != nil {
+		return err
+	}
+
+	driver, err := postgres_migrate.WithInstance(
+		s.DB, &postgres_migrate.Config{
+			MigrationsTable: ext.MigrationsTable(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error creating postgres migration: %w", err)
+	}
+
+	return applyExtensionMigrations(
+		ext, driver, s.cfg.DBName, postgresSchemaReplacements,
+	)
+}
+
 // NewTestPostgresDB is a helper function that creates a Postgres database for
 // testing.
 func NewTestPostgresDB(t testing.TB) *PostgresStore {
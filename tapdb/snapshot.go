@@ -0,0 +1,574 @@
+package tapdb
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SnapshotDbFileName is the name of the database file within a
+	// snapshot directory.
+	SnapshotDbFileName = "tapd.db"
+
+	// SnapshotProofsFileName is the name of the proof directory archive
+	// within a snapshot directory.
+	SnapshotProofsFileName = "proofs.tar.gz"
+
+	// SnapshotManifestFileName is the name of the file within a snapshot
+	// directory that contains the SHA-256 hashes of all other files of the
+	// snapshot.
+	SnapshotManifestFileName = "manifest.sha256"
+
+	// DefaultMaxSnapshots is the default number of snapshots to keep
+	// before the oldest one is removed.
+	DefaultMaxSnapshots = 7
+
+	// restoreTmpSuffix is appended to the database file and the proof
+	// directory to get the temporary paths a snapshot is restored to.
+	restoreTmpSuffix = ".restore"
+)
+
+// SnapshotConfig is the configuration for periodic snapshots of the SQLite
+// database and the proof directory.
+//
+//nolint:lll
+type SnapshotConfig struct {
+	Interval time.Duration `long:"interval" description:"The interval at which a snapshot of the database and the proof directory is created. Snapshots are disabled if set to zero. Valid time units are {s, m, h}."`
+
+	MaxSnapshots int `long:"max-snapshots" description:"The number of snapshots to keep. Once this number is exceeded, the oldest snapshot is removed."`
+
+	Dir string `long:"dir" description:"The directory the snapshots are written to. Each snapshot is stored in its own sub directory named after the Unix timestamp it was created at."`
+
+	RestoreFrom string `long:"restore-from" description:"The path of a snapshot to restore on startup, before the database is opened. The database file and the proof directory must not exist yet, so this option must be removed again once the snapshot was restored."`
+}
+
+// DefaultSnapshotConfig returns the default snapshot configuration, which has
+// snapshots disabled.
+func DefaultSnapshotConfig() *SnapshotConfig {
+	return &SnapshotConfig{
+		MaxSnapshots: DefaultMaxSnapshots,
+	}
+}
+
+// Snapshotter periodically creates snapshots of a SQLite database and the
+// proof directory, keeping a limited number of them around.
+type Snapshotter struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	cfg *SnapshotConfig
+
+	db *SqliteStore
+
+	proofDir string
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSnapshotter creates a new snapshotter for the given database and proof
+// directory.
+func NewSnapshotter(cfg *SnapshotConfig, db *SqliteStore,
+	proofDir string) *Snapshotter {
+
+	return &Snapshotter{
+		cfg:      cfg,
+		db:       db,
+		proofDir: proofDir,
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start starts the snapshot loop.
+func (s *Snapshotter) Start() error {
+	var startErr error
+	s.startOnce.Do(func() {
+		if s.cfg.Interval <= 0 {
+			startErr = fmt.Errorf("invalid snapshot interval %v",
+				s.cfg.Interval)
+			return
+		}
+
+		if err := os.MkdirAll(s.cfg.Dir, 0700); err != nil {
+			startErr = fmt.Errorf("unable to create snapshot "+
+				"dir: %w", err)
+			return
+		}
+
+		log.Infof("Starting database snapshotter (interval=%v, "+
+			"max_snapshots=%d, dir=%v)", s.cfg.Interval,
+			s.cfg.MaxSnapshots, s.cfg.Dir)
+
+		s.wg.Add(1)
+		go s.snapshotLoop()
+	})
+
+	return startErr
+}
+
+// Stop stops the snapshot loop.
+func (s *Snapshotter) Stop() error {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+		s.wg.Wait()
+	})
+
+	return nil
+}
+
+// snapshotLoop creates a new snapshot every interval and removes the snapshots
+// that exceed the maximum number of snapshots.
+func (s *Snapshotter) snapshotLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			snapshotPath, err := CreateSnapshot(
+				s.db, s.proofDir, s.cfg.Dir, time.Now(),
+			)
+			if err != nil {
+				log.Errorf("Unable to create snapshot: %v", err)
+				continue
+			}
+
+			log.Infof("Created snapshot at %v", snapshotPath)
+
+			err = RotateSnapshots(s.cfg.Dir, s.cfg.MaxSnapshots)
+			if err != nil {
+				log.Errorf("Unable to rotate snapshots: %v",
+					err)
+			}
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// CreateSnapshot creates a new snapshot of the given database and proof
+// directory in a sub directory of snapshotDir that is named after the Unix
+// timestamp of the given time. The snapshot consists of a consistent copy of
+// the database, a compressed archive of the proof directory and a manifest
+// with the SHA-256 hashes of both. The path of the new snapshot directory is
+// returned.
+func CreateSnapshot(db *SqliteStore, proofDir, snapshotDir string,
+	now time.Time) (string, error) {
+
+	snapshotPath := filepath.Join(
+		snapshotDir, strconv.FormatInt(now.Unix(), 10),
+	)
+
+	// We write the snapshot to a temporary directory first and only move
+	// it into place once it is complete, so an interrupted snapshot is
+	// never mistaken for a valid one.
+	tmpPath := snapshotPath + ".tmp"
+	if err := os.MkdirAll(tmpPath, 0700); err != nil {
+		return "", fmt.Errorf("unable to create snapshot dir: %w", err)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	// VACUUM INTO creates a transactionally consistent copy of the
+	// database, even while it is being written to.
+	dbPath := filepath.Join(tmpPath, SnapshotDbFileName)
+	if _, err := db.Exec("VACUUM INTO ?;", dbPath); err != nil {
+		return "", fmt.Errorf("unable to copy database: %w", err)
+	}
+
+	proofsPath := filepath.Join(tmpPath, SnapshotProofsFileName)
+	if err := archiveDir(proofDir, proofsPath); err != nil {
+		return "", fmt.Errorf("unable to archive proof dir: %w", err)
+	}
+
+	err := writeManifest(
+		tmpPath, SnapshotDbFileName, SnapshotProofsFileName,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, snapshotPath); err != nil {
+		return "", fmt.Errorf("unable to finalize snapshot: %w", err)
+	}
+
+	return snapshotPath, nil
+}
+
+// RotateSnapshots removes the oldest snapshots in the given directory until at
+// most maxSnapshots are left. A value of zero or less keeps all snapshots.
+func RotateSnapshots(snapshotDir string, maxSnapshots int) error {
+	if maxSnapshots <= 0 {
+		return nil
+	}
+
+	snapshots, err := ListSnapshots(snapshotDir)
+	if err != nil {
+		return err
+	}
+
+	for len(snapshots) > maxSnapshots {
+		if err := os.RemoveAll(snapshots[0]); err != nil {
+			return fmt.Errorf("unable to remove snapshot: %w", err)
+		}
+
+		log.Debugf("Removed old snapshot %v", snapshots[0])
+		snapshots = snapshots[1:]
+	}
+
+	return nil
+}
+
+// ListSnapshots returns the paths of all snapshots in the given directory,
+// ordered from oldest to newest.
+func ListSnapshots(snapshotDir string) ([]string, error) {
+	entries, err := os.ReadDir(snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot dir: %w", err)
+	}
+
+	timestamps := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// Anything that isn't named after a timestamp (e.g. an
+		// incomplete snapshot) is ignored.
+		timestamp, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	paths := make([]string, len(timestamps))
+	for idx, timestamp := range timestamps {
+		paths[idx] = filepath.Join(
+			snapshotDir, strconv.FormatInt(timestamp, 10),
+		)
+	}
+
+	return paths, nil
+}
+
+// VerifySnapshot makes sure all files of the given snapshot match the hashes
+// in its manifest.
+func VerifySnapshot(snapshotPath string) error {
+	manifestPath := filepath.Join(snapshotPath, SnapshotManifestFileName)
+	manifest, err := os.Open(manifestPath)
+	if err != nil {
+		return fmt.Errorf("unable to open manifest: %w", err)
+	}
+	defer manifest.Close()
+
+	expected := make(map[string]string)
+	scanner := bufio.NewScanner(manifest)
+	for scanner.Scan() {
+		hash, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return fmt.Errorf("invalid manifest line: %v",
+				scanner.Text())
+		}
+
+		expected[name] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read manifest: %w", err)
+	}
+
+	for _, name := range []string{
+		SnapshotDbFileName, SnapshotProofsFileName,
+	} {
+
+		expectedHash, ok := expected[name]
+		if !ok {
+			return fmt.Errorf("manifest is missing %v", name)
+		}
+
+		hash, err := hashFile(filepath.Join(snapshotPath, name))
+		if err != nil {
+			return err
+		}
+
+		if hash != expectedHash {
+			return fmt.Errorf("hash mismatch for %v: expected %v, "+
+				"got %v", name, expectedHash, hash)
+		}
+	}
+
+	return nil
+}
+
+// RestoreSnapshot verifies the given snapshot and restores the database to
+// dbFile and the proofs to proofDir. Neither the database file nor the proof
+// directory may exist yet, so existing data is never overwritten. Both are
+// restored to temporary paths first and only renamed into place once the
+// whole snapshot was restored, so a failed restore can simply be retried. This
+// must only be called while tapd is not running.
+func RestoreSnapshot(snapshotPath, dbFile, proofDir string) error {
+	if err := VerifySnapshot(snapshotPath); err != nil {
+		return fmt.Errorf("snapshot integrity check failed: %w", err)
+	}
+
+	if _, err := os.Stat(dbFile); !os.IsNotExist(err) {
+		return fmt.Errorf("database file %v already exists", dbFile)
+	}
+	if _, err := os.Stat(proofDir); !os.IsNotExist(err) {
+		return fmt.Errorf("proof dir %v already exists", proofDir)
+	}
+
+	// Any leftovers of a previous restore that failed are removed first.
+	// After a successful restore, the temporary paths no longer exist.
+	tmpDbFile := dbFile + restoreTmpSuffix
+	tmpProofDir := proofDir + restoreTmpSuffix
+	removeTmp := func() {
+		_ = os.Remove(tmpDbFile)
+		_ = os.RemoveAll(tmpProofDir)
+	}
+	removeTmp()
+	defer removeTmp()
+
+	err := copyFile(
+		filepath.Join(snapshotPath, SnapshotDbFileName), tmpDbFile,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to restore database: %w", err)
+	}
+
+	err = extractArchive(
+		filepath.Join(snapshotPath, SnapshotProofsFileName), tmpProofDir,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to restore proofs: %w", err)
+	}
+
+	if err := os.Rename(tmpProofDir, proofDir); err != nil {
+		return fmt.Errorf("unable to restore proofs: %w", err)
+	}
+
+	// The proofs are useless without the database, so we remove them
+	// again if the database can't be moved into place.
+	if err := os.Rename(tmpDbFile, dbFile); err != nil {
+		_ = os.RemoveAll(proofDir)
+		return fmt.Errorf("unable to restore database: %w", err)
+	}
+
+	return nil
+}
+
+// writeManifest writes a manifest with the SHA-256 hashes of the given files
+// to the given directory, in the format of the sha256sum tool.
+func writeManifest(dir string, fileNames ...string) error {
+	var manifest strings.Builder
+	for _, name := range fileNames {
+		hash, err := hashFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+
+		manifest.WriteString(fmt.Sprintf("%s  %s\n", hash, name))
+	}
+
+	err := os.WriteFile(
+		filepath.Join(dir, SnapshotManifestFileName),
+		[]byte(manifest.String()), 0600,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// hashFile returns the hex encoded SHA-256 hash of the given file.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %v: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("unable to hash %v: %w", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile copies the file at src to the new file dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// archiveDir writes all regular files of the given directory to a gzip
+// compressed tar archive at archivePath.
+func archiveDir(dir, archivePath string) error {
+	out, err := os.OpenFile(
+		archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600,
+	)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gzipWriter := gzip.NewWriter(out)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry,
+		err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		// We only archive regular files, the directory structure is
+		// implied by their names.
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tarWriter, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	return out.Sync()
+}
+
+// extractArchive extracts a gzip compressed tar archive created by archiveDir
+// into the given directory.
+func extractArchive(archivePath, dir string) error {
+	in, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	gzipReader, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unexpected entry type %v for %v",
+				header.Typeflag, header.Name)
+		}
+
+		// Make sure a malicious archive can't write outside of the
+		// target directory.
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid file name in archive: %v",
+				header.Name)
+		}
+
+		target := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(target), 0750)
+		if err != nil {
+			return err
+		}
+
+		out, err := os.OpenFile(
+			target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(out, tarReader)
+		closeErr := out.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+}
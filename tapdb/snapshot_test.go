@@ -0,0 +1,96 @@
+package tapdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshots tests that snapshots of the database and the proof directory
+// can be created, rotated, verified and restored.
+func TestSnapshots(t *testing.T) {
+	t.Parallel()
+
+	db := NewTestSqliteDB(t)
+
+	_, err := db.Exec("CREATE TABLE snapshot_test (value TEXT);")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO snapshot_test VALUES ('foo');")
+	require.NoError(t, err)
+
+	proofDir := t.TempDir()
+	proofFile := filepath.Join("bitcoin", "abcd.assetproof")
+	require.NoError(t, os.MkdirAll(
+		filepath.Join(proofDir, "bitcoin"), 0700,
+	))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(proofDir, proofFile), []byte("proof"), 0600,
+	))
+
+	// Create three snapshots, then rotate down to two.
+	snapshotDir := t.TempDir()
+	now := time.Unix(1_700_000_000, 0)
+	var snapshots []string
+	for i := 0; i < 3; i++ {
+		snapshotPath, err := CreateSnapshot(
+			db, proofDir, snapshotDir,
+			now.Add(time.Duration(i)*time.Hour),
+		)
+		require.NoError(t, err)
+		require.NoError(t, VerifySnapshot(snapshotPath))
+
+		snapshots = append(snapshots, snapshotPath)
+	}
+
+	require.NoError(t, RotateSnapshots(snapshotDir, 2))
+	remaining, err := ListSnapshots(snapshotDir)
+	require.NoError(t, err)
+	require.Equal(t, snapshots[1:], remaining)
+
+	// Restoring the snapshot must bring back the database and the proofs.
+	// Leftovers of a previous restore that failed don't get in the way.
+	restoreDir := t.TempDir()
+	restoredDb := filepath.Join(restoreDir, "tapd.db")
+	restoredProofs := filepath.Join(restoreDir, "proofs")
+	require.NoError(t, os.WriteFile(
+		restoredDb+restoreTmpSuffix, []byte("partial"), 0600,
+	))
+	require.NoError(t, os.MkdirAll(restoredProofs+restoreTmpSuffix, 0700))
+
+	err = RestoreSnapshot(remaining[1], restoredDb, restoredProofs)
+	require.NoError(t, err)
+	require.NoFileExists(t, restoredDb+restoreTmpSuffix)
+	require.NoDirExists(t, restoredProofs+restoreTmpSuffix)
+
+	proof, err := os.ReadFile(filepath.Join(restoredProofs, proofFile))
+	require.NoError(t, err)
+	require.Equal(t, []byte("proof"), proof)
+
+	restoredStore, err := NewSqliteStore(&SqliteConfig{
+		DatabaseFileName: restoredDb,
+		SkipMigrations:   true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, restoredStore.DB.Close())
+	})
+
+	var value string
+	err = restoredStore.QueryRow(
+		"SELECT value FROM snapshot_test;",
+	).Scan(&value)
+	require.NoError(t, err)
+	require.Equal(t, "foo", value)
+
+	// Restoring over existing data is refused.
+	err = RestoreSnapshot(remaining[1], restoredDb, t.TempDir())
+	require.ErrorContains(t, err, "already exists")
+
+	// A modified snapshot must fail the integrity check.
+	dbCopy := filepath.Join(remaining[0], SnapshotDbFileName)
+	require.NoError(t, os.WriteFile(dbCopy, []byte("corrupt"), 0600))
+	require.ErrorContains(t, VerifySnapshot(remaining[0]), "hash mismatch")
+}
@@ -21,6 +21,7 @@ SELECT
     managed_utxos.amt_sats as amt_sats,
     managed_utxos.tapscript_sibling as tapscript_sibling,
     internal_keys.raw_key as internal_key,
+    addr_events.transfer_metadata,
     (SELECT count(*) FROM addr_event_proofs ap 
      WHERE ap.addr_event_id = addr_events.id) AS num_proofs
 FROM addr_events
@@ -42,6 +43,7 @@ type FetchAddrEventRow struct {
 	AmtSats            sql.NullInt64
 	TapscriptSibling   []byte
 	InternalKey        []byte
+	TransferMetadata   []byte
 	NumProofs          int64
 }
 
@@ -57,6 +59,7 @@ func (q *Queries) FetchAddrEvent(ctx context.Context, id int64) (FetchAddrEventR
 		&i.AmtSats,
 		&i.TapscriptSibling,
 		&i.InternalKey,
+		&i.TransferMetadata,
 		&i.NumProofs,
 	)
 	return i, err
@@ -78,6 +81,7 @@ SELECT
     managed_utxos.amt_sats as amt_sats,
     managed_utxos.tapscript_sibling as tapscript_sibling,
     internal_keys.raw_key as internal_key,
+    addr_events.transfer_metadata,
     (SELECT count(*) FROM addr_event_proofs ap
      WHERE ap.addr_event_id = addr_events.id) AS num_proofs
 FROM addr_events
@@ -109,6 +113,7 @@ type FetchAddrEventByAddrKeyAndOutpointRow struct {
 	AmtSats            sql.NullInt64
 	TapscriptSibling   []byte
 	InternalKey        []byte
+	TransferMetadata   []byte
 	NumProofs          int64
 }
 
@@ -125,6 +130,7 @@ func (q *Queries) FetchAddrEventByAddrKeyAndOutpoint(ctx context.Context, arg Fe
 		&i.AmtSats,
 		&i.TapscriptSibling,
 		&i.InternalKey,
+		&i.TransferMetadata,
 		&i.NumProofs,
 	)
 	return i, err
@@ -475,6 +481,22 @@ func (q *Queries) QueryLastEventHeight(ctx context.Context, version int16) (int6
 	return last_height, err
 }
 
+const SetAddrEventTransferMetadata = `-- name: SetAddrEventTransferMetadata :exec
+UPDATE addr_events
+SET transfer_metadata = $2
+WHERE id = $1
+`
+
+type SetAddrEventTransferMetadataParams struct {
+	ID               int64
+	TransferMetadata []byte
+}
+
+func (q *Queries) SetAddrEventTransferMetadata(ctx context.Context, arg SetAddrEventTransferMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, SetAddrEventTransferMetadata, arg.ID, arg.TransferMetadata)
+	return err
+}
+
 const SetAddrManaged = `-- name: SetAddrManaged :exec
 WITH target_addr(addr_id) AS (
     SELECT id
@@ -0,0 +1,4 @@
+-- Drop the asset_invoices table and its indexes.
+DROP INDEX IF EXISTS asset_invoices_status_idx;
+DROP INDEX IF EXISTS asset_invoices_addr_id_idx;
+DROP TABLE IF EXISTS asset_invoices;
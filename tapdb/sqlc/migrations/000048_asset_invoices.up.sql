@@ -0,0 +1,37 @@
+-- Table to track asset invoices, which request a specific amount of an asset
+-- to be received through a Taproot Asset address before an expiry time.
+CREATE TABLE asset_invoices (
+    id INTEGER PRIMARY KEY,
+
+    -- The address the invoice is paid to.
+    addr_id BIGINT NOT NULL REFERENCES addrs(id),
+
+    -- The requested asset amount.
+    amount BIGINT NOT NULL,
+
+    -- An optional description of the invoice.
+    memo TEXT NOT NULL,
+
+    -- The time the invoice was created. Only assets received after this
+    -- time count towards the invoice.
+    creation_time TIMESTAMP NOT NULL,
+
+    -- The time after which an unpaid invoice expires.
+    expiry_time TIMESTAMP NOT NULL,
+
+    -- The status of the invoice: open (0), paid (1), underpaid (2) or
+    -- expired (3).
+    status SMALLINT NOT NULL CHECK(status IN (0, 1, 2, 3)),
+
+    -- The total asset amount received for the invoice.
+    amount_received BIGINT NOT NULL,
+
+    -- The time the invoice was fully paid, NULL if it isn't paid yet.
+    settle_time TIMESTAMP
+);
+
+-- Add index for lookups by address.
+CREATE INDEX asset_invoices_addr_id_idx ON asset_invoices(addr_id);
+
+-- Add index for lookups by status.
+CREATE INDEX asset_invoices_status_idx ON asset_invoices(status);
@@ -0,0 +1,2 @@
+ALTER TABLE addr_events DROP COLUMN transfer_metadata;
+ALTER TABLE asset_transfer_outputs DROP COLUMN transfer_metadata;
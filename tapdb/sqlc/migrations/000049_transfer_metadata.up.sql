@@ -0,0 +1,11 @@
+-- transfer_metadata is the optional, JSON encoded metadata the sender attaches
+-- to the encrypted send fragment of a V2 address transfer. We store it with the
+-- transfer output, because the send fragments are only created once the
+-- transfer transaction confirmed.
+ALTER TABLE asset_transfer_outputs
+    ADD COLUMN transfer_metadata BLOB;
+
+-- On the receiving side, the transfer metadata from the send fragment is
+-- stored with the address event it was received for.
+ALTER TABLE addr_events
+    ADD COLUMN transfer_metadata BLOB;
@@ -0,0 +1,2 @@
+-- Drop the event_sequences table.
+DROP TABLE IF EXISTS event_sequences;
@@ -0,0 +1,10 @@
+-- Table to persist the sequence number of the next event of an event stream,
+-- so the sequence numbers keep increasing across restarts.
+CREATE TABLE event_sequences (
+    -- The unique name of the event stream.
+    stream_name TEXT PRIMARY KEY,
+
+    -- The sequence number that will be assigned to the next event of the
+    -- stream.
+    next_seq BIGINT NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Drop the inactivity_sweeper table.
+DROP TABLE IF EXISTS inactivity_sweeper;
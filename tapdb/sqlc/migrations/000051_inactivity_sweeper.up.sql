@@ -0,0 +1,10 @@
+-- Table to persist the time the inactivity sweeper was enabled, so the
+-- inactivity period isn't restarted by every restart of the daemon.
+CREATE TABLE inactivity_sweeper (
+    -- There is only ever a single row in this table.
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+
+    -- The time the inactivity sweeper was enabled. The inactivity period is
+    -- measured from this time if there was no wallet activity since.
+    start_time TIMESTAMP NOT NULL
+);
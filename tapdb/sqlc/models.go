@@ -34,6 +34,7 @@ type AddrEvent struct {
 	ChainTxnID          int64
 	ChainTxnOutputIndex int32
 	ManagedUtxoID       int64
+	TransferMetadata    []byte
 }
 
 type AddrEventOutput struct {
@@ -93,6 +94,18 @@ type AssetGroupWitness struct {
 	GroupKeyID   int64
 }
 
+type AssetInvoice struct {
+	ID             int64
+	AddrID         int64
+	Amount         int64
+	Memo           string
+	CreationTime   time.Time
+	ExpiryTime     time.Time
+	Status         int16
+	AmountReceived int64
+	SettleTime     sql.NullTime
+}
+
 type AssetMintingBatch struct {
 	BatchID             int64
 	BatchState          int16
@@ -168,6 +181,7 @@ type AssetTransferOutput struct {
 	ProofDeliveryComplete    sql.NullBool
 	Position                 int32
 	TapAddress               sql.NullString
+	TransferMetadata         []byte
 }
 
 type AssetWitness struct {
@@ -210,6 +224,11 @@ type ChainTxn struct {
 	TxIndex     sql.NullInt32
 }
 
+type EventSequence struct {
+	StreamName string
+	NextSeq    int64
+}
+
 type FederationGlobalSyncConfig struct {
 	ProofType       string
 	AllowSyncInsert bool
@@ -264,6 +283,11 @@ type GenesisPoint struct {
 	AnchorTxID sql.NullInt64
 }
 
+type InactivitySweeper struct {
+	ID        int64
+	StartTime time.Time
+}
+
 type InternalKey struct {
 	KeyID     int64
 	RawKey    []byte
@@ -96,6 +96,11 @@ UPDATE addrs
 SET managed_from = $2
 WHERE id = (SELECT addr_id FROM target_addr);
 
+-- name: SetAddrEventTransferMetadata :exec
+UPDATE addr_events
+SET transfer_metadata = $2
+WHERE id = $1;
+
 -- name: UpsertAddrEvent :one
 WITH target_addr(addr_id) AS (
     SELECT id
@@ -152,6 +157,7 @@ SELECT
     managed_utxos.amt_sats as amt_sats,
     managed_utxos.tapscript_sibling as tapscript_sibling,
     internal_keys.raw_key as internal_key,
+    addr_events.transfer_metadata,
     (SELECT count(*) FROM addr_event_proofs ap 
      WHERE ap.addr_event_id = addr_events.id) AS num_proofs
 FROM addr_events
@@ -202,6 +208,7 @@ SELECT
     managed_utxos.amt_sats as amt_sats,
     managed_utxos.tapscript_sibling as tapscript_sibling,
     internal_keys.raw_key as internal_key,
+    addr_events.transfer_metadata,
     (SELECT count(*) FROM addr_event_proofs ap
      WHERE ap.addr_event_id = addr_events.id) AS num_proofs
 FROM addr_events
@@ -0,0 +1,13 @@
+-- name: FetchEventSequence :one
+SELECT next_seq
+FROM event_sequences
+WHERE stream_name = @stream_name;
+
+-- name: UpsertEventSequence :exec
+INSERT INTO event_sequences (
+    stream_name, next_seq
+) VALUES (
+    @stream_name, @next_seq
+)
+ON CONFLICT (stream_name)
+    DO UPDATE SET next_seq = EXCLUDED.next_seq;
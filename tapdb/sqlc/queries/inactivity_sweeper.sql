@@ -0,0 +1,15 @@
+-- name: InsertInactivitySweeperStart :exec
+INSERT INTO inactivity_sweeper (
+    id, start_time
+) VALUES (
+    1, @start_time
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: FetchInactivitySweeperStart :one
+SELECT start_time
+FROM inactivity_sweeper
+WHERE id = 1;
+
+-- name: DeleteInactivitySweeperStart :exec
+DELETE FROM inactivity_sweeper;
@@ -0,0 +1,33 @@
+-- name: InsertAssetInvoice :one
+INSERT INTO asset_invoices (
+    addr_id, amount, memo, creation_time, expiry_time, status,
+    amount_received, settle_time
+) VALUES (
+    @addr_id, @amount, @memo, @creation_time, @expiry_time, @status,
+    @amount_received, @settle_time
+)
+RETURNING id;
+
+-- name: UpdateAssetInvoice :exec
+UPDATE asset_invoices
+SET status = @status, amount_received = @amount_received,
+    settle_time = @settle_time
+WHERE id = @id;
+
+-- name: QueryAssetInvoices :many
+SELECT
+    asset_invoices.id, addrs.taproot_output_key, asset_invoices.amount,
+    asset_invoices.memo, asset_invoices.creation_time,
+    asset_invoices.expiry_time, asset_invoices.status,
+    asset_invoices.amount_received, asset_invoices.settle_time
+FROM asset_invoices
+JOIN addrs
+  ON asset_invoices.addr_id = addrs.id
+WHERE
+    (asset_invoices.id = sqlc.narg('id') OR
+      sqlc.narg('id') IS NULL)
+    AND (asset_invoices.status = sqlc.narg('status') OR
+      sqlc.narg('status') IS NULL)
+    AND (addrs.taproot_output_key = sqlc.narg('taproot_output_key') OR
+      sqlc.narg('taproot_output_key') IS NULL)
+ORDER BY asset_invoices.id;
@@ -25,10 +25,11 @@ INSERT INTO asset_transfer_outputs (
     amount, serialized_witnesses, split_commitment_root_hash,
     split_commitment_root_value, proof_suffix, num_passive_assets,
     output_type, proof_courier_addr, asset_version, lock_time,
-    relative_lock_time, proof_delivery_complete, position, tap_address
+    relative_lock_time, proof_delivery_complete, position, tap_address,
+    transfer_metadata
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-    $18
+    $18, $19
 );
 
 -- name: SetTransferOutputProofDeliveryStatus :exec
@@ -105,6 +106,7 @@ SELECT
     split_commitment_root_hash, split_commitment_root_value, num_passive_assets,
     output_type, proof_courier_addr, proof_delivery_complete, position,
     asset_version, lock_time, relative_lock_time, tap_address,
+    transfer_metadata,
     utxos.utxo_id AS anchor_utxo_id,
     utxos.outpoint AS anchor_outpoint,
     utxos.amt_sats AS anchor_value,
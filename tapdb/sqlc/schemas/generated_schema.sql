@@ -76,7 +76,7 @@ CREATE TABLE addr_events (
     managed_utxo_id BIGINT NOT NULL REFERENCES managed_utxos(utxo_id),
     
     UNIQUE(addr_id, chain_txn_id, chain_txn_output_index)
-);
+, transfer_metadata BLOB);
 
 CREATE INDEX addr_group_keys ON addrs (group_key);
 
@@ -188,6 +188,40 @@ REFERENCES tapscript_roots(root_id));
 
 CREATE INDEX asset_ids on genesis_assets(asset_id);
 
+CREATE TABLE asset_invoices (
+    id INTEGER PRIMARY KEY,
+
+    -- The address the invoice is paid to.
+    addr_id BIGINT NOT NULL REFERENCES addrs(id),
+
+    -- The requested asset amount.
+    amount BIGINT NOT NULL,
+
+    -- An optional description of the invoice.
+    memo TEXT NOT NULL,
+
+    -- The time the invoice was created. Only assets received after this
+    -- time count towards the invoice.
+    creation_time TIMESTAMP NOT NULL,
+
+    -- The time after which an unpaid invoice expires.
+    expiry_time TIMESTAMP NOT NULL,
+
+    -- The status of the invoice: open (0), paid (1), underpaid (2) or
+    -- expired (3).
+    status SMALLINT NOT NULL CHECK(status IN (0, 1, 2, 3)),
+
+    -- The total asset amount received for the invoice.
+    amount_received BIGINT NOT NULL,
+
+    -- The time the invoice was fully paid, NULL if it isn't paid yet.
+    settle_time TIMESTAMP
+);
+
+CREATE INDEX asset_invoices_addr_id_idx ON asset_invoices(addr_id);
+
+CREATE INDEX asset_invoices_status_idx ON asset_invoices(status);
+
 CREATE TABLE asset_minting_batches (
     batch_id INTEGER PRIMARY KEY REFERENCES internal_keys(key_id),
 
@@ -289,7 +323,7 @@ CREATE TABLE asset_transfer_outputs (
     -- the output. This value will be NULL for outputs that do not require proof
     -- transfer.
     proof_courier_addr BLOB
-, lock_time INTEGER, relative_lock_time INTEGER, proof_delivery_complete BOOL, position INTEGER NOT NULL DEFAULT -1, tap_address VARCHAR);
+, lock_time INTEGER, relative_lock_time INTEGER, proof_delivery_complete BOOL, position INTEGER NOT NULL DEFAULT -1, tap_address VARCHAR, transfer_metadata BLOB);
 
 CREATE UNIQUE INDEX asset_transfer_outputs_transfer_id_position_unique
 ON asset_transfer_outputs (
@@ -434,6 +468,15 @@ CREATE TABLE chain_txns (
 
 CREATE INDEX creation_time_idx ON addr_events(creation_time);
 
+CREATE TABLE event_sequences (
+    -- The unique name of the event stream.
+    stream_name TEXT PRIMARY KEY,
+
+    -- The sequence number that will be assigned to the next event of the
+    -- stream.
+    next_seq BIGINT NOT NULL
+);
+
 CREATE TABLE federation_global_sync_config (
     proof_type TEXT NOT NULL PRIMARY KEY REFERENCES proof_types(proof_type),
     allow_sync_insert BOOLEAN NOT NULL,
@@ -549,6 +592,15 @@ ON mssmt_nodes(namespace, key, hash_key, sum);
 
 CREATE INDEX idx_universe_roots_composite ON universe_roots(namespace_root, proof_type, asset_id);
 
+CREATE TABLE inactivity_sweeper (
+    -- There is only ever a single row in this table.
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+
+    -- The time the inactivity sweeper was enabled. The inactivity period is
+    -- measured from this time if there was no wallet activity since.
+    start_time TIMESTAMP NOT NULL
+);
+
 CREATE TABLE internal_keys (
     key_id INTEGER PRIMARY KEY,
 
@@ -127,6 +127,7 @@ SELECT
     split_commitment_root_hash, split_commitment_root_value, num_passive_assets,
     output_type, proof_courier_addr, proof_delivery_complete, position,
     asset_version, lock_time, relative_lock_time, tap_address,
+    transfer_metadata,
     utxos.utxo_id AS anchor_utxo_id,
     utxos.outpoint AS anchor_outpoint,
     utxos.amt_sats AS anchor_value,
@@ -169,6 +170,7 @@ type FetchTransferOutputsRow struct {
 	LockTime                 sql.NullInt32
 	RelativeLockTime         sql.NullInt32
 	TapAddress               sql.NullString
+	TransferMetadata         []byte
 	AnchorUtxoID             int64
 	AnchorOutpoint           []byte
 	AnchorValue              int64
@@ -209,6 +211,7 @@ func (q *Queries) FetchTransferOutputs(ctx context.Context, transferID int64) ([
 			&i.LockTime,
 			&i.RelativeLockTime,
 			&i.TapAddress,
+			&i.TransferMetadata,
 			&i.AnchorUtxoID,
 			&i.AnchorOutpoint,
 			&i.AnchorValue,
@@ -311,10 +314,11 @@ INSERT INTO asset_transfer_outputs (
     amount, serialized_witnesses, split_commitment_root_hash,
     split_commitment_root_value, proof_suffix, num_passive_assets,
     output_type, proof_courier_addr, asset_version, lock_time,
-    relative_lock_time, proof_delivery_complete, position, tap_address
+    relative_lock_time, proof_delivery_complete, position, tap_address,
+    transfer_metadata
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-    $18
+    $18, $19
 )
 `
 
@@ -337,6 +341,7 @@ type InsertAssetTransferOutputParams struct {
 	ProofDeliveryComplete    sql.NullBool
 	Position                 int32
 	TapAddress               sql.NullString
+	TransferMetadata         []byte
 }
 
 func (q *Queries) InsertAssetTransferOutput(ctx context.Context, arg InsertAssetTransferOutputParams) error {
@@ -359,6 +364,7 @@ func (q *Queries) InsertAssetTransferOutput(ctx context.Context, arg InsertAsset
 		arg.ProofDeliveryComplete,
 		arg.Position,
 		arg.TapAddress,
+		arg.TransferMetadata,
 	)
 	return err
 }
@@ -60,6 +60,10 @@ type SqliteConfig struct {
 	// DatabaseFileName is the full file path where the database file can be
 	// found.
 	DatabaseFileName string `long:"dbfile" description:"The full path to the database."`
+
+	// Snapshot is the configuration for periodic snapshots of the
+	// database and the proof directory.
+	Snapshot *SnapshotConfig `group:"snapshot" namespace:"snapshot"`
 }
 
 // SqliteStore is a sqlite3 based database for the Taproot Asset daemon.
@@ -254,6 +258,29 @@ func (s *SqliteStore) ExecuteMigrations(target MigrationTarget,
 	)
 }
 
+// ExecuteExtensionMigrations applies all migrations of the given extension to
+// the sqlite database. The migration versions of the extension are tracked in
+// a separate table, so this can be called independently of tapd's own
+// migrations.
+func (s *SqliteStore) ExecuteExtensionMigrations(ext Extension) error {
+	if err := ext.Validate(); err != nil {
+		return err
+	}
+
+	driver, err := sqlite_migrate.WithInstance(
+		s.DB, &sqlite_migrate.Config{
+			MigrationsTable: ext.MigrationsTable(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error creating sqlite migration: %w", err)
+	}
+
+	return applyExtensionMigrations(
+		ext, driver, "sqlite", sqliteSchemaReplacements,
+	)
+}
+
 // NewTestSqliteDB is a helper function that creates an SQLite database for
 // testing.
 func NewTestSqliteDB(t testing.TB) *SqliteStore {
@@ -0,0 +1,394 @@
+package tapfreighter
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+)
+
+var (
+	// ErrDestinationBlocked is returned when a parcel sends assets to a
+	// destination that is on the blocklist of the porter.
+	ErrDestinationBlocked = errors.New("transfer destination is blocked")
+)
+
+// BlocklistEntryType is the type of destination a blocklist entry refers to.
+type BlocklistEntryType uint8
+
+const (
+	// BlocklistEntryScriptKey is a blocklist entry for an asset script
+	// key.
+	BlocklistEntryScriptKey BlocklistEntryType = iota
+
+	// BlocklistEntryGroupKey is a blocklist entry for an asset group key,
+	// which blocks any address that receives assets of the group.
+	BlocklistEntryGroupKey
+
+	// BlocklistEntryAddress is a blocklist entry for an encoded Taproot
+	// Asset address.
+	BlocklistEntryAddress
+)
+
+// String returns a human-readable representation of the entry type.
+func (t BlocklistEntryType) String() string {
+	switch t {
+	case BlocklistEntryScriptKey:
+		return "script_key"
+
+	case BlocklistEntryGroupKey:
+		return "group_key"
+
+	case BlocklistEntryAddress:
+		return "address"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// BlocklistEntry is a single destination on the blocklist.
+type BlocklistEntry struct {
+	// Type is the type of the destination.
+	Type BlocklistEntryType
+
+	// Value is the hex encoded compressed public key for script and group
+	// key entries, or the encoded address for address entries.
+	Value string
+}
+
+// String returns a human-readable representation of the entry.
+func (e BlocklistEntry) String() string {
+	return fmt.Sprintf("%v:%s", e.Type, e.Value)
+}
+
+// ScriptKeyBlocklistEntry returns the blocklist entry for the given script
+// key.
+func ScriptKeyBlocklistEntry(key *btcec.PublicKey) BlocklistEntry {
+	return BlocklistEntry{
+		Type:  BlocklistEntryScriptKey,
+		Value: hex.EncodeToString(key.SerializeCompressed()),
+	}
+}
+
+// GroupKeyBlocklistEntry returns the blocklist entry for the given group key.
+func GroupKeyBlocklistEntry(key *btcec.PublicKey) BlocklistEntry {
+	return BlocklistEntry{
+		Type:  BlocklistEntryGroupKey,
+		Value: hex.EncodeToString(key.SerializeCompressed()),
+	}
+}
+
+// AddressBlocklistEntry returns the blocklist entry for the given address.
+func AddressBlocklistEntry(addr *address.Tap) (BlocklistEntry, error) {
+	encoded, err := addr.EncodeAddress()
+	if err != nil {
+		return BlocklistEntry{}, fmt.Errorf("unable to encode "+
+			"address: %w", err)
+	}
+
+	return BlocklistEntry{
+		Type:  BlocklistEntryAddress,
+		Value: encoded,
+	}, nil
+}
+
+// BlocklistAuditor is called whenever a blocked destination is allowed for a
+// single transfer because of an override.
+type BlocklistAuditor func(entry BlocklistEntry, reason string)
+
+// Blocklist is a set of destinations the porter refuses to send assets to.
+// A blocked destination can be allowed for a single transfer with an explicit
+// override, which is reported to the auditor once the transfer is committed.
+// The blocklist is safe for concurrent use.
+type Blocklist struct {
+	// auditor is called whenever an override is used.
+	auditor BlocklistAuditor
+
+	// entries is the set of blocked destinations.
+	entries fn.Set[BlocklistEntry]
+
+	// overrides maps the blocked destinations that are allowed for the
+	// next transfer to the reason of the override.
+	overrides map[BlocklistEntry]string
+
+	mtx sync.Mutex
+}
+
+// NewBlocklist creates a new, empty blocklist. The auditor is optional and is
+// called whenever an override is used.
+func NewBlocklist(auditor BlocklistAuditor) *Blocklist {
+	return &Blocklist{
+		auditor:   auditor,
+		entries:   fn.NewSet[BlocklistEntry](),
+		overrides: make(map[BlocklistEntry]string),
+	}
+}
+
+// SetAuditor sets the auditor that is called whenever an override is used,
+// replacing any previously set auditor. A nil auditor disables auditing.
+func (b *Blocklist) SetAuditor(auditor BlocklistAuditor) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.auditor = auditor
+}
+
+// Add adds the given destinations to the blocklist.
+func (b *Blocklist) Add(entries ...BlocklistEntry) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for _, entry := range entries {
+		b.entries.Add(entry)
+	}
+}
+
+// Remove removes the given destinations and any pending overrides for them
+// from the blocklist.
+func (b *Blocklist) Remove(entries ...BlocklistEntry) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for _, entry := range entries {
+		b.entries.Remove(entry)
+		delete(b.overrides, entry)
+	}
+}
+
+// Contains returns true if the given destination is on the blocklist.
+func (b *Blocklist) Contains(entry BlocklistEntry) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.entries.Contains(entry)
+}
+
+// AllowOnce allows a blocked destination for the next transfer that sends to
+// it. The override is consumed and its reason reported to the auditor once
+// that transfer is committed.
+func (b *Blocklist) AllowOnce(entry BlocklistEntry, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("blocklist override requires a reason")
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if !b.entries.Contains(entry) {
+		return fmt.Errorf("destination %v is not blocked", entry)
+	}
+
+	b.overrides[entry] = reason
+
+	return nil
+}
+
+// check returns ErrDestinationBlocked if any of the given destinations is
+// blocked and not overridden. The overrides used for the destinations are
+// returned, but not consumed.
+func (b *Blocklist) check(
+	entries []BlocklistEntry) (map[BlocklistEntry]string, error) {
+
+	used := make(map[BlocklistEntry]string)
+	for _, entry := range entries {
+		if !b.entries.Contains(entry) {
+			continue
+		}
+
+		reason, ok := b.overrides[entry]
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", ErrDestinationBlocked,
+				entry)
+		}
+
+		used[entry] = reason
+	}
+
+	return used, nil
+}
+
+// CheckParcel returns ErrDestinationBlocked if the given parcel sends assets
+// to a blocked destination that is not overridden. This doesn't consume any
+// overrides, which only happens once the transfer is committed.
+func (b *Blocklist) CheckParcel(parcel Parcel) error {
+	entries, err := parcelEntries(parcel)
+	if err != nil {
+		return err
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	_, err = b.check(entries)
+	return err
+}
+
+// reserveOverrides returns ErrDestinationBlocked if the given transfer sends
+// assets to a blocked destination that is not overridden. Otherwise, the
+// overrides used by the transfer are removed, so no other transfer can use
+// them, and returned. They must either be released with releaseOverrides once
+// the transfer is committed, or put back with restoreOverrides if committing
+// the transfer failed.
+func (b *Blocklist) reserveOverrides(
+	pkg *sendPackage) (map[BlocklistEntry]string, error) {
+
+	entries, err := parcelEntries(pkg.Parcel)
+	if err != nil {
+		return nil, err
+	}
+	pktEntries, err := vPacketEntries(pkg.VirtualPackets)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, pktEntries...)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	used, err := b.check(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	for entry := range used {
+		delete(b.overrides, entry)
+	}
+
+	return used, nil
+}
+
+// restoreOverrides puts back the given reserved overrides, as the transfer
+// they were reserved for wasn't committed. Overrides for destinations that
+// were removed from the blocklist in the meantime are dropped.
+func (b *Blocklist) restoreOverrides(used map[BlocklistEntry]string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for entry, reason := range used {
+		if b.entries.Contains(entry) {
+			b.overrides[entry] = reason
+		}
+	}
+}
+
+// releaseOverrides reports the given reserved overrides to the auditor, as the
+// transfer they were reserved for was committed.
+func (b *Blocklist) releaseOverrides(used map[BlocklistEntry]string) {
+	b.mtx.Lock()
+	auditor := b.auditor
+	b.mtx.Unlock()
+
+	for entry, reason := range used {
+		log.Warnf("Sending to blocked destination %v because of "+
+			"override: %s", entry, reason)
+
+		if auditor != nil {
+			auditor(entry, reason)
+		}
+	}
+}
+
+// parcelEntries returns the blocklist entries of all destinations of the
+// given parcel that are known before the parcel is funded.
+func parcelEntries(parcel Parcel) ([]BlocklistEntry, error) {
+	switch p := parcel.(type) {
+	case *AddressParcel:
+		var entries []BlocklistEntry
+		for _, addr := range p.destAddrs {
+			addrEntries, err := addressEntries(addr)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, addrEntries...)
+		}
+
+		return entries, nil
+
+	case *PreSignedParcel:
+		return vPacketEntries(p.vPackets)
+
+	case *PreAnchoredParcel:
+		return vPacketEntries(p.virtualPackets)
+
+	default:
+		return nil, nil
+	}
+}
+
+// addressEntries returns the blocklist entries of the given address.
+func addressEntries(addr *address.Tap) ([]BlocklistEntry, error) {
+	entry, err := AddressBlocklistEntry(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []BlocklistEntry{
+		entry, ScriptKeyBlocklistEntry(&addr.ScriptKey),
+	}
+	if addr.GroupKey != nil {
+		entries = append(entries, GroupKeyBlocklistEntry(addr.GroupKey))
+	}
+
+	return entries, nil
+}
+
+// vPacketEntries returns the blocklist entries of all outputs of the given
+// virtual packets, including interactive ones. Every output is also checked
+// against the group of the assets it carries, which is taken from the output
+// asset if it is already known and from the packet inputs otherwise.
+func vPacketEntries(vPackets []*tappsbt.VPacket) ([]BlocklistEntry, error) {
+	var entries []BlocklistEntry
+	for _, vPkt := range vPackets {
+		groupKeys := make(map[BlocklistEntry]struct{})
+		for _, vIn := range vPkt.Inputs {
+			inputAsset := vIn.Asset()
+			if inputAsset == nil || inputAsset.GroupKey == nil {
+				continue
+			}
+
+			groupEntry := GroupKeyBlocklistEntry(
+				&inputAsset.GroupKey.GroupPubKey,
+			)
+			groupKeys[groupEntry] = struct{}{}
+		}
+
+		for _, vOut := range vPkt.Outputs {
+			if vOut.ScriptKey.PubKey != nil {
+				entries = append(
+					entries, ScriptKeyBlocklistEntry(
+						vOut.ScriptKey.PubKey,
+					),
+				)
+			}
+
+			if vOut.Asset != nil && vOut.Asset.GroupKey != nil {
+				groupEntry := GroupKeyBlocklistEntry(
+					&vOut.Asset.GroupKey.GroupPubKey,
+				)
+				groupKeys[groupEntry] = struct{}{}
+			}
+
+			if vOut.Address != nil {
+				addrEntries, err := addressEntries(vOut.Address)
+				if err != nil {
+					return nil, err
+				}
+
+				entries = append(entries, addrEntries...)
+			}
+		}
+
+		for groupEntry := range groupKeys {
+			entries = append(entries, groupEntry)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,202 @@
+package tapfreighter
+
+import (
+	"testing"
+
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBlocklist tests that parcels to blocked destinations are rejected unless
+// an override was registered, and that overrides are audited and only used
+// once.
+func TestBlocklist(t *testing.T) {
+	t.Parallel()
+
+	type audit struct {
+		entry  BlocklistEntry
+		reason string
+	}
+	var audits []audit
+	blocklist := NewBlocklist(func(entry BlocklistEntry, reason string) {
+		audits = append(audits, audit{entry, reason})
+	})
+
+	addr, _, _ := address.RandAddrWithVersion(
+		t, testParams, address.RandProofCourierAddr(t), address.V1,
+	)
+	addrParcel := NewAddressParcel(nil, "", true, addr.Tap)
+
+	scriptKey := asset.RandScriptKey(t)
+	signedParcel := NewPreSignedParcel([]*tappsbt.VPacket{{
+		Outputs: []*tappsbt.VOutput{{
+			ScriptKey: scriptKey,
+		}},
+	}}, nil, "")
+
+	// Nothing is blocked yet.
+	require.NoError(t, blocklist.CheckParcel(addrParcel))
+	require.NoError(t, blocklist.CheckParcel(signedParcel))
+
+	addrEntry, err := AddressBlocklistEntry(addr.Tap)
+	require.NoError(t, err)
+	scriptKeyEntry := ScriptKeyBlocklistEntry(scriptKey.PubKey)
+	blocklist.Add(addrEntry, scriptKeyEntry)
+	require.True(t, blocklist.Contains(addrEntry))
+
+	err = blocklist.CheckParcel(addrParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+	err = blocklist.CheckParcel(signedParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+
+	// An override requires a reason and a blocked destination.
+	require.Error(t, blocklist.AllowOnce(addrEntry, ""))
+	otherEntry := ScriptKeyBlocklistEntry(asset.RandScriptKey(t).PubKey)
+	require.Error(t, blocklist.AllowOnce(otherEntry, "reason"))
+
+	// An override isn't consumed by the early check of a parcel.
+	require.NoError(t, blocklist.AllowOnce(addrEntry, "court order"))
+	require.NoError(t, blocklist.CheckParcel(addrParcel))
+	require.NoError(t, blocklist.CheckParcel(addrParcel))
+	require.Empty(t, audits)
+
+	// Once reserved for a transfer, the override can't be used by any
+	// other transfer. If the transfer isn't committed, it is put back.
+	addrPkg := &sendPackage{Parcel: addrParcel}
+	used, err := blocklist.reserveOverrides(addrPkg)
+	require.NoError(t, err)
+	require.Equal(t, map[BlocklistEntry]string{
+		addrEntry: "court order",
+	}, used)
+
+	err = blocklist.CheckParcel(addrParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+	_, err = blocklist.reserveOverrides(addrPkg)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+
+	blocklist.restoreOverrides(used)
+	require.NoError(t, blocklist.CheckParcel(addrParcel))
+	require.Empty(t, audits)
+
+	// The override is only audited once the transfer is committed and
+	// allows exactly one transfer.
+	used, err = blocklist.reserveOverrides(addrPkg)
+	require.NoError(t, err)
+	blocklist.releaseOverrides(used)
+	require.Equal(t, []audit{{addrEntry, "court order"}}, audits)
+
+	err = blocklist.CheckParcel(addrParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+
+	// A group key entry blocks the address if it is for a grouped asset.
+	if addr.Tap.GroupKey != nil {
+		blocklist.Remove(addrEntry)
+		require.NoError(t, blocklist.CheckParcel(addrParcel))
+
+		blocklist.Add(GroupKeyBlocklistEntry(addr.Tap.GroupKey))
+		err = blocklist.CheckParcel(addrParcel)
+		require.ErrorIs(t, err, ErrDestinationBlocked)
+	}
+
+	// Removing the entry allows transfers again.
+	blocklist.Remove(scriptKeyEntry)
+	require.NoError(t, blocklist.CheckParcel(signedParcel))
+	require.Len(t, audits, 1)
+
+	// The group of the assets of every output is checked, whether it is
+	// known from the output asset or from the packet inputs.
+	groupedAsset := asset.RandAsset(t, asset.Normal)
+	groupedAsset.GroupKey = &asset.GroupKey{
+		GroupPubKey: *test.RandPubKey(t),
+	}
+	groupEntry := GroupKeyBlocklistEntry(
+		&groupedAsset.GroupKey.GroupPubKey,
+	)
+	blocklist.Add(groupEntry)
+
+	outputParcel := NewPreSignedParcel([]*tappsbt.VPacket{{
+		Outputs: []*tappsbt.VOutput{{
+			ScriptKey: asset.RandScriptKey(t),
+			Asset:     groupedAsset,
+		}},
+	}}, nil, "")
+	err = blocklist.CheckParcel(outputParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+
+	inputPkt := &tappsbt.VPacket{
+		Outputs: []*tappsbt.VOutput{{
+			ScriptKey:   asset.RandScriptKey(t),
+			Interactive: true,
+		}},
+		ChainParams: testParams,
+	}
+	inputPkt.SetInputAsset(0, groupedAsset)
+	inputParcel := NewPreAnchoredParcel(
+		[]*tappsbt.VPacket{inputPkt}, nil, nil, false, "",
+	)
+	err = blocklist.CheckParcel(inputParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+
+	// Outputs that are only known once an address parcel was funded are
+	// checked when the transfer is committed.
+	_, err = blocklist.reserveOverrides(&sendPackage{
+		Parcel:         NewAddressParcel(nil, "", true),
+		VirtualPackets: []*tappsbt.VPacket{inputPkt},
+	})
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+}
+
+// TestBlocklistPorterAuditor tests that an auditor set on the blocklist after
+// it was handed to the porter, as done by applications embedding the daemon,
+// is called for the overrides used by the porter's transfers.
+func TestBlocklistPorterAuditor(t *testing.T) {
+	t.Parallel()
+
+	// The blocklist is created without an auditor and shared with the
+	// porter, the same way the server does it.
+	blocklist := NewBlocklist(nil)
+	porter := NewChainPorter(&ChainPorterConfig{
+		Blocklist: blocklist,
+	})
+
+	addr, _, _ := address.RandAddrWithVersion(
+		t, testParams, address.RandProofCourierAddr(t), address.V1,
+	)
+	addrEntry, err := AddressBlocklistEntry(addr.Tap)
+	require.NoError(t, err)
+	blocklist.Add(addrEntry)
+
+	var audited []string
+	blocklist.SetAuditor(func(entry BlocklistEntry, reason string) {
+		require.Equal(t, addrEntry, entry)
+		audited = append(audited, reason)
+	})
+
+	// Without an override, the porter rejects the parcel right away.
+	addrParcel := NewAddressParcel(nil, "", true, addr.Tap)
+	_, err = porter.RequestShipment(addrParcel)
+	require.ErrorIs(t, err, ErrDestinationBlocked)
+
+	// An override used by one of the porter's transfers is reported to
+	// the auditor once the transfer is committed.
+	require.NoError(t, blocklist.AllowOnce(addrEntry, "court order"))
+	used, err := porter.cfg.Blocklist.reserveOverrides(
+		&sendPackage{Parcel: addrParcel},
+	)
+	require.NoError(t, err)
+	porter.cfg.Blocklist.releaseOverrides(used)
+	require.Equal(t, []string{"court order"}, audited)
+
+	// Removing the auditor disables auditing.
+	blocklist.SetAuditor(nil)
+	require.NoError(t, blocklist.AllowOnce(addrEntry, "another order"))
+	used, err = porter.cfg.Blocklist.reserveOverrides(
+		&sendPackage{Parcel: addrParcel},
+	)
+	require.NoError(t, err)
+	porter.cfg.Blocklist.releaseOverrides(used)
+	require.Equal(t, []string{"court order"}, audited)
+}
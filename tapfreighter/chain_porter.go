@@ -36,6 +36,10 @@ const (
 	// we expect a send fragment to be valid for after its claimed outpoint
 	// has been spent. This is roughly equivalent to 90 days.
 	DefaultSendFragmentExpiryDelta = 12_960
+
+	// maxTransferTraces is the maximum number of transfer state machine
+	// traces the porter keeps in memory.
+	maxTransferTraces = 100
 )
 
 // ProofImporter is used to import proofs into the local proof archive after we
@@ -124,6 +128,14 @@ type ChainPorterConfig struct {
 	// key for a given asset, which is required for creating supply
 	// commitments.
 	DelegationKeyChecker address.DelegationKeyChecker
+
+	// Blocklist is an optional set of destinations the porter refuses to
+	// send assets to.
+	Blocklist *Blocklist
+
+	// SpendNotifier is an optional notifier used to watch the inputs of
+	// unconfirmed anchor transactions for conflicting spends.
+	SpendNotifier SpendNotifier
 }
 
 // ChainPorter is the main sub-system of the tapfreighter package. The porter
@@ -148,6 +160,13 @@ type ChainPorter struct {
 	// subscriberMtx guards the subscribers map.
 	subscriberMtx sync.Mutex
 
+	// traces holds the state machine traces of the most recent transfers,
+	// oldest first.
+	traces []*TransferTrace
+
+	// traceMtx guards the traces slice and the traces it contains.
+	traceMtx sync.Mutex
+
 	*fn.ContextGuard
 }
 
@@ -306,6 +325,15 @@ func (p *ChainPorter) RequestShipment(req Parcel) (*OutboundParcel, error) {
 		return nil, fmt.Errorf("failed to validate parcel: %w", err)
 	}
 
+	// We reject parcels to blocked destinations early, before any coins
+	// are locked. The final check that also consumes any overrides is
+	// done once the funded transfer is committed.
+	if p.cfg.Blocklist != nil {
+		if err := p.cfg.Blocklist.CheckParcel(req); err != nil {
+			return nil, err
+		}
+	}
+
 	if !fn.SendOrQuit(p.outboundParcels, req, p.Quit) {
 		return nil, fmt.Errorf("ChainPorter shutting down")
 	}
@@ -364,6 +392,10 @@ func (p *ChainPorter) mainEventLoop() {
 //
 // NOTE: This method MUST be called as a goroutine.
 func (p *ChainPorter) advanceState(pkg *sendPackage, kit *parcelKit) {
+	// Every run of the state machine is recorded in its own trace, so we
+	// can tell where a transfer got stuck.
+	trace := p.newTransferTrace()
+
 	// Continue state transitions whilst state complete has not yet
 	// been reached.
 	for pkg.SendState <= SendStateComplete {
@@ -380,23 +412,36 @@ func (p *ChainPorter) advanceState(pkg *sendPackage, kit *parcelKit) {
 
 		stateToExecute := pkg.SendState
 		updatedPkg, err := p.stateStep(*pkg)
+
+		// The state graph is rendered from the transition table, so we
+		// don't allow the state machine to take any other transition.
+		if err == nil && !validSendTransition(
+			stateToExecute, updatedPkg.SendState,
+		) {
+
+			err = fmt.Errorf("invalid send state transition from "+
+				"%v to %v", stateToExecute,
+				updatedPkg.SendState)
+		}
 		if err != nil {
 			kit.errChan <- err
 			log.Errorf("Error evaluating state (%v): %v",
 				pkg.SendState, err)
 
-			p.publishSubscriberEvent(newAssetSendErrorEvent(
+			errEvent := newAssetSendErrorEvent(
 				err, stateToExecute, *pkg,
-			))
+			)
+			p.recordTransition(trace, errEvent)
+			p.publishSubscriberEvent(errEvent)
 
 			return
 		}
 
 		// Notify subscribers that the state machine has executed a
 		// state successfully.
-		p.publishSubscriberEvent(newAssetSendEvent(
-			stateToExecute, *updatedPkg,
-		))
+		event := newAssetSendEvent(stateToExecute, *updatedPkg)
+		p.recordTransition(trace, event)
+		p.publishSubscriberEvent(event)
 
 		// Exit the loop once the state machine has executed its final
 		// state.
@@ -412,6 +457,61 @@ func (p *ChainPorter) advanceState(pkg *sendPackage, kit *parcelKit) {
 	}
 }
 
+// newTransferTrace creates a new, empty transfer trace and adds it to the
+// porter's recorded traces. Only the most recent maxTransferTraces traces are
+// kept.
+func (p *ChainPorter) newTransferTrace() *TransferTrace {
+	trace := NewTransferTrace()
+
+	p.traceMtx.Lock()
+	defer p.traceMtx.Unlock()
+
+	p.traces = append(p.traces, trace)
+	if len(p.traces) > maxTransferTraces {
+		p.traces = p.traces[len(p.traces)-maxTransferTraces:]
+	}
+
+	return trace
+}
+
+// recordTransition adds the given send event to the trace.
+func (p *ChainPorter) recordTransition(trace *TransferTrace,
+	event *AssetSendEvent) {
+
+	p.traceMtx.Lock()
+	defer p.traceMtx.Unlock()
+
+	trace.Add(event)
+}
+
+// TransferTraces returns a copy of the state machine traces of the most recent
+// transfers, oldest first.
+func (p *ChainPorter) TransferTraces() []*TransferTrace {
+	p.traceMtx.Lock()
+	defer p.traceMtx.Unlock()
+
+	return fn.Map(p.traces, func(trace *TransferTrace) *TransferTrace {
+		return trace.Copy()
+	})
+}
+
+// FetchTransferTrace returns a copy of the most recent state machine trace of
+// the transfer with the given anchor transaction hash, if one was recorded.
+func (p *ChainPorter) FetchTransferTrace(
+	anchorTxHash chainhash.Hash) (*TransferTrace, bool) {
+
+	p.traceMtx.Lock()
+	defer p.traceMtx.Unlock()
+
+	for idx := len(p.traces) - 1; idx >= 0; idx-- {
+		if p.traces[idx].AnchorTxid == anchorTxHash.String() {
+			return p.traces[idx].Copy(), true
+		}
+	}
+
+	return nil, false
+}
+
 // waitForTransferTxConf waits for the confirmation of the final transaction
 // within the delta. Once confirmed, the parcel will be marked as delivered on
 // chain, with the goroutine cleaning up its state.
@@ -434,6 +534,10 @@ func (p *ChainPorter) waitForTransferTxConf(pkg *sendPackage) error {
 	// Launch a goroutine that'll notify us when the transaction confirms.
 	defer confCancel()
 
+	// While we wait for the confirmation, we also watch the inputs of the
+	// anchor transaction, so we notice a double spend as early as possible.
+	p.watchAnchorInputSpends(confCtx, pkg)
+
 	var confEvent *chainntnfs.TxConfirmation
 	select {
 	case confEvent = <-confNtfn.Confirmed:
@@ -469,6 +573,149 @@ func (p *ChainPorter) waitForTransferTxConf(pkg *sendPackage) error {
 	return nil
 }
 
+// watchAnchorInputSpends watches all inputs of the anchor transaction of the
+// given package for spends until the context is canceled. If an input is spent
+// by a different transaction, an AnchorInputConflictEvent is published to all
+// subscribers.
+//
+// NOTE: The spend notifier only reports spends once the spending transaction
+// has confirmed. A conflicting transaction that is only in the mempool is
+// therefore not detected until it confirms.
+func (p *ChainPorter) watchAnchorInputSpends(ctx context.Context,
+	pkg *sendPackage) {
+
+	if p.cfg.SpendNotifier == nil || pkg.OutboundPkg == nil ||
+		pkg.OutboundPkg.AnchorTx == nil {
+
+		return
+	}
+
+	// The package is modified once the transaction confirms, so we take
+	// a copy of the transfer for the conflict events.
+	var (
+		transfer     = pkg.OutboundPkg.Copy()
+		label        = pkg.Label
+		anchorTxHash = transfer.AnchorTx.TxHash()
+	)
+
+	inputScripts, err := p.anchorInputScripts(pkg)
+	if err != nil {
+		log.Warnf("Unable to watch inputs of anchor tx %v for "+
+			"conflicting spends: %v", anchorTxHash, err)
+		return
+	}
+
+	for _, txIn := range transfer.AnchorTx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+		pkScript, ok := inputScripts[prevOut]
+		if !ok {
+			log.Warnf("Unable to watch input %v of anchor tx %v "+
+				"for conflicting spends, unknown pk script",
+				prevOut, anchorTxHash)
+			continue
+		}
+
+		spendEvent, err := p.cfg.SpendNotifier.RegisterSpendNtfn(
+			&prevOut, pkScript, transfer.AnchorTxHeightHint,
+		)
+		if err != nil {
+			log.Warnf("Unable to watch input %v of anchor tx %v "+
+				"for conflicting spends: %v", prevOut,
+				anchorTxHash, err)
+			continue
+		}
+
+		p.Wg.Add(1)
+		go func() {
+			defer p.Wg.Done()
+			defer spendEvent.Cancel()
+
+			select {
+			case spend, ok := <-spendEvent.Spend:
+				if !ok || *spend.SpenderTxHash == anchorTxHash {
+					return
+				}
+
+				log.Warnf("Input %v of anchor tx %v was spent "+
+					"by conflicting tx %v", prevOut,
+					anchorTxHash, spend.SpenderTxHash)
+
+				p.publishSubscriberEvent(
+					newAnchorInputConflictEvent(
+						transfer, label, prevOut, spend,
+					),
+				)
+
+			case <-ctx.Done():
+			case <-p.Quit:
+			}
+		}()
+	}
+}
+
+// anchorInputScripts returns the pk scripts of the outputs spent by the anchor
+// transaction of the given package. If the package still carries the funded
+// anchor PSBT, the scripts are taken from it. Packages resumed from disk only
+// carry the final anchor transaction, so the previous outputs are looked up in
+// the transactions known to the wallet instead.
+func (p *ChainPorter) anchorInputScripts(
+	pkg *sendPackage) (map[wire.OutPoint][]byte, error) {
+
+	anchorTx := pkg.OutboundPkg.AnchorTx
+	scripts := make(map[wire.OutPoint][]byte, len(anchorTx.TxIn))
+
+	if pkg.AnchorTx != nil && pkg.AnchorTx.FundedPsbt != nil {
+		anchorPkt := pkg.AnchorTx.FundedPsbt.Pkt
+		for idx, txIn := range anchorPkt.UnsignedTx.TxIn {
+			if idx >= len(anchorPkt.Inputs) ||
+				anchorPkt.Inputs[idx].WitnessUtxo == nil {
+
+				continue
+			}
+
+			witnessUtxo := anchorPkt.Inputs[idx].WitnessUtxo
+			scripts[txIn.PreviousOutPoint] = witnessUtxo.PkScript
+		}
+
+		return scripts, nil
+	}
+
+	if p.cfg.Wallet == nil {
+		return nil, fmt.Errorf("no wallet to look up previous outputs")
+	}
+
+	ctx, cancel := p.WithCtxQuit()
+	defer cancel()
+
+	// We only look up the transactions that created the inputs, each of
+	// them only once.
+	prevTxns := make(map[chainhash.Hash]*wire.MsgTx, len(anchorTx.TxIn))
+	for _, txIn := range anchorTx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+		prevTx, ok := prevTxns[prevOut.Hash]
+		if !ok {
+			walletTx, err := p.cfg.Wallet.GetTransaction(
+				ctx, prevOut.Hash,
+			)
+			if err != nil {
+				log.Debugf("Unable to look up previous tx "+
+					"%v: %v", prevOut.Hash, err)
+			}
+
+			prevTx = walletTx.Tx
+			prevTxns[prevOut.Hash] = prevTx
+		}
+
+		if prevTx == nil || int(prevOut.Index) >= len(prevTx.TxOut) {
+			continue
+		}
+
+		scripts[prevOut] = prevTx.TxOut[prevOut.Index].PkScript
+	}
+
+	return scripts, nil
+}
+
 // storeProofs writes the updated sender and receiver proof files to the proof
 // archive.
 func (p *ChainPorter) storeProofs(sendPkg *sendPackage) error {
@@ -525,6 +772,7 @@ func (p *ChainPorter) storeProofs(sendPkg *sendPackage) error {
 		GroupVerifier:  p.cfg.GroupVerifier,
 		ChainLookupGen: p.cfg.ChainBridge,
 		IgnoreChecker:  p.cfg.IgnoreChecker,
+		CacheID:        lfn.Some(proof.DefaultVerifierCacheID),
 	}
 
 	log.Infof("Importing %d passive asset proofs into local Proof "+
@@ -610,6 +858,7 @@ func (p *ChainPorter) storeProofs(sendPkg *sendPackage) error {
 			GroupVerifier:  p.cfg.GroupVerifier,
 			ChainLookupGen: p.cfg.ChainBridge,
 			IgnoreChecker:  p.cfg.IgnoreChecker,
+			CacheID:        lfn.Some(proof.DefaultVerifierCacheID),
 		}
 
 		// Before we import the proof into the proof archive, we'll
@@ -1706,10 +1955,34 @@ func (p *ChainPorter) stateStep(currentPkg sendPackage) (*sendPackage, error) {
 		}
 		currentPkg.OutboundPkg = parcel
 
+		// Any transfer metadata for V2 addresses needs to be persisted
+		// with the transfer outputs, as the send fragments are only
+		// created once the transfer transaction confirmed.
+		addrParcel, ok := currentPkg.Parcel.(*AddressParcel)
+		if ok {
+			addrParcel.attachTransferMetadata(parcel)
+		}
+
 		// Don't allow shutdown while we're attempting to store proofs.
 		ctx, cancel = p.CtxBlocking()
 		defer cancel()
 
+		// Now that the final virtual packets are known, we make sure
+		// none of the outputs goes to a blocked destination. Any
+		// overrides used by this transfer are reserved until we know
+		// whether the transfer could be committed.
+		var usedOverrides map[BlocklistEntry]string
+		if p.cfg.Blocklist != nil {
+			usedOverrides, err = p.cfg.Blocklist.reserveOverrides(
+				&currentPkg,
+			)
+			if err != nil {
+				p.unlockInputs(ctx, &currentPkg)
+
+				return nil, err
+			}
+		}
+
 		log.Infof("Committing pending parcel to disk")
 
 		// Write the parcel to disk as a pending parcel. This step also
@@ -1720,12 +1993,19 @@ func (p *ChainPorter) stateStep(currentPkg sendPackage) (*sendPackage, error) {
 			time.Now().Add(defaultBroadcastCoinLeaseDuration),
 		)
 		if err != nil {
+			if p.cfg.Blocklist != nil {
+				p.cfg.Blocklist.restoreOverrides(usedOverrides)
+			}
 			p.unlockInputs(ctx, &currentPkg)
 
 			return nil, fmt.Errorf("unable to write send pkg to "+
 				"disk: %w", err)
 		}
 
+		if p.cfg.Blocklist != nil {
+			p.cfg.Blocklist.releaseOverrides(usedOverrides)
+		}
+
 		// If skip flag is set—bypass anchor broadcast and advance to
 		// the confirmation wait state.
 		if currentPkg.OutboundPkg.SkipAnchorTxBroadcast {
@@ -2095,6 +2375,60 @@ func newAssetSendEvent(executedState SendState,
 	return newSendEvent
 }
 
+// AnchorInputConflictEvent is an event which is sent to the ChainPorter's
+// event subscribers if an input of an unconfirmed anchor transaction was spent
+// by a different, confirmed transaction. The transfer can then never confirm.
+type AnchorInputConflictEvent struct {
+	// timestamp is the time the event was created.
+	timestamp time.Time
+
+	// AnchorTxHash is the hash of the anchor transaction that was double
+	// spent.
+	AnchorTxHash chainhash.Hash
+
+	// Input is the anchor transaction input that was spent by the
+	// conflicting transaction.
+	Input wire.OutPoint
+
+	// ConflictingTxHash is the hash of the transaction that spent the
+	// input.
+	ConflictingTxHash chainhash.Hash
+
+	// SpendingHeight is the height the conflicting transaction was
+	// confirmed at. Conflicts are only detected once the conflicting
+	// transaction confirmed, so this is always set.
+	SpendingHeight int32
+
+	// TransferLabel is the label that was set for the transfer.
+	TransferLabel string
+
+	// Transfer is the on-disk level information that tracks the pending
+	// transfer.
+	Transfer *OutboundParcel
+}
+
+// Timestamp returns the timestamp of the event.
+func (e *AnchorInputConflictEvent) Timestamp() time.Time {
+	return e.timestamp
+}
+
+// newAnchorInputConflictEvent creates a new AnchorInputConflictEvent for the
+// given transfer and conflicting spend.
+func newAnchorInputConflictEvent(transfer *OutboundParcel, label string,
+	input wire.OutPoint,
+	spend *chainntnfs.SpendDetail) *AnchorInputConflictEvent {
+
+	return &AnchorInputConflictEvent{
+		timestamp:         time.Now().UTC(),
+		AnchorTxHash:      transfer.AnchorTx.TxHash(),
+		Input:             input,
+		ConflictingTxHash: *spend.SpenderTxHash,
+		SpendingHeight:    spend.SpendingHeight,
+		TransferLabel:     label,
+		Transfer:          transfer.Copy(),
+	}
+}
+
 // newAssetSendErrorEvent creates a new AssetSendEvent with an error.
 func newAssetSendErrorEvent(err error, executedState SendState,
 	pkg sendPackage) *AssetSendEvent {
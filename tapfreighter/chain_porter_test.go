@@ -1,18 +1,243 @@
 package tapfreighter
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btclog/v2"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/tapgarden"
+	"github.com/lightninglabs/taproot-assets/tapsend"
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunChainPorter(t *testing.T) {
 	t.Parallel()
 }
 
+// mockSpendNotifier is a SpendNotifier that hands out a spend channel per
+// registered outpoint.
+type mockSpendNotifier struct {
+	sync.Mutex
+
+	spends map[wire.OutPoint]chan *chainntnfs.SpendDetail
+
+	pkScripts map[wire.OutPoint][]byte
+}
+
+// RegisterSpendNtfn registers a new spend channel for the given outpoint.
+func (m *mockSpendNotifier) RegisterSpendNtfn(outpoint *wire.OutPoint,
+	pkScript []byte, _ uint32) (*chainntnfs.SpendEvent, error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	spendChan := make(chan *chainntnfs.SpendDetail, 1)
+	m.spends[*outpoint] = spendChan
+	m.pkScripts[*outpoint] = pkScript
+
+	return &chainntnfs.SpendEvent{
+		Spend:  spendChan,
+		Cancel: func() {},
+	}, nil
+}
+
+// spend notifies about a spend of the given outpoint.
+func (m *mockSpendNotifier) spend(t *testing.T, outpoint wire.OutPoint,
+	spenderTxHash chainhash.Hash) {
+
+	m.Lock()
+	spendChan, ok := m.spends[outpoint]
+	m.Unlock()
+
+	require.True(t, ok)
+	spendChan <- &chainntnfs.SpendDetail{
+		SpentOutPoint: &outpoint,
+		SpenderTxHash: &spenderTxHash,
+	}
+}
+
+// TestWatchAnchorInputSpends tests that a conflicting spend of an anchor
+// transaction input results in an event, while the spend by the anchor
+// transaction itself doesn't.
+func TestWatchAnchorInputSpends(t *testing.T) {
+	t.Parallel()
+
+	notifier := &mockSpendNotifier{
+		spends:    make(map[wire.OutPoint]chan *chainntnfs.SpendDetail),
+		pkScripts: make(map[wire.OutPoint][]byte),
+	}
+	porter := NewChainPorter(&ChainPorterConfig{
+		SpendNotifier: notifier,
+	})
+	t.Cleanup(func() {
+		close(porter.Quit)
+		porter.Wg.Wait()
+	})
+
+	events := fn.NewEventReceiver[fn.Event](fn.DefaultQueueSize)
+	require.NoError(t, porter.RegisterSubscriber(events, false, false))
+
+	inputs := []wire.OutPoint{
+		{Hash: test.RandHash(), Index: 1},
+		{Hash: test.RandHash(), Index: 2},
+	}
+	pkt, err := psbt.New(
+		[]*wire.OutPoint{&inputs[0], &inputs[1]},
+		[]*wire.TxOut{wire.NewTxOut(1_000, test.RandBytes(34))}, 2, 0,
+		[]uint32{0, 0},
+	)
+	require.NoError(t, err)
+	for idx := range pkt.Inputs {
+		pkt.Inputs[idx].WitnessUtxo = wire.NewTxOut(
+			2_000, test.RandBytes(34),
+		)
+	}
+
+	anchorTx := pkt.UnsignedTx.Copy()
+	pkg := &sendPackage{
+		Label: "transfer",
+		AnchorTx: &tapsend.AnchorTransaction{
+			FundedPsbt: &tapsend.FundedPsbt{
+				Pkt: pkt,
+			},
+		},
+		OutboundPkg: &OutboundParcel{
+			AnchorTx: anchorTx,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	porter.watchAnchorInputSpends(ctx, pkg)
+
+	// The spend by the anchor transaction itself isn't reported, the
+	// conflicting spend of the second input is.
+	notifier.spend(t, inputs[0], anchorTx.TxHash())
+
+	conflictingTxHash := test.RandHash()
+	notifier.spend(t, inputs[1], conflictingTxHash)
+
+	select {
+	case event := <-events.NewItemCreated.ChanOut():
+		conflict, ok := event.(*AnchorInputConflictEvent)
+		require.True(t, ok)
+
+		require.Equal(t, anchorTx.TxHash(), conflict.AnchorTxHash)
+		require.Equal(t, inputs[1], conflict.Input)
+		require.Equal(t, conflictingTxHash, conflict.ConflictingTxHash)
+		require.Equal(t, "transfer", conflict.TransferLabel)
+
+	case <-time.After(time.Second * 5):
+		t.Fatalf("no conflict event received")
+	}
+
+	select {
+	case event := <-events.NewItemCreated.ChanOut():
+		t.Fatalf("unexpected event: %v", event)
+
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// mockWalletAnchor extends the tapgarden mock wallet anchor with the methods
+// required by the porter.
+type mockWalletAnchor struct {
+	*tapgarden.MockWalletAnchor
+
+	txns map[chainhash.Hash]*wire.MsgTx
+}
+
+// GetTransaction returns the configured transaction with the given hash.
+func (m *mockWalletAnchor) GetTransaction(_ context.Context,
+	txid chainhash.Hash) (lndclient.Transaction, error) {
+
+	tx, ok := m.txns[txid]
+	if !ok {
+		return lndclient.Transaction{}, fmt.Errorf("tx %v not found",
+			txid)
+	}
+
+	return lndclient.Transaction{
+		Tx: tx,
+	}, nil
+}
+
+// SignPsbt returns the packet unchanged.
+func (m *mockWalletAnchor) SignPsbt(_ context.Context,
+	packet *psbt.Packet) (*psbt.Packet, error) {
+
+	return packet, nil
+}
+
+// TestWatchResumedAnchorInputSpends tests that the inputs of a transfer that
+// was resumed from disk are watched with the pk scripts of the previous
+// outputs found in the wallet.
+func TestWatchResumedAnchorInputSpends(t *testing.T) {
+	t.Parallel()
+
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxOut(wire.NewTxOut(1_000, test.RandBytes(34)))
+	prevTx.AddTxOut(wire.NewTxOut(2_000, test.RandBytes(34)))
+
+	notifier := &mockSpendNotifier{
+		spends:    make(map[wire.OutPoint]chan *chainntnfs.SpendDetail),
+		pkScripts: make(map[wire.OutPoint][]byte),
+	}
+	porter := NewChainPorter(&ChainPorterConfig{
+		Wallet: &mockWalletAnchor{
+			MockWalletAnchor: tapgarden.NewMockWalletAnchor(),
+			txns: map[chainhash.Hash]*wire.MsgTx{
+				prevTx.TxHash(): prevTx,
+			},
+		},
+		SpendNotifier: notifier,
+	})
+	t.Cleanup(func() {
+		close(porter.Quit)
+		porter.Wg.Wait()
+	})
+
+	// The anchor transaction spends the second output of the wallet
+	// transaction and an output the wallet doesn't know about.
+	knownInput := wire.OutPoint{Hash: prevTx.TxHash(), Index: 1}
+	unknownInput := wire.OutPoint{Hash: test.RandHash(), Index: 0}
+
+	anchorTx := wire.NewMsgTx(2)
+	anchorTx.AddTxIn(wire.NewTxIn(&knownInput, nil, nil))
+	anchorTx.AddTxIn(wire.NewTxIn(&unknownInput, nil, nil))
+	anchorTx.AddTxOut(wire.NewTxOut(1_000, test.RandBytes(34)))
+
+	// A resumed package only carries the outbound parcel.
+	pkg := &sendPackage{
+		OutboundPkg: &OutboundParcel{
+			AnchorTx: anchorTx,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	porter.watchAnchorInputSpends(ctx, pkg)
+
+	notifier.Lock()
+	require.Len(t, notifier.pkScripts, 1)
+	require.Equal(
+		t, prevTx.TxOut[1].PkScript, notifier.pkScripts[knownInput],
+	)
+	notifier.Unlock()
+}
+
 func init() {
 	rand.Seed(time.Now().Unix())
 
@@ -0,0 +1,413 @@
+package tapfreighter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tapgarden"
+	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightningnetwork/lnd/chainntnfs"
+)
+
+// SecondLevelHtlcState is the state of the intermediate output of a two-stage
+// HTLC claim that is reported by the HTLC watcher.
+type SecondLevelHtlcState uint8
+
+const (
+	// SecondLevelHtlcConfirmed means the intermediate output confirmed
+	// and its CSV delay started.
+	SecondLevelHtlcConfirmed SecondLevelHtlcState = iota
+
+	// SecondLevelHtlcSweepable means the CSV delay of the intermediate
+	// output passed, so a sweep through the delay path can be included in
+	// the next block.
+	SecondLevelHtlcSweepable
+
+	// SecondLevelHtlcRevoked means the intermediate output was spent
+	// before its CSV delay passed, which is only possible through the
+	// revocation key spend path.
+	SecondLevelHtlcRevoked
+
+	// SecondLevelHtlcSpent means the intermediate output was spent after
+	// its CSV delay passed. The spend can either be the sweep through the
+	// delay path or a late spend through the revocation path.
+	SecondLevelHtlcSpent
+)
+
+// String returns a human-readable representation of the state.
+func (s SecondLevelHtlcState) String() string {
+	switch s {
+	case SecondLevelHtlcConfirmed:
+		return "confirmed"
+
+	case SecondLevelHtlcSweepable:
+		return "sweepable"
+
+	case SecondLevelHtlcRevoked:
+		return "revoked"
+
+	case SecondLevelHtlcSpent:
+		return "spent"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// SecondLevelHtlcEvent is sent to the subscribers of the HTLC watcher whenever
+// a watched intermediate output changes its state.
+type SecondLevelHtlcEvent struct {
+	// timestamp is the time the event was created.
+	timestamp time.Time
+
+	// OutPoint is the anchor outpoint of the intermediate output.
+	OutPoint wire.OutPoint
+
+	// State is the new state of the intermediate output.
+	State SecondLevelHtlcState
+
+	// ConfHeight is the height at which the intermediate output
+	// confirmed.
+	ConfHeight uint32
+
+	// SweepHeight is the first height at which the intermediate output
+	// can be swept through the delay path.
+	SweepHeight uint32
+
+	// Spend is the spend of the intermediate output. It is only set for
+	// the revoked and spent states.
+	Spend *chainntnfs.SpendDetail
+}
+
+// Timestamp returns the timestamp of the event.
+func (e *SecondLevelHtlcEvent) Timestamp() time.Time {
+	return e.timestamp
+}
+
+// A compile-time assertion to ensure that SecondLevelHtlcEvent implements the
+// fn.Event interface.
+var _ fn.Event = (*SecondLevelHtlcEvent)(nil)
+
+// SecondLevelHtlcWatchReq describes an intermediate output of a two-stage HTLC
+// claim that should be watched.
+type SecondLevelHtlcWatchReq struct {
+	// OutPoint is the anchor outpoint of the intermediate output.
+	OutPoint wire.OutPoint
+
+	// PkScript is the pk script of the anchor output.
+	PkScript []byte
+
+	// HeightHint is the height from which on the anchor output is looked
+	// for on chain.
+	HeightHint uint32
+
+	// Tree is the second level tree of the intermediate output.
+	Tree *tapscript.SecondLevelHtlcTree
+}
+
+// HtlcWatcherConfig houses the dependencies of the HTLC watcher.
+type HtlcWatcherConfig struct {
+	// ChainBridge is used to get notified about the confirmation of the
+	// intermediate outputs and about new blocks.
+	ChainBridge ChainBridge
+
+	// SpendNotifier is used to get notified about spends of the
+	// intermediate outputs.
+	SpendNotifier SpendNotifier
+}
+
+// HtlcWatcher watches the intermediate outputs of two-stage HTLC claims. It
+// reports when an output confirmed, when its CSV delay passed so it should be
+// swept, and when it was spent, which before the CSV delay passed can only
+// be a revocation. The watched outputs aren't persisted, so they need to be
+// registered again after a restart.
+type HtlcWatcher struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	cfg *HtlcWatcherConfig
+
+	// eventDistributor is used to distribute the HTLC events to
+	// subscribers.
+	eventDistributor *fn.EventDistributor[fn.Event]
+
+	// ContextGuard provides a wait group and main quit channel that can be
+	// used to create guarded contexts.
+	*fn.ContextGuard
+}
+
+// NewHtlcWatcher creates a new HTLC watcher from the given config.
+func NewHtlcWatcher(cfg *HtlcWatcherConfig) *HtlcWatcher {
+	return &HtlcWatcher{
+		cfg:              cfg,
+		eventDistributor: fn.NewEventDistributor[fn.Event](),
+		ContextGuard: &fn.ContextGuard{
+			DefaultTimeout: tapgarden.DefaultTimeout,
+			Quit:           make(chan struct{}),
+		},
+	}
+}
+
+// Start starts the HTLC watcher.
+func (w *HtlcWatcher) Start() error {
+	w.startOnce.Do(func() {
+		log.Info("Starting HTLC watcher")
+	})
+
+	return nil
+}
+
+// Stop stops the HTLC watcher and all its watches.
+func (w *HtlcWatcher) Stop() error {
+	w.stopOnce.Do(func() {
+		log.Info("Stopping HTLC watcher")
+
+		close(w.Quit)
+		w.Wg.Wait()
+	})
+
+	return nil
+}
+
+// RegisterSubscriber adds a new subscriber for receiving events.
+//
+// NOTE: This is part of the fn.EventPublisher interface.
+func (w *HtlcWatcher) RegisterSubscriber(receiver *fn.EventReceiver[fn.Event],
+	deliverExisting bool, _ bool) error {
+
+	if deliverExisting {
+		return fmt.Errorf("HtlcWatcher does not support delivering " +
+			"existing events")
+	}
+
+	w.eventDistributor.RegisterSubscriber(receiver)
+	return nil
+}
+
+// RemoveSubscriber removes the given subscriber and also stops it from
+// processing events.
+//
+// NOTE: This is part of the fn.EventPublisher interface.
+func (w *HtlcWatcher) RemoveSubscriber(
+	subscriber *fn.EventReceiver[fn.Event]) error {
+
+	return w.eventDistributor.RemoveSubscriber(subscriber)
+}
+
+// A compile-time assertion to ensure HtlcWatcher implements the
+// fn.EventPublisher interface.
+var _ fn.EventPublisher[fn.Event, bool] = (*HtlcWatcher)(nil)
+
+// WatchSecondLevelOutput starts watching the given intermediate output until
+// it is spent or the watcher is stopped.
+func (w *HtlcWatcher) WatchSecondLevelOutput(
+	req *SecondLevelHtlcWatchReq) error {
+
+	if req == nil || req.Tree == nil {
+		return fmt.Errorf("second level tree must be set")
+	}
+
+	ctx, cancel := w.WithCtxQuitNoTimeout()
+
+	chainBridge := w.cfg.ChainBridge
+	confEvent, confErrChan, err := chainBridge.RegisterConfirmationsNtfn(
+		ctx, &req.OutPoint.Hash, req.PkScript, 1, req.HeightHint,
+		false, nil,
+	)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("unable to register for confirmation of "+
+			"%v: %w", req.OutPoint, err)
+	}
+
+	spendEvent, err := w.cfg.SpendNotifier.RegisterSpendNtfn(
+		&req.OutPoint, req.PkScript, req.HeightHint,
+	)
+	if err != nil {
+		confEvent.Cancel()
+		cancel()
+		return fmt.Errorf("unable to register for spend of %v: %w",
+			req.OutPoint, err)
+	}
+
+	log.Infof("Watching second level HTLC output %v (csv_delay=%d)",
+		req.OutPoint, req.Tree.CsvDelay)
+
+	w.Wg.Add(1)
+	go func() {
+		defer w.Wg.Done()
+		defer cancel()
+		defer confEvent.Cancel()
+		defer spendEvent.Cancel()
+
+		err := w.watchSecondLevelOutput(
+			ctx, req, confEvent, confErrChan, spendEvent,
+		)
+		if err != nil {
+			log.Errorf("Unable to watch second level HTLC output "+
+				"%v: %v", req.OutPoint, err)
+		}
+	}()
+
+	return nil
+}
+
+// watchSecondLevelOutput publishes the state changes of the given intermediate
+// output until it is spent or the context is canceled.
+func (w *HtlcWatcher) watchSecondLevelOutput(ctx context.Context,
+	req *SecondLevelHtlcWatchReq, confEvent *chainntnfs.ConfirmationEvent,
+	confErrChan chan error, spendEvent *chainntnfs.SpendEvent) error {
+
+	var (
+		confirmed    bool
+		sweepable    bool
+		confHeight   uint32
+		sweepHeight  uint32
+		blockChan    chan int32
+		blockErrChan chan error
+	)
+	newEvent := func(state SecondLevelHtlcState,
+		spend *chainntnfs.SpendDetail) *SecondLevelHtlcEvent {
+
+		return &SecondLevelHtlcEvent{
+			timestamp:   time.Now().UTC(),
+			OutPoint:    req.OutPoint,
+			State:       state,
+			ConfHeight:  confHeight,
+			SweepHeight: sweepHeight,
+			Spend:       spend,
+		}
+	}
+	confirm := func(conf *chainntnfs.TxConfirmation) error {
+		confirmed = true
+		confHeight = conf.BlockHeight
+		sweepHeight = req.Tree.SweepHeight(confHeight)
+
+		log.Infof("Second level HTLC output %v confirmed at height "+
+			"%d, sweepable at height %d", req.OutPoint, confHeight,
+			sweepHeight)
+
+		w.eventDistributor.NotifySubscribers(
+			newEvent(SecondLevelHtlcConfirmed, nil),
+		)
+
+		// We don't need to track the confirmation anymore, so we
+		// only listen for new blocks from now on.
+		confErrChan = nil
+
+		var err error
+		bridge := w.cfg.ChainBridge
+		blockChan, blockErrChan, err = bridge.RegisterBlockEpochNtfn(
+			ctx,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to register for blocks: %w",
+				err)
+		}
+
+		return nil
+	}
+
+	for {
+		select {
+		case conf, ok := <-confEvent.Confirmed:
+			if !ok {
+				return fmt.Errorf("confirmation channel closed")
+			}
+
+			if err := confirm(conf); err != nil {
+				return err
+			}
+
+		case height := <-blockChan:
+			// The sweep can be included in the next block once
+			// that block reaches the sweep height.
+			if sweepable || uint32(height)+1 < sweepHeight {
+				continue
+			}
+
+			sweepable = true
+			log.Infof("Second level HTLC output %v can now be "+
+				"swept", req.OutPoint)
+
+			w.eventDistributor.NotifySubscribers(
+				newEvent(SecondLevelHtlcSweepable, nil),
+			)
+
+		case spend, ok := <-spendEvent.Spend:
+			if !ok {
+				return fmt.Errorf("spend channel closed")
+			}
+
+			// We need the confirmation height to tell a revocation
+			// apart from a sweep, so we wait for it if the spend
+			// notification arrived first.
+			if !confirmed {
+				conf, err := waitForConf(ctx, confEvent)
+				switch {
+				// We're shutting down.
+				case ctx.Err() != nil:
+					return nil
+
+				case err != nil:
+					return err
+				}
+
+				confHeight = conf.BlockHeight
+				sweepHeight = req.Tree.SweepHeight(confHeight)
+			}
+
+			// The delay path can't be used before the sweep
+			// height, so an earlier spend must be a revocation.
+			state := SecondLevelHtlcSpent
+			if uint32(spend.SpendingHeight) < sweepHeight {
+				state = SecondLevelHtlcRevoked
+
+				log.Warnf("Second level HTLC output %v was "+
+					"revoked by tx %v", req.OutPoint,
+					spend.SpenderTxHash)
+			} else {
+				log.Infof("Second level HTLC output %v was "+
+					"spent by tx %v", req.OutPoint,
+					spend.SpenderTxHash)
+			}
+
+			w.eventDistributor.NotifySubscribers(
+				newEvent(state, spend),
+			)
+
+			return nil
+
+		case err := <-confErrChan:
+			return fmt.Errorf("confirmation error: %w", err)
+
+		case err := <-blockErrChan:
+			return fmt.Errorf("block notification error: %w", err)
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// waitForConf waits for the given confirmation event to be delivered.
+func waitForConf(ctx context.Context,
+	confEvent *chainntnfs.ConfirmationEvent) (*chainntnfs.TxConfirmation,
+	error) {
+
+	select {
+	case conf, ok := <-confEvent.Confirmed:
+		if !ok {
+			return nil, fmt.Errorf("confirmation channel closed")
+		}
+
+		return conf, nil
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
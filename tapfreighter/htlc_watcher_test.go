@@ -0,0 +1,171 @@
+package tapfreighter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/tapgarden"
+	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHtlcChainBridge is a chain bridge that hands out a confirmation channel
+// per registered transaction and a single block channel.
+type mockHtlcChainBridge struct {
+	*tapgarden.MockChainBridge
+
+	confs  chan chan *chainntnfs.TxConfirmation
+	blocks chan int32
+}
+
+// RegisterConfirmationsNtfn registers a new confirmation channel.
+func (m *mockHtlcChainBridge) RegisterConfirmationsNtfn(_ context.Context,
+	_ *chainhash.Hash, _ []byte, _, _ uint32, _ bool,
+	_ chan struct{}) (*chainntnfs.ConfirmationEvent, chan error, error) {
+
+	confChan := make(chan *chainntnfs.TxConfirmation, 1)
+	m.confs <- confChan
+
+	return &chainntnfs.ConfirmationEvent{
+		Confirmed: confChan,
+		Cancel:    func() {},
+	}, make(chan error), nil
+}
+
+// RegisterBlockEpochNtfn returns the block channel of the mock.
+func (m *mockHtlcChainBridge) RegisterBlockEpochNtfn(
+	context.Context) (chan int32, chan error, error) {
+
+	return m.blocks, make(chan error), nil
+}
+
+// TestHtlcWatcher tests that the HTLC watcher reports when an intermediate
+// output confirmed, became sweepable and was spent or revoked.
+func TestHtlcWatcher(t *testing.T) {
+	t.Parallel()
+
+	const csvDelay = 10
+
+	chainBridge := &mockHtlcChainBridge{
+		MockChainBridge: tapgarden.NewMockChainBridge(),
+		confs:           make(chan chan *chainntnfs.TxConfirmation, 2),
+		blocks:          make(chan int32),
+	}
+	notifier := &mockSpendNotifier{
+		spends:    make(map[wire.OutPoint]chan *chainntnfs.SpendDetail),
+		pkScripts: make(map[wire.OutPoint][]byte),
+	}
+	watcher := NewHtlcWatcher(&HtlcWatcherConfig{
+		ChainBridge:   chainBridge,
+		SpendNotifier: notifier,
+	})
+	require.NoError(t, watcher.Start())
+	t.Cleanup(func() {
+		require.NoError(t, watcher.Stop())
+	})
+
+	events := fn.NewEventReceiver[fn.Event](fn.DefaultQueueSize)
+	require.NoError(t, watcher.RegisterSubscriber(events, false, false))
+
+	tree, err := tapscript.NewSecondLevelHtlcTree(
+		test.RandPubKey(t), test.RandPubKey(t), csvDelay,
+	)
+	require.NoError(t, err)
+
+	watch := func(t *testing.T) (wire.OutPoint,
+		chan *chainntnfs.TxConfirmation,
+		chan *chainntnfs.SpendDetail) {
+
+		outPoint := test.RandOp(t)
+		require.NoError(t, watcher.WatchSecondLevelOutput(
+			&SecondLevelHtlcWatchReq{
+				OutPoint:   outPoint,
+				PkScript:   test.RandBytes(34),
+				HeightHint: 100,
+				Tree:       tree,
+			},
+		))
+
+		notifier.Lock()
+		spendChan := notifier.spends[outPoint]
+		notifier.Unlock()
+
+		return outPoint, <-chainBridge.confs, spendChan
+	}
+	receiveEvent := func(t *testing.T) *SecondLevelHtlcEvent {
+		select {
+		case event := <-events.NewItemCreated.ChanOut():
+			htlcEvent, ok := event.(*SecondLevelHtlcEvent)
+			require.True(t, ok)
+
+			return htlcEvent
+
+		case <-time.After(time.Second):
+			t.Fatalf("no event received")
+			return nil
+		}
+	}
+	spend := func(spendChan chan *chainntnfs.SpendDetail,
+		height int32) chainhash.Hash {
+
+		spenderTxHash := test.RandHash()
+		spendChan <- &chainntnfs.SpendDetail{
+			SpenderTxHash:  &spenderTxHash,
+			SpendingHeight: height,
+		}
+
+		return spenderTxHash
+	}
+
+	// The first output confirms, becomes sweepable once the sweep can be
+	// included in the next block and is then swept.
+	outPoint, confChan, spendChan := watch(t)
+	confChan <- &chainntnfs.TxConfirmation{
+		BlockHeight: 200,
+	}
+
+	event := receiveEvent(t)
+	require.Equal(t, outPoint, event.OutPoint)
+	require.Equal(t, SecondLevelHtlcConfirmed, event.State)
+	require.EqualValues(t, 200, event.ConfHeight)
+	require.EqualValues(t, 200+csvDelay, event.SweepHeight)
+
+	chainBridge.blocks <- 200 + csvDelay - 2
+	chainBridge.blocks <- 200 + csvDelay - 1
+	event = receiveEvent(t)
+	require.Equal(t, SecondLevelHtlcSweepable, event.State)
+
+	spenderTxHash := spend(spendChan, 200+csvDelay)
+	event = receiveEvent(t)
+	require.Equal(t, SecondLevelHtlcSpent, event.State)
+	require.Equal(t, spenderTxHash, *event.Spend.SpenderTxHash)
+
+	// The second output is spent before its CSV delay passed, which can
+	// only be a revocation. The spend might be reported before the
+	// confirmation, which the watcher then needs to wait for.
+	outPoint, confChan, spendChan = watch(t)
+	spenderTxHash = spend(spendChan, 300)
+	confChan <- &chainntnfs.TxConfirmation{
+		BlockHeight: 299,
+	}
+
+	event = receiveEvent(t)
+	if event.State == SecondLevelHtlcConfirmed {
+		event = receiveEvent(t)
+	}
+	require.Equal(t, outPoint, event.OutPoint)
+	require.Equal(t, SecondLevelHtlcRevoked, event.State)
+	require.EqualValues(t, 299+csvDelay, event.SweepHeight)
+	require.Equal(t, spenderTxHash, *event.Spend.SpenderTxHash)
+
+	// A watch request needs a tree.
+	require.Error(t, watcher.WatchSecondLevelOutput(
+		&SecondLevelHtlcWatchReq{},
+	))
+}
@@ -0,0 +1,385 @@
+package tapfreighter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tapgarden"
+	"github.com/lightningnetwork/lnd/clock"
+)
+
+const (
+	// DefaultInactivitySweepCheckInterval is the default interval at which
+	// the inactivity sweeper checks for wallet activity.
+	DefaultInactivitySweepCheckInterval = time.Hour
+
+	// InactivitySweepLabel is the label of the transfers created by the
+	// inactivity sweeper.
+	InactivitySweepLabel = "inactivity-sweep"
+)
+
+// InactivitySweepConfig is the user facing configuration of the inactivity
+// sweeper.
+//
+// nolint: lll
+type InactivitySweepConfig struct {
+	Enable bool `long:"enable" description:"If set, all assets of the recovery addresses' asset IDs or groups are swept to those addresses once there was no wallet activity for the configured period. Only enable this if the recovery addresses are under your control."`
+
+	RecoveryAddrs []string `long:"recovery-addr" description:"A V2 Taproot Asset address with a zero amount that all assets of its asset ID or group are swept to after the inactivity period. Can be specified multiple times, once for each asset ID or group."`
+
+	Period time.Duration `long:"period" description:"The period without any incoming or outgoing transfer after which the assets are swept to the recovery addresses. Valid time units are {s, m, h}."`
+
+	CheckInterval time.Duration `long:"check-interval" description:"The interval at which the wallet activity is checked. Valid time units are {s, m, h}."`
+}
+
+// DefaultInactivitySweepConfig returns the default inactivity sweep
+// configuration, which has the sweeper disabled.
+func DefaultInactivitySweepConfig() *InactivitySweepConfig {
+	return &InactivitySweepConfig{
+		CheckInterval: DefaultInactivitySweepCheckInterval,
+	}
+}
+
+// ShipmentRequester is the subset of the chain porter that is used by the
+// inactivity sweeper to look up past transfers and to request new ones.
+type ShipmentRequester interface {
+	// RequestShipment attempts to request that a new send be funneled
+	// through the chain porter.
+	RequestShipment(req Parcel) (*OutboundParcel, error)
+
+	// QueryParcels returns the set of confirmed or unconfirmed parcels.
+	QueryParcels(ctx context.Context,
+		anchorTxHash fn.Option[chainhash.Hash],
+		pending bool) ([]*OutboundParcel, error)
+}
+
+// AddrEventQuerier is used to look up the events of incoming transfers.
+type AddrEventQuerier interface {
+	// QueryEvents returns a list of address events that match the given
+	// query parameters.
+	QueryEvents(context.Context,
+		address.EventQueryParams) ([]*address.Event, error)
+}
+
+// InactivityStartStore persists the time the inactivity sweeper was enabled,
+// so a restart of the daemon doesn't restart the inactivity period.
+type InactivityStartStore interface {
+	// FetchOrStoreStartTime returns the time the inactivity sweeper was
+	// first started. If no start time is stored yet, the given time is
+	// stored and returned.
+	FetchOrStoreStartTime(ctx context.Context,
+		now time.Time) (time.Time, error)
+}
+
+// InactivitySweeperConfig houses the dependencies of the inactivity sweeper.
+type InactivitySweeperConfig struct {
+	// RecoveryAddrs are the addresses the assets are swept to. Each of
+	// them must be a V2 address with a zero amount, so the full balance
+	// of its asset ID or group can be sent to it.
+	RecoveryAddrs []*address.Tap
+
+	// Period is the period without any wallet activity after which the
+	// assets are swept.
+	Period time.Duration
+
+	// CheckInterval is the interval at which the wallet activity is
+	// checked.
+	CheckInterval time.Duration
+
+	// Porter is used to look up outgoing transfers and to send the assets
+	// to the recovery addresses.
+	Porter ShipmentRequester
+
+	// AddrEvents is used to look up incoming transfers.
+	AddrEvents AddrEventQuerier
+
+	// CoinLister is used to determine the balance that is swept.
+	CoinLister CoinLister
+
+	// StartStore persists the time the sweeper was first started.
+	StartStore InactivityStartStore
+
+	// Clock is the clock used to measure the inactivity period.
+	Clock clock.Clock
+}
+
+// Validate makes sure the inactivity sweeper config is sane.
+func (c *InactivitySweeperConfig) Validate() error {
+	if len(c.RecoveryAddrs) == 0 {
+		return fmt.Errorf("at least one recovery address is required")
+	}
+
+	specs := fn.NewSet[asset.Specifier]()
+	for _, addr := range c.RecoveryAddrs {
+		if addr.Version != address.V2 || addr.Amount != 0 {
+			return fmt.Errorf("recovery address %s must be a V2 "+
+				"address with a zero amount", addr.String())
+		}
+
+		spec := asset.NewSpecifierOptionalGroupPubKey(
+			addr.AssetID, addr.GroupKey,
+		)
+		if specs.Contains(spec) {
+			return fmt.Errorf("multiple recovery addresses for "+
+				"asset %s", spec.String())
+		}
+		specs.Add(spec)
+	}
+
+	if c.Period <= 0 {
+		return fmt.Errorf("inactivity period must be positive")
+	}
+
+	if c.CheckInterval <= 0 {
+		return fmt.Errorf("inactivity check interval must be positive")
+	}
+
+	return nil
+}
+
+// InactivitySweeper is an opt-in dead man's switch that sweeps all assets of
+// the configured asset IDs or groups to their recovery addresses once there
+// was no incoming or outgoing transfer for the configured period. This makes
+// sure a custodian's assets aren't stranded if the operator of the node
+// becomes unavailable.
+//
+// The sweeps themselves don't count as wallet activity, so sweeps that failed
+// are retried on every check, while assets received after a sweep are swept
+// again once the inactivity period passed since their receipt. If the wallet
+// never had any activity, the period is measured from the time the sweeper was
+// first started, which is persisted across restarts.
+type InactivitySweeper struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	cfg *InactivitySweeperConfig
+
+	// startTime is the time the sweeper was first started, as loaded from
+	// the start store.
+	startTime time.Time
+
+	// ContextGuard provides a wait group and main quit channel that can be
+	// used to create guarded contexts.
+	*fn.ContextGuard
+}
+
+// NewInactivitySweeper creates a new inactivity sweeper from the given config.
+func NewInactivitySweeper(
+	cfg *InactivitySweeperConfig) (*InactivitySweeper, error) {
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid inactivity sweeper config: %w",
+			err)
+	}
+
+	return &InactivitySweeper{
+		cfg: cfg,
+		ContextGuard: &fn.ContextGuard{
+			DefaultTimeout: tapgarden.DefaultTimeout,
+			Quit:           make(chan struct{}),
+		},
+	}, nil
+}
+
+// Start starts the inactivity check loop.
+func (s *InactivitySweeper) Start() error {
+	var startErr error
+	s.startOnce.Do(func() {
+		log.Infof("Starting inactivity sweeper (period=%v, "+
+			"recovery_addrs=%d)", s.cfg.Period,
+			len(s.cfg.RecoveryAddrs))
+
+		ctx, cancel := s.WithCtxQuit()
+		defer cancel()
+
+		s.startTime, startErr = s.cfg.StartStore.FetchOrStoreStartTime(
+			ctx, s.cfg.Clock.Now(),
+		)
+		if startErr != nil {
+			return
+		}
+
+		s.Wg.Add(1)
+		go s.checkLoop()
+	})
+
+	return startErr
+}
+
+// Stop stops the inactivity check loop.
+func (s *InactivitySweeper) Stop() error {
+	s.stopOnce.Do(func() {
+		log.Info("Stopping inactivity sweeper")
+
+		close(s.Quit)
+		s.Wg.Wait()
+	})
+
+	return nil
+}
+
+// checkLoop checks for wallet activity every check interval and sweeps the
+// assets once the inactivity period has passed.
+func (s *InactivitySweeper) checkLoop() {
+	defer s.Wg.Done()
+
+	ticker := s.cfg.Clock.TickAfter(s.cfg.CheckInterval)
+	for {
+		select {
+		case <-ticker:
+			// Failed sweeps are retried on the next tick, as they
+			// might be caused by a temporary lack of BTC to pay
+			// the chain fees.
+			if err := s.checkInactivity(); err != nil {
+				log.Errorf("Unable to sweep inactive assets: "+
+					"%v", err)
+			}
+
+			ticker = s.cfg.Clock.TickAfter(s.cfg.CheckInterval)
+
+		case <-s.Quit:
+			return
+		}
+	}
+}
+
+// checkInactivity sweeps the assets to the recovery addresses if there was no
+// wallet activity during the inactivity period.
+func (s *InactivitySweeper) checkInactivity() error {
+	ctx, cancel := s.WithCtxQuit()
+	defer cancel()
+
+	lastActivity, err := s.lastActivity(ctx)
+	if err != nil {
+		return err
+	}
+
+	inactiveFor := s.cfg.Clock.Now().Sub(lastActivity)
+	if inactiveFor < s.cfg.Period {
+		log.Debugf("Last wallet activity %v ago, not sweeping",
+			inactiveFor)
+		return nil
+	}
+
+	log.Warnf("No wallet activity for %v, sweeping assets to recovery "+
+		"addresses", inactiveFor)
+
+	var sweepErr error
+	for _, addr := range s.cfg.RecoveryAddrs {
+		if err := s.sweep(ctx, addr); err != nil {
+			sweepErr = errors.Join(sweepErr, err)
+		}
+	}
+
+	return sweepErr
+}
+
+// lastActivity returns the time of the latest incoming or outgoing transfer,
+// or the time the sweeper was first started if that is later.
+func (s *InactivitySweeper) lastActivity(
+	ctx context.Context) (time.Time, error) {
+
+	lastActivity := s.startTime
+
+	for _, pending := range []bool{false, true} {
+		parcels, err := s.cfg.Porter.QueryParcels(
+			ctx, fn.None[chainhash.Hash](), pending,
+		)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to query "+
+				"transfers: %w", err)
+		}
+
+		for _, parcel := range parcels {
+			// Our own sweeps don't count as activity. The swept
+			// assets are no longer available for coin selection,
+			// so only sweeps that failed are retried.
+			if parcel.Label == InactivitySweepLabel {
+				continue
+			}
+
+			if parcel.TransferTime.After(lastActivity) {
+				lastActivity = parcel.TransferTime
+			}
+		}
+	}
+
+	events, err := s.cfg.AddrEvents.QueryEvents(
+		ctx, address.EventQueryParams{
+			CreationTimeFrom: &lastActivity,
+		},
+	)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to query address "+
+			"events: %w", err)
+	}
+
+	for _, event := range events {
+		if event.CreationTime.After(lastActivity) {
+			lastActivity = event.CreationTime
+		}
+	}
+
+	return lastActivity, nil
+}
+
+// sweep sends the full spendable balance of the recovery address' asset ID or
+// group to the recovery address.
+func (s *InactivitySweeper) sweep(ctx context.Context,
+	addr *address.Tap) error {
+
+	spec := asset.NewSpecifierOptionalGroupPubKey(
+		addr.AssetID, addr.GroupKey,
+	)
+	coins, err := s.cfg.CoinLister.ListEligibleCoins(
+		ctx, CommitmentConstraints{
+			AssetSpecifier:    spec,
+			MinAmt:            1,
+			DistinctSpecifier: true,
+			ScriptKeyType:     fn.Some(asset.ScriptKeyBip86),
+		},
+	)
+	switch {
+	case errors.Is(err, ErrMatchingAssetsNotFound):
+		log.Debugf("No assets of %s to sweep", spec.String())
+		return nil
+
+	case err != nil:
+		return fmt.Errorf("unable to list assets of %s: %w",
+			spec.String(), err)
+	}
+
+	var balance uint64
+	for _, coin := range coins {
+		balance += coin.Asset.Amount
+	}
+
+	if balance == 0 {
+		return nil
+	}
+
+	// The recovery address has a zero amount, so we can set the amount to
+	// the full balance, just like a user would do when sending to it.
+	sweepAddr := *addr
+	sweepAddr.Amount = balance
+
+	log.Infof("Sweeping %d units of %s to recovery address", balance,
+		spec.String())
+
+	_, err = s.cfg.Porter.RequestShipment(NewAddressParcel(
+		nil, InactivitySweepLabel, false, &sweepAddr,
+	))
+	if err != nil {
+		return fmt.Errorf("unable to sweep %s: %w", spec.String(),
+			err)
+	}
+
+	return nil
+}
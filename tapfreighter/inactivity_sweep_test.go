@@ -0,0 +1,280 @@
+package tapfreighter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSweepPorter is a mock implementation of the ShipmentRequester
+// interface that records the requested shipments.
+type mockSweepPorter struct {
+	sync.Mutex
+
+	parcels  []*OutboundParcel
+	requests []*AddressParcel
+}
+
+// RequestShipment records the requested shipment.
+func (m *mockSweepPorter) RequestShipment(req Parcel) (*OutboundParcel,
+	error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.requests = append(m.requests, req.(*AddressParcel))
+
+	return &OutboundParcel{}, nil
+}
+
+// QueryParcels returns the configured parcels.
+func (m *mockSweepPorter) QueryParcels(context.Context,
+	fn.Option[chainhash.Hash], bool) ([]*OutboundParcel, error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	return m.parcels, nil
+}
+
+// mockSweepAddrEvents is a mock implementation of the AddrEventQuerier
+// interface.
+type mockSweepAddrEvents struct {
+	events []*address.Event
+}
+
+// QueryEvents returns the configured events.
+func (m *mockSweepAddrEvents) QueryEvents(context.Context,
+	address.EventQueryParams) ([]*address.Event, error) {
+
+	return m.events, nil
+}
+
+// mockSweepCoinLister is a CoinLister that returns the configured coins of
+// each asset.
+type mockSweepCoinLister struct {
+	CoinLister
+
+	coins map[asset.Specifier][]*AnchoredCommitment
+}
+
+// ListEligibleCoins returns the configured coins of the given asset.
+func (m *mockSweepCoinLister) ListEligibleCoins(_ context.Context,
+	constraints CommitmentConstraints) ([]*AnchoredCommitment, error) {
+
+	coins := m.coins[constraints.AssetSpecifier]
+	if len(coins) == 0 {
+		return nil, ErrMatchingAssetsNotFound
+	}
+
+	return coins, nil
+}
+
+// mockStartStore is a mock implementation of the InactivityStartStore
+// interface.
+type mockStartStore struct {
+	startTime fn.Option[time.Time]
+}
+
+// FetchOrStoreStartTime returns the stored start time or stores the given
+// one.
+func (m *mockStartStore) FetchOrStoreStartTime(_ context.Context,
+	now time.Time) (time.Time, error) {
+
+	if m.startTime.IsNone() {
+		m.startTime = fn.Some(now)
+	}
+
+	return m.startTime.UnwrapOr(now), nil
+}
+
+// TestInactivitySweeper tests that assets are only swept to the recovery
+// addresses once there was no wallet activity for the configured period.
+func TestInactivitySweeper(t *testing.T) {
+	t.Parallel()
+
+	newRecoveryAddr := func() *address.Tap {
+		addr, _, _ := address.RandAddrWithVersion(
+			t, &address.RegressionNetTap,
+			address.RandProofCourierAddrForVersion(t, address.V2),
+			address.V2,
+		)
+		addr.Amount = 0
+
+		return addr.Tap
+	}
+
+	recoveryAddr := newRecoveryAddr()
+	emptyAddr := newRecoveryAddr()
+	spec := asset.NewSpecifierOptionalGroupPubKey(
+		recoveryAddr.AssetID, recoveryAddr.GroupKey,
+	)
+
+	startTime := time.Now()
+	testClock := clock.NewTestClock(startTime)
+	porter := &mockSweepPorter{}
+	addrEvents := &mockSweepAddrEvents{}
+	cfg := &InactivitySweeperConfig{
+		RecoveryAddrs: []*address.Tap{recoveryAddr, emptyAddr},
+		Period:        24 * time.Hour,
+		CheckInterval: time.Hour,
+		Porter:        porter,
+		AddrEvents:    addrEvents,
+		CoinLister: &mockSweepCoinLister{
+			coins: map[asset.Specifier][]*AnchoredCommitment{
+				spec: {
+					{Asset: &asset.Asset{Amount: 10}},
+					{Asset: &asset.Asset{Amount: 32}},
+				},
+			},
+		},
+		Clock: testClock,
+	}
+
+	// We don't start the sweeper's check loop, so we can control when
+	// the inactivity is checked.
+	sweeper, err := NewInactivitySweeper(cfg)
+	require.NoError(t, err)
+	sweeper.startTime = startTime
+
+	numSweeps := func() int {
+		porter.Lock()
+		defer porter.Unlock()
+
+		return len(porter.requests)
+	}
+
+	// Nothing is swept before the period passed since the start.
+	testClock.SetTime(startTime.Add(23 * time.Hour))
+	require.NoError(t, sweeper.checkInactivity())
+	require.Zero(t, numSweeps())
+
+	// Recent incoming and outgoing transfers reset the period, but our
+	// own sweeps don't.
+	addrEvents.events = []*address.Event{{
+		CreationTime: startTime.Add(12 * time.Hour),
+	}}
+	porter.parcels = []*OutboundParcel{{
+		TransferTime: startTime.Add(2 * time.Hour),
+	}, {
+		TransferTime: startTime.Add(30 * time.Hour),
+		Label:        InactivitySweepLabel,
+	}}
+	testClock.SetTime(startTime.Add(35 * time.Hour))
+	require.NoError(t, sweeper.checkInactivity())
+	require.Zero(t, numSweeps())
+
+	// Once the period passed, the full balance is swept to the recovery
+	// address. The address without a balance is skipped.
+	testClock.SetTime(startTime.Add(36 * time.Hour))
+	require.NoError(t, sweeper.checkInactivity())
+	require.Equal(t, 1, numSweeps())
+
+	sweep := porter.requests[0]
+	require.Equal(t, InactivitySweepLabel, sweep.label)
+	require.Len(t, sweep.destAddrs, 1)
+	require.EqualValues(t, 42, sweep.destAddrs[0].Amount)
+	require.Equal(t, recoveryAddr.ScriptKey, sweep.destAddrs[0].ScriptKey)
+
+	// The configured recovery address itself isn't modified.
+	require.Zero(t, recoveryAddr.Amount)
+}
+
+// TestInactivitySweeperRestart tests that a restart of the sweeper doesn't
+// restart the inactivity period.
+func TestInactivitySweeperRestart(t *testing.T) {
+	t.Parallel()
+
+	addr, _, _ := address.RandAddrWithVersion(
+		t, &address.RegressionNetTap,
+		address.RandProofCourierAddrForVersion(t, address.V2),
+		address.V2,
+	)
+	addr.Amount = 0
+
+	firstStart := time.Now()
+	testClock := clock.NewTestClock(firstStart)
+	store := &mockStartStore{}
+	newSweeper := func() *InactivitySweeper {
+		sweeper, err := NewInactivitySweeper(&InactivitySweeperConfig{
+			RecoveryAddrs: []*address.Tap{addr.Tap},
+			Period:        24 * time.Hour,
+			CheckInterval: time.Hour,
+			Porter:        &mockSweepPorter{},
+			AddrEvents:    &mockSweepAddrEvents{},
+			StartStore:    store,
+			Clock:         testClock,
+		})
+		require.NoError(t, err)
+
+		return sweeper
+	}
+
+	sweeper := newSweeper()
+	require.NoError(t, sweeper.Start())
+	require.NoError(t, sweeper.Stop())
+	require.Equal(t, firstStart, sweeper.startTime)
+
+	// A later start keeps measuring from the first one.
+	testClock.SetTime(firstStart.Add(12 * time.Hour))
+	sweeper = newSweeper()
+	require.NoError(t, sweeper.Start())
+	require.NoError(t, sweeper.Stop())
+	require.Equal(t, firstStart, sweeper.startTime)
+
+	lastActivity, err := sweeper.lastActivity(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, firstStart, lastActivity)
+}
+
+// TestInactivitySweeperConfigValidate tests that invalid inactivity sweeper
+// configs are rejected.
+func TestInactivitySweeperConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	addr, _, _ := address.RandAddrWithVersion(
+		t, &address.RegressionNetTap,
+		address.RandProofCourierAddrForVersion(t, address.V2),
+		address.V2,
+	)
+	addr.Amount = 0
+
+	validCfg := func() *InactivitySweeperConfig {
+		return &InactivitySweeperConfig{
+			RecoveryAddrs: []*address.Tap{addr.Tap},
+			Period:        time.Hour,
+			CheckInterval: time.Minute,
+		}
+	}
+	require.NoError(t, validCfg().Validate())
+
+	cfg := validCfg()
+	cfg.RecoveryAddrs = nil
+	require.ErrorContains(t, cfg.Validate(), "at least one recovery")
+
+	cfg = validCfg()
+	cfg.RecoveryAddrs = append(cfg.RecoveryAddrs, addr.Tap)
+	require.ErrorContains(t, cfg.Validate(), "multiple recovery")
+
+	fixedAmountAddr := *addr.Tap
+	fixedAmountAddr.Amount = 100
+	cfg = validCfg()
+	cfg.RecoveryAddrs = []*address.Tap{&fixedAmountAddr}
+	require.ErrorContains(t, cfg.Validate(), "zero amount")
+
+	cfg = validCfg()
+	cfg.Period = 0
+	require.ErrorContains(t, cfg.Validate(), "period must be positive")
+
+	cfg = validCfg()
+	cfg.CheckInterval = 0
+	require.ErrorContains(t, cfg.Validate(), "interval must be positive")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/lndclient"
 	"github.com/lightninglabs/taproot-assets/asset"
 	"github.com/lightninglabs/taproot-assets/commitment"
 	"github.com/lightninglabs/taproot-assets/fn"
@@ -21,6 +22,7 @@ import (
 	"github.com/lightninglabs/taproot-assets/tapgarden"
 	"github.com/lightninglabs/taproot-assets/tappsbt"
 	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightningnetwork/lnd/chainntnfs"
 	"github.com/lightningnetwork/lnd/keychain"
 )
 
@@ -329,6 +331,11 @@ type TransferOutput struct {
 
 	// Position is the position of the output in the transfer output list.
 	Position uint64
+
+	// TransferMetadata is the optional, JSON encoded metadata that is sent
+	// to the receiver of a V2 address transfer as part of the encrypted
+	// send fragment.
+	TransferMetadata []byte
 }
 
 // ShouldDeliverProof returns true if a proof corresponding to the subject
@@ -617,6 +624,15 @@ type ExportLog interface {
 // ChainBridge aliases into the ChainBridge of the tapgarden package.
 type ChainBridge = tapgarden.ChainBridge
 
+// SpendNotifier is used to get notified about on-chain spends of outpoints.
+type SpendNotifier interface {
+	// RegisterSpendNtfn registers an intent to be notified once the
+	// outpoint is spent on-chain. The notification is only sent once the
+	// spending transaction has confirmed.
+	RegisterSpendNtfn(outpoint *wire.OutPoint, pkScript []byte,
+		heightHint uint32) (*chainntnfs.SpendEvent, error)
+}
+
 // WalletAnchor aliases into the WalletAnchor of the taparden package.
 type WalletAnchor interface {
 	tapgarden.WalletAnchor
@@ -624,6 +640,10 @@ type WalletAnchor interface {
 	// SignPsbt signs all the inputs it can in the passed-in PSBT packet,
 	// returning a new one with updated signature/witness data.
 	SignPsbt(ctx context.Context, packet *psbt.Packet) (*psbt.Packet, error)
+
+	// GetTransaction returns the wallet transaction with the given hash.
+	GetTransaction(ctx context.Context,
+		txid chainhash.Hash) (lndclient.Transaction, error)
 }
 
 // KeyRing aliases into the KeyRing of the tapgarden package.
@@ -153,6 +153,10 @@ type AddressParcel struct {
 	// testing purposes or to force transfer attempts even if the
 	// proof courier is not immediately reachable.
 	skipProofCourierPingCheck bool
+
+	// transferMetadata is the optional transfer metadata that is sent to
+	// the receivers of V2 addresses, keyed by the encoded address.
+	transferMetadata map[string][]byte
 }
 
 // A compile-time assertion to ensure AddressParcel implements the parcel
@@ -176,6 +180,61 @@ func NewAddressParcel(feeRate *chainfee.SatPerKWeight, label string,
 	}
 }
 
+// AddTransferMetadata attaches the given transfer metadata to the transfer to
+// the given V2 address. The metadata must be a JSON object. It is persisted
+// with the transfer output and sent to the receiver as part of the encrypted
+// send fragment once the transfer confirmed.
+func (p *AddressParcel) AddTransferMetadata(addr *address.Tap,
+	metadata []byte) error {
+
+	if !addr.UsesSendManifests() {
+		return fmt.Errorf("transfer metadata can only be sent to "+
+			"addresses of version %d or later", address.V2)
+	}
+
+	if err := proof.ValidateTransferMetadata(metadata); err != nil {
+		return err
+	}
+
+	addrStr, err := addr.EncodeAddress()
+	if err != nil {
+		return fmt.Errorf("unable to encode address: %w", err)
+	}
+
+	isDestAddr := fn.Any(p.destAddrs, func(destAddr *address.Tap) bool {
+		destAddrStr, err := destAddr.EncodeAddress()
+		return err == nil && destAddrStr == addrStr
+	})
+	if !isDestAddr {
+		return fmt.Errorf("address %s is not a destination of the "+
+			"parcel", addrStr)
+	}
+
+	if p.transferMetadata == nil {
+		p.transferMetadata = make(map[string][]byte)
+	}
+	p.transferMetadata[addrStr] = fn.CopySlice(metadata)
+
+	return nil
+}
+
+// attachTransferMetadata sets the transfer metadata of the parcel on all
+// outputs of the given transfer that were created for the corresponding
+// address.
+func (p *AddressParcel) attachTransferMetadata(transfer *OutboundParcel) {
+	for idx := range transfer.Outputs {
+		out := &transfer.Outputs[idx]
+		if len(out.TapAddress) == 0 {
+			continue
+		}
+
+		metadata, ok := p.transferMetadata[out.TapAddress]
+		if ok {
+			out.TransferMetadata = metadata
+		}
+	}
+}
+
 // pkg returns the send package that should be delivered.
 func (p *AddressParcel) pkg() *sendPackage {
 	addrStrings := fn.Map(p.destAddrs, func(addr *address.Tap) string {
@@ -0,0 +1,209 @@
+package tapfreighter
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lightninglabs/taproot-assets/fn"
+)
+
+// sendStateTransitions maps each send state to the states the porter can move
+// to after successfully executing it. The porter refuses to take any
+// transition that isn't listed here, so the graph rendered from this table
+// always matches the state machine.
+var sendStateTransitions = map[SendState][]SendState{
+	SendStateStartHandleAddrParcel: {
+		SendStateVirtualCommitmentSelect,
+	},
+	SendStateVirtualCommitmentSelect: {
+		SendStateVirtualSign,
+	},
+	SendStateVirtualSign: {
+		SendStateAnchorSign,
+	},
+	SendStateAnchorSign: {
+		SendStateStorePreBroadcast,
+	},
+	SendStateStorePreBroadcast: {
+		SendStateBroadcast,
+		SendStateWaitTxConf,
+	},
+	SendStateBroadcast: {
+		SendStateWaitTxConf,
+	},
+	SendStateWaitTxConf: {
+		SendStateStorePostAnchorTxConf,
+	},
+	SendStateStorePostAnchorTxConf: {
+		SendStateTransferProofs,
+	},
+	SendStateTransferProofs: {
+		SendStateComplete,
+	},
+}
+
+// SendStateTransitions returns the states the porter can move to after
+// successfully executing the given state.
+func SendStateTransitions(state SendState) []SendState {
+	return append([]SendState(nil), sendStateTransitions[state]...)
+}
+
+// validSendTransition returns true if the porter may move from the given state
+// to the next state. Staying in the same state is always allowed, which happens
+// for the final state and if a state is interrupted by a shutdown.
+func validSendTransition(state, next SendState) bool {
+	if state == next {
+		return true
+	}
+
+	return slices.Contains(sendStateTransitions[state], next)
+}
+
+// TransferTraceEntry is a single executed state of a transfer's state machine.
+type TransferTraceEntry struct {
+	// Timestamp is the time the state was executed.
+	Timestamp time.Time `json:"timestamp"`
+
+	// State is the state that was executed.
+	State SendState `json:"-"`
+
+	// StateName is the human-readable name of the executed state.
+	StateName string `json:"state"`
+
+	// NextState is the state the porter moved to after executing State.
+	NextState SendState `json:"-"`
+
+	// NextStateName is the human-readable name of the next state.
+	NextStateName string `json:"next_state"`
+
+	// Error is the error that occurred while executing State, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// TransferTrace is the recorded state machine history of a single transfer.
+// It can be exported as JSON or as a DOT graph to debug stuck transfers.
+type TransferTrace struct {
+	// Label is the label of the transfer.
+	Label string `json:"label,omitempty"`
+
+	// AnchorTxid is the anchor transaction ID of the transfer, once it is
+	// known.
+	AnchorTxid string `json:"anchor_txid,omitempty"`
+
+	// Entries is the list of executed states, in the order they were
+	// executed.
+	Entries []TransferTraceEntry `json:"entries"`
+}
+
+// NewTransferTrace creates a new transfer trace from the send events that
+// were published for a single transfer, in the order they were received.
+func NewTransferTrace(events ...*AssetSendEvent) *TransferTrace {
+	trace := &TransferTrace{
+		Entries: make([]TransferTraceEntry, 0, len(events)),
+	}
+	for _, event := range events {
+		trace.Add(event)
+	}
+
+	return trace
+}
+
+// Add appends the given send event to the trace.
+func (t *TransferTrace) Add(event *AssetSendEvent) {
+	entry := TransferTraceEntry{
+		Timestamp:     event.Timestamp(),
+		State:         event.SendState,
+		StateName:     event.SendState.String(),
+		NextState:     event.NextSendState,
+		NextStateName: event.NextSendState.String(),
+	}
+	if event.Error != nil {
+		entry.Error = event.Error.Error()
+	}
+
+	if event.TransferLabel != "" {
+		t.Label = event.TransferLabel
+	}
+	switch {
+	case event.AnchorTx != nil && event.AnchorTx.FinalTx != nil:
+		t.AnchorTxid = event.AnchorTx.FinalTx.TxHash().String()
+
+	// Transfers resumed from disk only carry the final anchor transaction
+	// in the outbound parcel.
+	case event.Transfer != nil && event.Transfer.AnchorTx != nil:
+		t.AnchorTxid = event.Transfer.AnchorTx.TxHash().String()
+	}
+
+	t.Entries = append(t.Entries, entry)
+}
+
+// Copy returns a deep copy of the trace.
+func (t *TransferTrace) Copy() *TransferTrace {
+	return &TransferTrace{
+		Label:      t.Label,
+		AnchorTxid: t.AnchorTxid,
+		Entries:    fn.CopySlice(t.Entries),
+	}
+}
+
+// JSON returns the JSON encoding of the trace.
+func (t *TransferTrace) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// DOT returns the full send state machine as a graph in the DOT language,
+// with the states and transitions executed in this trace highlighted. States
+// that failed are colored red.
+func (t *TransferTrace) DOT() string {
+	var (
+		first   = SendStateStartHandleAddrParcel
+		last    = SendStateComplete
+		visited = make(map[SendState]bool)
+		failed  = make(map[SendState]bool)
+		edges   = make(map[[2]SendState]bool)
+	)
+	for _, entry := range t.Entries {
+		visited[entry.State] = true
+		if entry.Error != "" {
+			failed[entry.State] = true
+			continue
+		}
+
+		visited[entry.NextState] = true
+		edges[[2]SendState{entry.State, entry.NextState}] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph send_state_machine {\n")
+	b.WriteString("\trankdir=LR;\n")
+
+	for state := first; state <= last; state++ {
+		attrs := ""
+		switch {
+		case failed[state]:
+			attrs = ` [style=filled, fillcolor="red"]`
+
+		case visited[state]:
+			attrs = ` [style=filled, fillcolor="lightgreen"]`
+		}
+		fmt.Fprintf(&b, "\t%q%s;\n", state.String(), attrs)
+	}
+
+	for state := first; state <= last; state++ {
+		for _, next := range sendStateTransitions[state] {
+			attrs := ""
+			if edges[[2]SendState{state, next}] {
+				attrs = " [penwidth=2]"
+			}
+			fmt.Fprintf(&b, "\t%q -> %q%s;\n", state.String(),
+				next.String(), attrs)
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package tapfreighter
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransferTrace tests that a transfer trace correctly records the send
+// events and exports them as JSON and DOT.
+func TestTransferTrace(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	events := []*AssetSendEvent{{
+		timestamp:     now,
+		SendState:     SendStateAnchorSign,
+		NextSendState: SendStateStorePreBroadcast,
+		TransferLabel: "payout",
+	}, {
+		timestamp:     now.Add(time.Second),
+		SendState:     SendStateStorePreBroadcast,
+		NextSendState: SendStateBroadcast,
+	}, {
+		timestamp:     now.Add(2 * time.Second),
+		SendState:     SendStateBroadcast,
+		NextSendState: SendStateBroadcast,
+		Error:         errors.New("insufficient fee"),
+	}}
+
+	trace := NewTransferTrace(events...)
+	require.Equal(t, "payout", trace.Label)
+	require.Len(t, trace.Entries, 3)
+	require.Equal(t, "insufficient fee", trace.Entries[2].Error)
+
+	jsonBytes, err := trace.JSON()
+	require.NoError(t, err)
+
+	var decoded TransferTrace
+	require.NoError(t, json.Unmarshal(jsonBytes, &decoded))
+	require.Equal(t, "payout", decoded.Label)
+	require.Len(t, decoded.Entries, 3)
+	require.Equal(
+		t, SendStateStorePreBroadcast.String(),
+		decoded.Entries[0].NextStateName,
+	)
+
+	dot := trace.DOT()
+	require.Contains(t, dot, "digraph send_state_machine {")
+	require.Contains(
+		t, dot, `"SendStateAnchorSign" -> `+
+			`"SendStateStorePreBroadcast" [penwidth=2];`,
+	)
+	require.Contains(
+		t, dot, `"SendStateBroadcast" [style=filled, fillcolor="red"];`,
+	)
+	require.Contains(
+		t, dot, `"SendStateWaitTxConf" -> `+
+			`"SendStateStorePostAnchorTxConf";`,
+	)
+
+	// Every state except the final one must have at least one
+	// transition.
+	first, last := SendStateStartHandleAddrParcel, SendStateComplete
+	for state := first; state < last; state++ {
+		require.NotEmpty(t, SendStateTransitions(state), state)
+	}
+	require.Empty(t, SendStateTransitions(SendStateComplete))
+}
+
+// TestValidSendTransition tests that only the transitions of the transition
+// table are accepted.
+func TestValidSendTransition(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, validSendTransition(
+		SendStateStorePreBroadcast, SendStateBroadcast,
+	))
+	require.True(t, validSendTransition(
+		SendStateStorePreBroadcast, SendStateWaitTxConf,
+	))
+	require.True(t, validSendTransition(
+		SendStateWaitTxConf, SendStateWaitTxConf,
+	))
+	require.True(t, validSendTransition(
+		SendStateComplete, SendStateComplete,
+	))
+
+	require.False(t, validSendTransition(
+		SendStateVirtualSign, SendStateBroadcast,
+	))
+	require.False(t, validSendTransition(
+		SendStateComplete, SendStateStartHandleAddrParcel,
+	))
+}
+
+// TestChainPorterTransferTraces tests that the porter records the executed
+// states of each transfer and only keeps the most recent traces.
+func TestChainPorterTransferTraces(t *testing.T) {
+	t.Parallel()
+
+	porter := NewChainPorter(&ChainPorterConfig{})
+
+	anchorTx := wire.NewMsgTx(2)
+	anchorTx.AddTxOut(wire.NewTxOut(1_000, test.RandBytes(34)))
+
+	trace := porter.newTransferTrace()
+	porter.recordTransition(trace, &AssetSendEvent{
+		SendState:     SendStateWaitTxConf,
+		NextSendState: SendStateStorePostAnchorTxConf,
+		TransferLabel: "resumed",
+		Transfer: &OutboundParcel{
+			AnchorTx: anchorTx,
+		},
+	})
+
+	fetched, ok := porter.FetchTransferTrace(anchorTx.TxHash())
+	require.True(t, ok)
+	require.Equal(t, "resumed", fetched.Label)
+	require.Len(t, fetched.Entries, 1)
+
+	// The returned trace is a copy that isn't modified by later
+	// transitions.
+	porter.recordTransition(trace, &AssetSendEvent{
+		SendState:     SendStateStorePostAnchorTxConf,
+		NextSendState: SendStateTransferProofs,
+	})
+	require.Len(t, fetched.Entries, 1)
+
+	fetched, ok = porter.FetchTransferTrace(anchorTx.TxHash())
+	require.True(t, ok)
+	require.Len(t, fetched.Entries, 2)
+
+	_, ok = porter.FetchTransferTrace(test.RandHash())
+	require.False(t, ok)
+
+	// Only the most recent traces are kept.
+	for range maxTransferTraces {
+		porter.newTransferTrace()
+	}
+	require.Len(t, porter.TransferTraces(), maxTransferTraces)
+
+	_, ok = porter.FetchTransferTrace(anchorTx.TxHash())
+	require.False(t, ok)
+}
@@ -453,6 +453,13 @@ func createSendManifests(chainParams *address.ChainParams,
 			ScriptKey:    asset.ToSerialized(scriptKey.PubKey),
 		}
 
+		// All outputs of a fragment were created for the same address,
+		// so they carry the same transfer metadata.
+		if len(vOut.TransferMetadata) > 0 {
+			fragment := &manifest.Fragment
+			fragment.TransferMetadata = vOut.TransferMetadata
+		}
+
 		return nil
 	}
 
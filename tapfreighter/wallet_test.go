@@ -0,0 +1,105 @@
+package tapfreighter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/proof"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSendManifestTransferMetadata tests that the transfer metadata attached
+// to an address parcel ends up in the send fragment for that address, after
+// being round-tripped through the transfer outputs.
+func TestSendManifestTransferMetadata(t *testing.T) {
+	t.Parallel()
+
+	params := &address.RegressionNetTap
+	newAddr := func(version address.Version) *address.Tap {
+		addr, _, _ := address.RandAddrWithVersion(
+			t, params,
+			address.RandProofCourierAddrForVersion(t, version),
+			version,
+		)
+
+		return addr.Tap
+	}
+	v2Addr := newAddr(address.V2)
+	otherV2Addr := newAddr(address.V2)
+	v1Addr := newAddr(address.V1)
+
+	metadata := []byte(`{"beneficiary":"bob"}`)
+	parcel := NewAddressParcel(nil, "", false, v2Addr, v1Addr)
+
+	// Only valid JSON objects can be sent to V2 addresses that are part of
+	// the parcel.
+	err := parcel.AddTransferMetadata(v1Addr, metadata)
+	require.ErrorContains(t, err, "addresses of version")
+
+	err = parcel.AddTransferMetadata(otherV2Addr, metadata)
+	require.ErrorContains(t, err, "not a destination")
+
+	err = parcel.AddTransferMetadata(v2Addr, []byte("bob"))
+	require.ErrorContains(t, err, "must be a JSON object")
+
+	require.NoError(t, parcel.AddTransferMetadata(v2Addr, metadata))
+
+	// The metadata is attached to the transfer output that was created for
+	// the address.
+	outAsset := asset.RandAsset(t, asset.Normal)
+	scriptKey, err := asset.DeriveUniqueScriptKey(
+		v2Addr.ScriptKey, outAsset.ID(),
+		asset.ScriptKeyDerivationUniquePedersen,
+	)
+	require.NoError(t, err)
+
+	// The asset ID is extracted from the proof suffix, so we only need to
+	// encode the asset leaf of a proof.
+	var proofSuffix bytes.Buffer
+	_, err = proofSuffix.Write(proof.PrefixMagicBytes[:])
+	require.NoError(t, err)
+
+	stream, err := tlv.NewStream(proof.AssetLeafRecord(outAsset))
+	require.NoError(t, err)
+	require.NoError(t, stream.Encode(&proofSuffix))
+
+	addrStr, err := v2Addr.EncodeAddress()
+	require.NoError(t, err)
+	courierAddr := []byte(v2Addr.ProofCourierAddr.String())
+
+	transfer := &OutboundParcel{
+		Outputs: []TransferOutput{{
+			Amount:           outAsset.Amount,
+			ScriptKey:        scriptKey,
+			ProofSuffix:      proofSuffix.Bytes(),
+			ProofCourierAddr: courierAddr,
+			TapAddress:       addrStr,
+		}, {
+			Amount: 1,
+		}},
+	}
+	parcel.attachTransferMetadata(transfer)
+	require.Equal(t, metadata, transfer.Outputs[0].TransferMetadata)
+	require.Empty(t, transfer.Outputs[1].TransferMetadata)
+
+	// The metadata then ends up in the send fragment, which survives the
+	// encoding for the receiver.
+	transfer.Outputs = transfer.Outputs[:1]
+	manifests, err := createSendManifests(params, transfer)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+
+	fragment := manifests[0].Fragment
+	require.Equal(t, metadata, fragment.TransferMetadata)
+
+	fragmentBytes, err := fn.Encode(&fragment)
+	require.NoError(t, err)
+
+	var decoded proof.SendFragment
+	require.NoError(t, decoded.Decode(bytes.NewReader(fragmentBytes)))
+	require.Equal(t, metadata, decoded.TransferMetadata)
+}
@@ -157,6 +157,13 @@ type CustodianConfig struct {
 	// a proof should be ignored.
 	IgnoreChecker lfn.Option[proof.IgnoreChecker]
 
+	// TransferMetadataHandler is an optional callback that is invoked
+	// with the address event and the transfer metadata a sender attached
+	// to a received send fragment. If it is nil, any attached metadata is
+	// ignored.
+	TransferMetadataHandler func(ctx context.Context, event *address.Event,
+		metadata []byte) error
+
 	// ErrChan is the main error channel the custodian will report back
 	// critical errors to the main server.
 	ErrChan chan<- error
@@ -1061,6 +1068,26 @@ func (c *Custodian) handleMailboxMessages(msgs *mbox.ReceivedMessages) error {
 		// Let's update our cache of ongoing events.
 		c.events[op] = event
 
+		// Hand any transfer metadata the sender attached over to the
+		// handler, if the receiver opted in to processing it. Invalid
+		// metadata was already dropped when decoding the fragment.
+		if len(fragment.TransferMetadata) > 0 &&
+			c.cfg.TransferMetadataHandler != nil {
+
+			ctxt, cancel := c.CtxBlocking()
+			err := c.cfg.TransferMetadataHandler(
+				ctxt, event, fragment.TransferMetadata,
+			)
+			cancel()
+
+			// The metadata is only informational, so we don't
+			// abort receiving the assets if it can't be handled.
+			if err != nil {
+				log.Warnf("Unable to handle transfer metadata "+
+					"for %v: %v", op, err)
+			}
+		}
+
 		// We'll want to ratchet forward the start block height for new
 		// mailbox queries, so we don't always receive the same events
 		// over and over again. We'll use the maximum block height
@@ -1146,6 +1173,17 @@ func (c *Custodian) decryptMailboxMsg(ctx context.Context,
 		return nil, fmt.Errorf("invalid send fragment: %w", err)
 	}
 
+	// The transfer metadata is only informational, so we drop it instead
+	// of rejecting the whole fragment if it's invalid.
+	if len(fragment.TransferMetadata) > 0 {
+		err := proof.ValidateTransferMetadata(fragment.TransferMetadata)
+		if err != nil {
+			log.Warnf("Dropping invalid transfer metadata of send "+
+				"fragment for %v: %v", fragment.OutPoint, err)
+			fragment.TransferMetadata = nil
+		}
+	}
+
 	return fragment, nil
 }
 
@@ -1615,5 +1653,6 @@ func (c *Custodian) verifierCtx(ctx context.Context) proof.VerifierCtx {
 		GroupVerifier:  c.cfg.GroupVerifier,
 		ChainLookupGen: c.cfg.ChainBridge,
 		IgnoreChecker:  c.cfg.IgnoreChecker,
+		CacheID:        lfn.Some(proof.DefaultVerifierCacheID),
 	}
 }
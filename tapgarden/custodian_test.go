@@ -679,6 +679,16 @@ func TestV2AddressHandling(t *testing.T) {
 	err = h.tapdbBook.InsertAddrs(ctxb, *addr)
 	require.NoError(t, err)
 
+	// Any transfer metadata is stored with the address event, the same
+	// way tapd does it.
+	h.cfg.TransferMetadataHandler = func(ctx context.Context,
+		event *address.Event, metadata []byte) error {
+
+		return h.addrBook.SetEventTransferMetadata(
+			ctx, event.ID, metadata,
+		)
+	}
+
 	// We now start the custodian and make sure it's started up correctly.
 	require.NoError(t, h.c.Start())
 	t.Cleanup(func() {
@@ -734,8 +744,9 @@ func TestV2AddressHandling(t *testing.T) {
 				DerivationMethod: derivationMethod,
 			},
 		},
-		BlockHeight: 123,
-		BlockHeader: blockHeader,
+		BlockHeight:      123,
+		BlockHeader:      blockHeader,
+		TransferMetadata: []byte(`{"originator":"alice"}`),
 	}
 	h.chainBridge.Blocks[block.BlockHash()] = block
 
@@ -778,8 +789,10 @@ func TestV2AddressHandling(t *testing.T) {
 		ProofBlockHeight: 123,
 	})
 
-	// We expect one event to be created, and it should be completed.
-	h.assertEventsPresent(1, address.StatusCompleted)
+	// We expect one event to be created, and it should be completed. The
+	// transfer metadata of the fragment is stored with the event.
+	events := h.assertEventsPresent(1, address.StatusCompleted)
+	require.Equal(t, fragment.TransferMetadata, events[0].TransferMetadata)
 
 	dbProof, err := h.assetDB.FetchProof(ctxb, mockProof.Locator)
 	require.NoError(t, err)
@@ -417,6 +417,11 @@ type KeyRing interface {
 	DeriveNextKey(context.Context,
 		keychain.KeyFamily) (keychain.KeyDescriptor, error)
 
+	// DeriveKey derives the key at the given key locator. This can be used
+	// to re-derive the public key of a previously issued key descriptor.
+	DeriveKey(context.Context,
+		keychain.KeyLocator) (keychain.KeyDescriptor, error)
+
 	// IsLocalKey returns true if the key is under the control of the wallet
 	// and can be derived by it.
 	IsLocalKey(context.Context, keychain.KeyDescriptor) bool
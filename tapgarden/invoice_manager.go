@@ -0,0 +1,543 @@
+package tapgarden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightningnetwork/lnd/clock"
+)
+
+const (
+	// DefaultInvoiceExpiryCheckInterval is the default interval in which
+	// open invoices are checked for their expiry.
+	DefaultInvoiceExpiryCheckInterval = time.Minute
+)
+
+var (
+	// ErrInvoiceAddrInUse is returned when a new invoice is created for an
+	// address that still has an unpaid (open, underpaid or expired)
+	// invoice. Since payments are detected per address, only one unpaid
+	// invoice can exist per address.
+	ErrInvoiceAddrInUse = errors.New("address already has an unpaid " +
+		"invoice")
+)
+
+// ReceiveEventSource is the source of asset receive events, usually the
+// custodian.
+type ReceiveEventSource = fn.EventPublisher[fn.Event, time.Time]
+
+// AddrEventQuerier is used to query the address events that belong to an
+// address.
+type AddrEventQuerier interface {
+	// QueryEvents returns all address events that match the given query.
+	QueryEvents(context.Context,
+		address.EventQueryParams) ([]*address.Event, error)
+}
+
+// InvoiceEvent is an event that is sent to a subscriber whenever the status
+// or received amount of an invoice changes.
+type InvoiceEvent struct {
+	// timestamp is the time the event was created.
+	timestamp time.Time
+
+	// Invoice is a copy of the invoice after the change.
+	Invoice *address.Invoice
+}
+
+// Timestamp returns the timestamp of the event.
+func (e *InvoiceEvent) Timestamp() time.Time {
+	return e.timestamp
+}
+
+// NewInvoiceEvent creates a new InvoiceEvent for the given invoice.
+func NewInvoiceEvent(invoice *address.Invoice) *InvoiceEvent {
+	return &InvoiceEvent{
+		timestamp: time.Now().UTC(),
+		Invoice:   invoice.Copy(),
+	}
+}
+
+// InvoiceManagerConfig houses all the items that the invoice manager needs to
+// carry out its duties.
+type InvoiceManagerConfig struct {
+	// InvoiceStore is the persistent storage of asset invoices.
+	InvoiceStore address.InvoiceStore
+
+	// AddrEvents is used to query the completed receive events of an
+	// invoice's address.
+	AddrEvents AddrEventQuerier
+
+	// ReceiveEvents is the source of asset receive events we use to
+	// detect invoice payments.
+	ReceiveEvents ReceiveEventSource
+
+	// Clock is the clock used to determine invoice expiry.
+	Clock clock.Clock
+
+	// ExpiryCheckInterval is the interval in which open invoices are
+	// checked for their expiry.
+	ExpiryCheckInterval time.Duration
+
+	// ErrChan is the main error channel the invoice manager will report
+	// back critical errors to the main server.
+	ErrChan chan<- error
+}
+
+// InvoiceManager keeps track of asset invoices. It detects payments to the
+// invoices' addresses, marks invoices as paid, underpaid or expired and
+// notifies subscribers about those changes.
+type InvoiceManager struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	cfg *InvoiceManagerConfig
+
+	// receiveSubscription is the subscription queue through which we
+	// receive asset receive events.
+	receiveSubscription *fn.EventReceiver[fn.Event]
+
+	// invoiceMtx serializes the creation and the updates of invoices.
+	invoiceMtx sync.Mutex
+
+	// eventSubs is a map of subscribers that want to be notified on
+	// invoice events, keyed by their subscription ID.
+	eventSubs map[uint64]*fn.EventReceiver[fn.Event]
+
+	// eventSubsMtx guards the invoice event subscribers map.
+	eventSubsMtx sync.Mutex
+
+	// ContextGuard provides a wait group and main quit channel that can be
+	// used to create guarded contexts.
+	*fn.ContextGuard
+}
+
+// NewInvoiceManager creates a new invoice manager based on the passed config.
+func NewInvoiceManager(cfg *InvoiceManagerConfig) *InvoiceManager {
+	return &InvoiceManager{
+		cfg: cfg,
+		receiveSubscription: fn.NewEventReceiver[fn.Event](
+			fn.DefaultQueueSize,
+		),
+		eventSubs: make(map[uint64]*fn.EventReceiver[fn.Event]),
+		ContextGuard: &fn.ContextGuard{
+			DefaultTimeout: DefaultTimeout,
+			Quit:           make(chan struct{}),
+		},
+	}
+}
+
+// Start attempts to start the invoice manager.
+func (m *InvoiceManager) Start() error {
+	var startErr error
+	m.startOnce.Do(func() {
+		log.Info("Starting invoice manager")
+
+		err := m.cfg.ReceiveEvents.RegisterSubscriber(
+			m.receiveSubscription, false, time.Time{},
+		)
+		if err != nil {
+			startErr = fmt.Errorf("unable to subscribe to receive "+
+				"events: %w", err)
+			return
+		}
+
+		// Payments might have been received while we were offline, so
+		// we bring all unsettled invoices up to date before processing
+		// new events.
+		err = m.syncUnsettledInvoices()
+		if err != nil {
+			startErr = err
+			return
+		}
+
+		m.Wg.Add(1)
+		go m.watchInvoices()
+	})
+	return startErr
+}
+
+// Stop signals for the invoice manager to gracefully exit.
+func (m *InvoiceManager) Stop() error {
+	var stopErr error
+	m.stopOnce.Do(func() {
+		log.Info("Stopping invoice manager")
+
+		close(m.Quit)
+		m.Wg.Wait()
+
+		err := m.cfg.ReceiveEvents.RemoveSubscriber(
+			m.receiveSubscription,
+		)
+		if err != nil {
+			stopErr = err
+		}
+
+		// Remove all invoice event subscribers.
+		m.eventSubsMtx.Lock()
+		defer m.eventSubsMtx.Unlock()
+
+		for _, subscriber := range m.eventSubs {
+			subscriber.Stop()
+			delete(m.eventSubs, subscriber.ID())
+		}
+	})
+
+	return stopErr
+}
+
+// NewInvoice creates and stores a new invoice for the given address, which
+// must already be known to the address book. If the amount is zero, the
+// amount of the address is requested.
+func (m *InvoiceManager) NewInvoice(ctx context.Context,
+	addr *address.AddrWithKeyInfo, amount uint64, memo string,
+	expiry time.Duration) (*address.Invoice, error) {
+
+	m.invoiceMtx.Lock()
+	defer m.invoiceMtx.Unlock()
+
+	invoice, err := address.NewInvoice(
+		addr, amount, memo, m.cfg.Clock.Now(), expiry,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := m.cfg.InvoiceStore.QueryInvoices(
+		ctx, address.InvoiceQuery{
+			AddrTaprootOutputKey: schnorr.SerializePubKey(
+				&addr.TaprootOutputKey,
+			),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query invoices: %w", err)
+	}
+	for _, existingInvoice := range existing {
+		if isUnsettled(existingInvoice) {
+			return nil, fmt.Errorf("%w: invoice %d",
+				ErrInvoiceAddrInUse, existingInvoice.ID)
+		}
+	}
+
+	if err := m.cfg.InvoiceStore.InsertInvoice(ctx, invoice); err != nil {
+		return nil, fmt.Errorf("unable to store invoice: %w", err)
+	}
+
+	log.Infof("Created invoice %d for %d units of asset %v", invoice.ID,
+		invoice.Amount, addr.AssetID)
+
+	m.publishInvoiceEvent(invoice)
+
+	return invoice, nil
+}
+
+// QueryInvoices returns all invoices that match the given query.
+func (m *InvoiceManager) QueryInvoices(ctx context.Context,
+	query address.InvoiceQuery) ([]*address.Invoice, error) {
+
+	return m.cfg.InvoiceStore.QueryInvoices(ctx, query)
+}
+
+// watchInvoices processes asset receive events and periodically checks open
+// invoices for their expiry.
+func (m *InvoiceManager) watchInvoices() {
+	defer m.Wg.Done()
+
+	reportErr := func(err error) {
+		select {
+		case m.cfg.ErrChan <- err:
+		case <-m.Quit:
+		}
+	}
+
+	checkInterval := m.cfg.ExpiryCheckInterval
+	if checkInterval == 0 {
+		checkInterval = DefaultInvoiceExpiryCheckInterval
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-m.receiveSubscription.NewItemCreated.ChanOut():
+			receiveEvent, ok := event.(*AssetReceiveEvent)
+			if !ok {
+				continue
+			}
+
+			err := m.handleReceiveEvent(receiveEvent)
+			if err != nil {
+				reportErr(err)
+				return
+			}
+
+		case <-ticker.C:
+			if err := m.expireInvoices(); err != nil {
+				reportErr(err)
+				return
+			}
+
+		case <-m.Quit:
+			return
+		}
+	}
+}
+
+// handleReceiveEvent updates all unsettled invoices of the address an asset
+// was received on.
+func (m *InvoiceManager) handleReceiveEvent(event *AssetReceiveEvent) error {
+	// Only completed receives count towards an invoice, as the assets
+	// aren't spendable before that.
+	if event.Error != nil || event.Status != address.StatusCompleted {
+		return nil
+	}
+
+	taprootOutputKey, err := event.Address.TaprootOutputKey()
+	if err != nil {
+		return fmt.Errorf("unable to derive address Taproot output "+
+			"key: %w", err)
+	}
+
+	ctx, cancel := m.WithCtxQuit()
+	defer cancel()
+
+	invoices, err := m.cfg.InvoiceStore.QueryInvoices(
+		ctx, address.InvoiceQuery{
+			AddrTaprootOutputKey: schnorr.SerializePubKey(
+				taprootOutputKey,
+			),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to query invoices: %w", err)
+	}
+
+	return m.updateInvoices(ctx, invoices)
+}
+
+// syncUnsettledInvoices updates the received amount of all unsettled
+// invoices.
+func (m *InvoiceManager) syncUnsettledInvoices() error {
+	ctx, cancel := m.WithCtxQuitNoTimeout()
+	defer cancel()
+
+	invoices, err := m.cfg.InvoiceStore.QueryInvoices(
+		ctx, address.InvoiceQuery{},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to query invoices: %w", err)
+	}
+
+	if err := m.updateInvoices(ctx, invoices); err != nil {
+		return err
+	}
+
+	return m.expireInvoices()
+}
+
+// updateInvoices recalculates the received amount of all unsettled invoices
+// in the given list and stores and publishes any changes.
+func (m *InvoiceManager) updateInvoices(ctx context.Context,
+	invoices []*address.Invoice) error {
+
+	m.invoiceMtx.Lock()
+	defer m.invoiceMtx.Unlock()
+
+	for _, invoice := range invoices {
+		if !isUnsettled(invoice) {
+			continue
+		}
+
+		received, err := m.amountReceived(ctx, invoice)
+		if err != nil {
+			return err
+		}
+
+		if !invoice.UpdateAmountReceived(received, m.cfg.Clock.Now()) {
+			continue
+		}
+
+		if err := m.storeUpdate(ctx, invoice); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// amountReceived returns the total asset amount of all completed receives to
+// the invoice's address since the invoice was created.
+func (m *InvoiceManager) amountReceived(ctx context.Context,
+	invoice *address.Invoice) (uint64, error) {
+
+	completed := address.StatusCompleted
+	events, err := m.cfg.AddrEvents.QueryEvents(
+		ctx, address.EventQueryParams{
+			AddrTaprootOutputKey: schnorr.SerializePubKey(
+				&invoice.Addr.TaprootOutputKey,
+			),
+			StatusFrom:       &completed,
+			StatusTo:         &completed,
+			CreationTimeFrom: &invoice.CreationTime,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("unable to query address events: %w", err)
+	}
+
+	var received uint64
+	for _, event := range events {
+		for _, output := range event.Outputs {
+			received += output.Amount
+		}
+	}
+
+	return received, nil
+}
+
+// expireInvoices marks all open invoices that passed their expiry time as
+// expired.
+func (m *InvoiceManager) expireInvoices() error {
+	ctx, cancel := m.WithCtxQuit()
+	defer cancel()
+
+	m.invoiceMtx.Lock()
+	defer m.invoiceMtx.Unlock()
+
+	invoices, err := m.cfg.InvoiceStore.QueryInvoices(
+		ctx, address.InvoiceQuery{
+			Status: fn.Some(address.InvoiceStatusOpen),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to query open invoices: %w", err)
+	}
+
+	now := m.cfg.Clock.Now()
+	for _, invoice := range invoices {
+		if !invoice.UpdateExpiry(now) {
+			continue
+		}
+
+		if err := m.storeUpdate(ctx, invoice); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeUpdate persists the changes of an invoice and notifies subscribers
+// about them.
+func (m *InvoiceManager) storeUpdate(ctx context.Context,
+	invoice *address.Invoice) error {
+
+	if err := m.cfg.InvoiceStore.UpdateInvoice(ctx, invoice); err != nil {
+		return fmt.Errorf("unable to update invoice %d: %w", invoice.ID,
+			err)
+	}
+
+	log.Infof("Invoice %d is now %v, received %d of %d units", invoice.ID,
+		invoice.Status, invoice.AmountReceived, invoice.Amount)
+
+	m.publishInvoiceEvent(invoice)
+
+	return nil
+}
+
+// publishInvoiceEvent publishes an event for the given invoice to all
+// subscribers.
+func (m *InvoiceManager) publishInvoiceEvent(invoice *address.Invoice) {
+	event := NewInvoiceEvent(invoice)
+
+	m.eventSubsMtx.Lock()
+	defer m.eventSubsMtx.Unlock()
+
+	for _, sub := range m.eventSubs {
+		if !fn.SendOrQuit(sub.NewItemCreated.ChanIn(), fn.Event(event),
+			m.Quit) {
+
+			log.Errorf("Unable publish invoice event, invoice " +
+				"manager shutting down")
+		}
+	}
+}
+
+// RegisterSubscriber adds a new subscriber for receiving invoice events. If
+// deliverExisting is true, an event for each invoice created at or after
+// deliverFrom is delivered first.
+func (m *InvoiceManager) RegisterSubscriber(
+	receiver *fn.EventReceiver[fn.Event], deliverExisting bool,
+	deliverFrom time.Time) error {
+
+	m.eventSubsMtx.Lock()
+	defer m.eventSubsMtx.Unlock()
+
+	m.eventSubs[receiver.ID()] = receiver
+
+	if !deliverExisting {
+		return nil
+	}
+
+	invoices, err := m.cfg.InvoiceStore.QueryInvoices(
+		context.Background(), address.InvoiceQuery{},
+	)
+	if err != nil {
+		return fmt.Errorf("error querying invoices: %w", err)
+	}
+
+	for _, invoice := range invoices {
+		if invoice.CreationTime.Before(deliverFrom) {
+			continue
+		}
+
+		receiver.NewItemCreated.ChanIn() <- NewInvoiceEvent(invoice)
+	}
+
+	return nil
+}
+
+// RemoveSubscriber removes a subscriber from the set of invoice event
+// subscribers.
+func (m *InvoiceManager) RemoveSubscriber(
+	subscriber *fn.EventReceiver[fn.Event]) error {
+
+	m.eventSubsMtx.Lock()
+	defer m.eventSubsMtx.Unlock()
+
+	_, ok := m.eventSubs[subscriber.ID()]
+	if !ok {
+		return fmt.Errorf("invoice event subscriber with ID %d not "+
+			"found", subscriber.ID())
+	}
+
+	subscriber.Stop()
+	delete(m.eventSubs, subscriber.ID())
+
+	return nil
+}
+
+// isUnsettled returns true if the invoice can still receive payments that
+// change its status. Paid and expired invoices are final, so they neither
+// block new invoices for their address nor are they re-checked for payments.
+// Underpaid invoices stay unsettled even after their expiry time, as the
+// merchant already received part of the payment.
+func isUnsettled(invoice *address.Invoice) bool {
+	switch invoice.Status {
+	case address.InvoiceStatusOpen, address.InvoiceStatusUnderpaid:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// A compile-time assertion to make sure InvoiceManager satisfies the
+// fn.EventPublisher interface.
+var _ fn.EventPublisher[fn.Event, time.Time] = (*InvoiceManager)(nil)
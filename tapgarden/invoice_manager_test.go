@@ -0,0 +1,318 @@
+package tapgarden
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockInvoiceStore is an in-memory implementation of the InvoiceStore
+// interface.
+type mockInvoiceStore struct {
+	sync.Mutex
+
+	invoices []*address.Invoice
+}
+
+// InsertInvoice inserts a new invoice and sets its ID.
+func (s *mockInvoiceStore) InsertInvoice(_ context.Context,
+	invoice *address.Invoice) error {
+
+	s.Lock()
+	defer s.Unlock()
+
+	invoice.ID = int64(len(s.invoices) + 1)
+	s.invoices = append(s.invoices, invoice.Copy())
+
+	return nil
+}
+
+// UpdateInvoice updates an existing invoice.
+func (s *mockInvoiceStore) UpdateInvoice(_ context.Context,
+	invoice *address.Invoice) error {
+
+	s.Lock()
+	defer s.Unlock()
+
+	if invoice.ID < 1 || invoice.ID > int64(len(s.invoices)) {
+		return address.ErrInvoiceNotFound
+	}
+	s.invoices[invoice.ID-1] = invoice.Copy()
+
+	return nil
+}
+
+// QueryInvoices returns all invoices that match the given query.
+func (s *mockInvoiceStore) QueryInvoices(_ context.Context,
+	query address.InvoiceQuery) ([]*address.Invoice, error) {
+
+	s.Lock()
+	defer s.Unlock()
+
+	var result []*address.Invoice
+	for _, invoice := range s.invoices {
+		if query.ID.IsSome() && query.ID.UnwrapOr(0) != invoice.ID {
+			continue
+		}
+		if query.Status.IsSome() &&
+			query.Status.UnwrapOr(0) != invoice.Status {
+
+			continue
+		}
+
+		addrKey := schnorr.SerializePubKey(
+			&invoice.Addr.TaprootOutputKey,
+		)
+		if len(query.AddrTaprootOutputKey) > 0 &&
+			!bytes.Equal(query.AddrTaprootOutputKey, addrKey) {
+
+			continue
+		}
+
+		result = append(result, invoice.Copy())
+	}
+
+	return result, nil
+}
+
+// mockAddrEvents returns a fixed set of address events.
+type mockAddrEvents struct {
+	sync.Mutex
+
+	events []*address.Event
+}
+
+// QueryEvents returns all completed events created at or after the query's
+// creation time.
+func (m *mockAddrEvents) QueryEvents(_ context.Context,
+	query address.EventQueryParams) ([]*address.Event, error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	var result []*address.Event
+	for _, event := range m.events {
+		if event.CreationTime.Before(*query.CreationTimeFrom) {
+			continue
+		}
+		if event.Status != *query.StatusFrom {
+			continue
+		}
+		result = append(result, event)
+	}
+
+	return result, nil
+}
+
+// addEvent adds a new address event for the given amount.
+func (m *mockAddrEvents) addEvent(addr *address.AddrWithKeyInfo,
+	amount uint64, status address.Status, creationTime time.Time) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.events = append(m.events, &address.Event{
+		CreationTime: creationTime,
+		Addr:         addr,
+		Status:       status,
+		Outputs: map[asset.ID]address.AssetOutput{
+			addr.AssetID: {
+				Amount: amount,
+			},
+		},
+	})
+}
+
+// mockReceiveEvents is a simple receive event source with a single
+// subscriber.
+type mockReceiveEvents struct {
+	sub *fn.EventReceiver[fn.Event]
+}
+
+// RegisterSubscriber registers the single subscriber.
+func (m *mockReceiveEvents) RegisterSubscriber(
+	receiver *fn.EventReceiver[fn.Event], _ bool, _ time.Time) error {
+
+	m.sub = receiver
+	return nil
+}
+
+// RemoveSubscriber removes the single subscriber.
+func (m *mockReceiveEvents) RemoveSubscriber(
+	subscriber *fn.EventReceiver[fn.Event]) error {
+
+	if m.sub == nil || m.sub.ID() != subscriber.ID() {
+		return fmt.Errorf("unknown subscriber")
+	}
+
+	subscriber.Stop()
+	m.sub = nil
+
+	return nil
+}
+
+// TestInvoiceManager tests that the invoice manager detects payments and
+// expiry of invoices and notifies subscribers about them.
+func TestInvoiceManager(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	startTime := time.Now()
+	testClock := clock.NewTestClock(startTime)
+	store := &mockInvoiceStore{}
+	addrEvents := &mockAddrEvents{}
+	receiveEvents := &mockReceiveEvents{}
+	errChan := make(chan error, 1)
+
+	manager := NewInvoiceManager(&InvoiceManagerConfig{
+		InvoiceStore:        store,
+		AddrEvents:          addrEvents,
+		ReceiveEvents:       receiveEvents,
+		Clock:               testClock,
+		ExpiryCheckInterval: 10 * time.Millisecond,
+		ErrChan:             errChan,
+	})
+	require.NoError(t, manager.Start())
+	t.Cleanup(func() {
+		require.NoError(t, manager.Stop())
+	})
+
+	invoiceEvents := fn.NewEventReceiver[fn.Event](fn.DefaultQueueSize)
+	require.NoError(t, manager.RegisterSubscriber(
+		invoiceEvents, false, time.Time{},
+	))
+
+	// newAddr creates a new random address that allows for partial
+	// payments.
+	newAddr := func() *address.AddrWithKeyInfo {
+		for {
+			addr, _, _ := address.RandAddrWithVersion(
+				t, &address.RegressionNetTap,
+				address.RandProofCourierAddr(t), address.V0,
+			)
+			if addr.Amount >= 2 {
+				return addr
+			}
+		}
+	}
+
+	// receive simulates a completed receive of the given amount to the
+	// address.
+	receive := func(addr *address.AddrWithKeyInfo, amount uint64) {
+		addrEvents.addEvent(
+			addr, amount, address.StatusCompleted, testClock.Now(),
+		)
+		event := NewAssetReceiveEvent(
+			*addr.Tap, wire.OutPoint{}, 123,
+			address.StatusCompleted,
+		)
+		receiveEvents.sub.NewItemCreated.ChanIn() <- event
+	}
+
+	nextEvent := func() *address.Invoice {
+		t.Helper()
+
+		select {
+		case event := <-invoiceEvents.NewItemCreated.ChanOut():
+			invoiceEvent, ok := event.(*InvoiceEvent)
+			require.True(t, ok)
+
+			return invoiceEvent.Invoice
+
+		case err := <-errChan:
+			t.Fatalf("unexpected error: %v", err)
+
+		case <-time.After(DefaultTimeout):
+			t.Fatalf("no invoice event received")
+		}
+
+		return nil
+	}
+
+	// Assets received before the invoice was created don't count towards
+	// it.
+	addr := newAddr()
+	addrEvents.addEvent(
+		addr, addr.Amount, address.StatusCompleted,
+		startTime.Add(-time.Minute),
+	)
+
+	// The invoice amount must match the address amount.
+	_, err := manager.NewInvoice(ctx, addr, addr.Amount-1, "", time.Hour)
+	require.Error(t, err)
+
+	invoice, err := manager.NewInvoice(ctx, addr, 0, "memo", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, addr.Amount, invoice.Amount)
+	require.Equal(t, address.InvoiceStatusOpen, nextEvent().Status)
+
+	// Only one unpaid invoice can exist per address.
+	_, err = manager.NewInvoice(ctx, addr, 0, "", time.Hour)
+	require.ErrorIs(t, err, ErrInvoiceAddrInUse)
+
+	// A partial payment makes the invoice underpaid, a second one pays it.
+	firstPart := addr.Amount / 2
+	receive(addr, firstPart)
+	update := nextEvent()
+	require.Equal(t, invoice.ID, update.ID)
+	require.Equal(t, address.InvoiceStatusUnderpaid, update.Status)
+	require.Equal(t, firstPart, update.AmountReceived)
+
+	receive(addr, addr.Amount-firstPart)
+	update = nextEvent()
+	require.Equal(t, address.InvoiceStatusPaid, update.Status)
+	require.Equal(t, addr.Amount, update.AmountReceived)
+	require.False(t, update.SettleTime.IsZero())
+
+	// Once the invoice is paid, a new one can be created for the address.
+	// Only assets received after its creation count towards it.
+	testClock.SetTime(startTime.Add(time.Minute))
+	invoice, err = manager.NewInvoice(ctx, addr, 0, "", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, address.InvoiceStatusOpen, nextEvent().Status)
+
+	otherAddr := newAddr()
+	otherInvoice, err := manager.NewInvoice(
+		ctx, otherAddr, 0, "", time.Hour,
+	)
+	require.NoError(t, err)
+	require.Equal(t, address.InvoiceStatusOpen, nextEvent().Status)
+
+	// Both invoices expire without a payment.
+	testClock.SetTime(startTime.Add(2 * time.Hour))
+	for _, id := range []int64{invoice.ID, otherInvoice.ID} {
+		update = nextEvent()
+		require.Equal(t, id, update.ID)
+		require.Equal(t, address.InvoiceStatusExpired, update.Status)
+	}
+
+	// Expired invoices are final, so they don't block a new invoice for
+	// their address. A payment after its creation only counts towards the
+	// new invoice.
+	newInvoice, err := manager.NewInvoice(ctx, otherAddr, 0, "", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, address.InvoiceStatusOpen, nextEvent().Status)
+
+	receive(otherAddr, otherAddr.Amount)
+	update = nextEvent()
+	require.Equal(t, newInvoice.ID, update.ID)
+	require.Equal(t, address.InvoiceStatusPaid, update.Status)
+
+	expired, err := manager.QueryInvoices(ctx, address.InvoiceQuery{
+		ID: fn.Some(otherInvoice.ID),
+	})
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	require.Equal(t, address.InvoiceStatusExpired, expired[0].Status)
+}
@@ -938,6 +938,31 @@ func (m *MockKeyRing) DeriveNextKey(ctx context.Context,
 	return desc, nil
 }
 
+// DeriveKey returns the previously derived key at the given key locator.
+func (m *MockKeyRing) DeriveKey(ctx context.Context,
+	keyLoc keychain.KeyLocator) (keychain.KeyDescriptor, error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return keychain.KeyDescriptor{}, fmt.Errorf("shutting down")
+	default:
+	}
+
+	priv, ok := m.Keys[keyLoc]
+	if !ok {
+		return keychain.KeyDescriptor{}, fmt.Errorf("key not found at "+
+			"family %d, index %d", keyLoc.Family, keyLoc.Index)
+	}
+
+	return keychain.KeyDescriptor{
+		PubKey:     priv.PubKey(),
+		KeyLocator: keyLoc,
+	}, nil
+}
+
 func (m *MockKeyRing) IsLocalKey(ctx context.Context,
 	d keychain.KeyDescriptor) bool {
 
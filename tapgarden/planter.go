@@ -3169,6 +3169,7 @@ func (c *ChainPlanter) verifierCtx(ctx context.Context) proof.VerifierCtx {
 		GroupVerifier:  groupVerifier,
 		ChainLookupGen: c.cfg.ChainBridge,
 		IgnoreChecker:  c.cfg.IgnoreChecker,
+		CacheID:        lfn.Some(proof.DefaultVerifierCacheID),
 	}
 }
 
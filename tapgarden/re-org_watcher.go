@@ -593,6 +593,7 @@ func (w *ReOrgWatcher) DefaultUpdateCallback() proof.UpdateCallback {
 			GroupVerifier:  w.cfg.GroupVerifier,
 			ChainLookupGen: w.cfg.ChainBridge,
 			IgnoreChecker:  w.cfg.IgnoreChecker,
+			CacheID:        lfn.Some(proof.DefaultVerifierCacheID),
 		}
 		for idx := range proofs {
 			err := proof.ReplaceProofInBlob(
@@ -94,11 +94,19 @@ func NewFromPsbt(packet *psbt.Packet) (*VPacket, error) {
 			version)
 	}
 
+	// The swap metadata is optional and only present if the packet is
+	// part of a swap.
+	swapMetadata, err := ExtractSwapMetadata(packet.Unknowns)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding swap metadata: %w", err)
+	}
+
 	vPkt := &VPacket{
-		Version:     VPacketVersion(version),
-		ChainParams: chainParams,
-		Inputs:      make([]*VInput, len(packet.Inputs)),
-		Outputs:     make([]*VOutput, len(packet.Outputs)),
+		Version:      VPacketVersion(version),
+		ChainParams:  chainParams,
+		Inputs:       make([]*VInput, len(packet.Inputs)),
+		Outputs:      make([]*VOutput, len(packet.Outputs)),
+		SwapMetadata: swapMetadata,
 	}
 
 	for idx := range packet.Inputs {
@@ -72,6 +72,10 @@ func (p *VPacket) EncodeAsPsbt() (*psbt.Packet, error) {
 		},
 	}
 
+	p.SwapMetadata.WhenSome(func(meta SwapMetadata) {
+		packet.Unknowns = SetSwapMetadata(packet.Unknowns, meta)
+	})
+
 	for idx := range p.Inputs {
 		pIn, err := p.Inputs[idx].encode()
 		if err != nil {
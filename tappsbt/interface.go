@@ -86,6 +86,11 @@ var (
 	// has some AltLeaves set, but a call was made to set the AltLeaves
 	// again.
 	ErrAltLeavesAlreadySet = errors.New("tappsbt: alt leaves already set")
+
+	// ErrKeyPathSpendable is returned when an anchor output that must only
+	// be spendable through its script paths has a spendable internal key.
+	ErrKeyPathSpendable = errors.New("tappsbt: anchor output key path " +
+		"is spendable")
 )
 
 // VPacketVersion is the version of the virtual transaction. This can signal
@@ -181,15 +186,21 @@ type VPacket struct {
 
 	// Version is the version of the virtual transaction.
 	Version VPacketVersion
+
+	// SwapMetadata is the optional metadata of the swap this virtual
+	// transaction is part of. It is encoded as global proprietary fields
+	// and is not committed to by the virtual transaction.
+	SwapMetadata fn.Option[SwapMetadata]
 }
 
 // Copy creates a deep copy of the VPacket.
 func (p *VPacket) Copy() *VPacket {
 	return &VPacket{
-		Inputs:      fn.CopyAll(p.Inputs),
-		Outputs:     fn.CopyAll(p.Outputs),
-		ChainParams: p.ChainParams,
-		Version:     p.Version,
+		Inputs:       fn.CopyAll(p.Inputs),
+		Outputs:      fn.CopyAll(p.Outputs),
+		ChainParams:  p.ChainParams,
+		Version:      p.Version,
+		SwapMetadata: p.SwapMetadata,
 	}
 }
 
@@ -664,6 +675,61 @@ func (o *VOutput) SetAnchorInternalKey(keyDesc keychain.KeyDescriptor,
 	)
 }
 
+// SetUnspendableAnchorInternalKey sets the internal key of the anchor output
+// to the provably unspendable NUMS point, which disables the key path spend of
+// the anchor output. All spend paths must therefore be carried by the given
+// tapscript sibling, which is required.
+func (o *VOutput) SetUnspendableAnchorInternalKey(
+	sibling *commitment.TapscriptPreimage) error {
+
+	if sibling == nil || sibling.IsEmpty() {
+		return fmt.Errorf("tapscript sibling is required for " +
+			"script path only anchor output")
+	}
+
+	o.AnchorOutputInternalKey = asset.NUMSPubKey
+	o.AnchorOutputTapscriptSibling = sibling
+
+	// No wallet can derive the private key of the NUMS point, so any
+	// derivation information would be wrong.
+	o.AnchorOutputBip32Derivation = nil
+	o.AnchorOutputTaprootBip32Derivation = nil
+
+	return nil
+}
+
+// ValidateScriptPathOnly makes sure the anchor output can only be spent
+// through its script paths. This is the case if the internal key is the
+// provably unspendable NUMS point and a tapscript sibling is present. This
+// should be used by trust-minimized contracts to make sure the key path of
+// an anchor output wasn't accidentally made spendable.
+func (o *VOutput) ValidateScriptPathOnly() error {
+	switch {
+	case o.AnchorOutputInternalKey == nil:
+		return fmt.Errorf("%w: internal key is missing",
+			ErrKeyPathSpendable)
+
+	case !o.AnchorOutputInternalKey.IsEqual(asset.NUMSPubKey):
+		return fmt.Errorf("%w: internal key %x is not the NUMS key",
+			ErrKeyPathSpendable,
+			o.AnchorOutputInternalKey.SerializeCompressed())
+
+	case len(o.AnchorOutputBip32Derivation) > 0 ||
+		len(o.AnchorOutputTaprootBip32Derivation) > 0:
+
+		return fmt.Errorf("%w: internal key has derivation info",
+			ErrKeyPathSpendable)
+
+	case o.AnchorOutputTapscriptSibling == nil ||
+		o.AnchorOutputTapscriptSibling.IsEmpty():
+
+		return fmt.Errorf("tapscript sibling is missing, anchor " +
+			"output has no spend paths")
+	}
+
+	return nil
+}
+
 // SetAltLeaves asserts that a set of AltLeaves are valid, and updates a VOutput
 // to set the AltLeaves. Setting the output's AltLeaves twice is disallowed.
 func (o *VOutput) SetAltLeaves(altLeafAssets []*asset.Asset) error {
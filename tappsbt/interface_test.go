@@ -3,8 +3,11 @@ package tappsbt
 import (
 	"testing"
 
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/commitment"
 	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/stretchr/testify/require"
 )
 
@@ -81,3 +84,44 @@ func TestAssetSpecifier(t *testing.T) {
 		})
 	}
 }
+
+// TestScriptPathOnlyAnchorOutput tests that an anchor output with an
+// unspendable internal key is detected as script path only, and that any
+// accidentally spendable key path is rejected.
+func TestScriptPathOnlyAnchorOutput(t *testing.T) {
+	t.Parallel()
+
+	leaf := txscript.NewBaseTapLeaf([]byte{txscript.OP_TRUE})
+	sibling, err := commitment.NewPreimageFromLeaf(leaf)
+	require.NoError(t, err)
+
+	// A sibling is required, otherwise the output couldn't be spent at
+	// all.
+	vOut := &VOutput{}
+	require.Error(t, vOut.SetUnspendableAnchorInternalKey(nil))
+
+	keyDesc := keychain.KeyDescriptor{
+		PubKey: test.RandPubKey(t),
+	}
+	vOut.SetAnchorInternalKey(keyDesc, 0)
+	require.ErrorIs(t, vOut.ValidateScriptPathOnly(), ErrKeyPathSpendable)
+
+	require.NoError(t, vOut.SetUnspendableAnchorInternalKey(sibling))
+	require.NoError(t, vOut.ValidateScriptPathOnly())
+	require.Empty(t, vOut.AnchorOutputBip32Derivation)
+	require.Empty(t, vOut.AnchorOutputTaprootBip32Derivation)
+
+	// Adding derivation info for the NUMS key is rejected.
+	withDerivation := vOut.Copy()
+	withDerivation.SetAnchorInternalKey(keychain.KeyDescriptor{
+		PubKey: asset.NUMSPubKey,
+	}, 0)
+	require.ErrorIs(
+		t, withDerivation.ValidateScriptPathOnly(), ErrKeyPathSpendable,
+	)
+
+	// Removing the sibling leaves the output without spend paths.
+	noSibling := vOut.Copy()
+	noSibling.AnchorOutputTapscriptSibling = nil
+	require.Error(t, noSibling.ValidateScriptPathOnly())
+}
@@ -0,0 +1,251 @@
+package tappsbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightningnetwork/lnd/lntypes"
+)
+
+const (
+	// PsbtKeyTypeProprietary is the BIP-0174 key type of proprietary
+	// fields. Proprietary fields are never interpreted or removed by
+	// parsers that don't know the identifier they use.
+	PsbtKeyTypeProprietary = 0xfc
+
+	// PsbtProprietarySubTypeSwapID is the proprietary sub type of the swap
+	// ID field.
+	PsbtProprietarySubTypeSwapID uint64 = 0x00
+
+	// PsbtProprietarySubTypeSwapPaymentHash is the proprietary sub type of
+	// the swap payment hash field.
+	PsbtProprietarySubTypeSwapPaymentHash uint64 = 0x01
+
+	// PsbtProprietarySubTypeSwapExpiry is the proprietary sub type of the
+	// swap expiry field.
+	PsbtProprietarySubTypeSwapExpiry uint64 = 0x02
+)
+
+var (
+	// PsbtProprietaryIdentifier is the identifier used for all proprietary
+	// fields defined by Taproot Assets.
+	PsbtProprietaryIdentifier = []byte("tap")
+
+	// ErrInvalidProprietaryKey is returned when a PSBT key is not a valid
+	// BIP-0174 proprietary key.
+	ErrInvalidProprietaryKey = errors.New("tappsbt: invalid proprietary " +
+		"key")
+)
+
+// ProprietaryKey returns the full PSBT key of a Taproot Assets proprietary
+// field with the given sub type and optional key data. The key is encoded as
+// specified in BIP-0174:
+//
+//	0xfc || <compact size len(identifier)> || identifier ||
+//	<compact size sub type> || key data
+func ProprietaryKey(subType uint64, keyData []byte) []byte {
+	var b bytes.Buffer
+	b.WriteByte(PsbtKeyTypeProprietary)
+
+	// Writing to a bytes.Buffer can't fail.
+	_ = wire.WriteVarInt(&b, 0, uint64(len(PsbtProprietaryIdentifier)))
+	b.Write(PsbtProprietaryIdentifier)
+	_ = wire.WriteVarInt(&b, 0, subType)
+	b.Write(keyData)
+
+	return b.Bytes()
+}
+
+// ParseProprietaryKey parses the given PSBT key as a proprietary key and
+// returns its identifier, sub type and key data.
+func ParseProprietaryKey(key []byte) ([]byte, uint64, []byte, error) {
+	if len(key) == 0 || key[0] != PsbtKeyTypeProprietary {
+		return nil, 0, nil, ErrInvalidProprietaryKey
+	}
+
+	r := bytes.NewReader(key[1:])
+	idLen, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%w: %w",
+			ErrInvalidProprietaryKey, err)
+	}
+	if idLen > uint64(r.Len()) {
+		return nil, 0, nil, fmt.Errorf("%w: identifier too long",
+			ErrInvalidProprietaryKey)
+	}
+
+	identifier := make([]byte, idLen)
+	if _, err := io.ReadFull(r, identifier); err != nil {
+		return nil, 0, nil, fmt.Errorf("%w: %w",
+			ErrInvalidProprietaryKey, err)
+	}
+
+	subType, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%w: %w",
+			ErrInvalidProprietaryKey, err)
+	}
+
+	keyData := make([]byte, r.Len())
+	_, _ = r.Read(keyData)
+
+	return identifier, subType, keyData, nil
+}
+
+// findProprietaryField returns the Taproot Assets proprietary field with the
+// given sub type from the list of unknowns, or nil if it is not present.
+func findProprietaryField(unknowns []*customPsbtField,
+	subType uint64) *customPsbtField {
+
+	for _, unknown := range unknowns {
+		id, fieldType, _, err := ParseProprietaryKey(unknown.Key)
+		if err != nil {
+			continue
+		}
+
+		if bytes.Equal(id, PsbtProprietaryIdentifier) &&
+			fieldType == subType {
+
+			return unknown
+		}
+	}
+
+	return nil
+}
+
+// isSwapMetadataField returns true if the given unknown is one of the swap
+// metadata proprietary fields.
+func isSwapMetadataField(unknown *customPsbtField) bool {
+	id, subType, _, err := ParseProprietaryKey(unknown.Key)
+	if err != nil || !bytes.Equal(id, PsbtProprietaryIdentifier) {
+		return false
+	}
+
+	switch subType {
+	case PsbtProprietarySubTypeSwapID,
+		PsbtProprietarySubTypeSwapPaymentHash,
+		PsbtProprietarySubTypeSwapExpiry:
+
+		return true
+
+	default:
+		return false
+	}
+}
+
+// SwapMetadata is the metadata of a swap that both parties of the swap carry
+// in the anchor PSBT and the virtual PSBTs, so their tooling can track the
+// same identifiers across the protocol.
+type SwapMetadata struct {
+	// SwapID is the unique identifier of the swap.
+	SwapID [32]byte
+
+	// PaymentHash is the optional payment hash the swap is locked to.
+	PaymentHash fn.Option[lntypes.Hash]
+
+	// Expiry is the optional absolute block height at which the swap
+	// expires.
+	Expiry fn.Option[uint32]
+}
+
+// ProprietaryFields returns the swap metadata encoded as PSBT proprietary
+// fields.
+func (m SwapMetadata) ProprietaryFields() []*psbt.Unknown {
+	swapID := m.SwapID
+	fields := []*psbt.Unknown{{
+		Key:   ProprietaryKey(PsbtProprietarySubTypeSwapID, nil),
+		Value: swapID[:],
+	}}
+
+	m.PaymentHash.WhenSome(func(hash lntypes.Hash) {
+		fields = append(fields, &psbt.Unknown{
+			Key: ProprietaryKey(
+				PsbtProprietarySubTypeSwapPaymentHash, nil,
+			),
+			Value: hash[:],
+		})
+	})
+
+	m.Expiry.WhenSome(func(expiry uint32) {
+		var value [4]byte
+		binary.BigEndian.PutUint32(value[:], expiry)
+
+		fields = append(fields, &psbt.Unknown{
+			Key: ProprietaryKey(
+				PsbtProprietarySubTypeSwapExpiry, nil,
+			),
+			Value: value[:],
+		})
+	})
+
+	return fields
+}
+
+// SetSwapMetadata returns the given list of unknowns with any existing swap
+// metadata fields replaced by the given swap metadata. It can be used on the
+// global, input and output unknowns of any PSBT.
+func SetSwapMetadata(unknowns []*psbt.Unknown,
+	meta SwapMetadata) []*psbt.Unknown {
+
+	result := fn.Filter(unknowns, func(unknown *psbt.Unknown) bool {
+		return !isSwapMetadataField(unknown)
+	})
+
+	return append(result, meta.ProprietaryFields()...)
+}
+
+// ExtractSwapMetadata extracts the swap metadata from the given list of
+// unknowns of a PSBT. If no swap ID field is present, None is returned.
+func ExtractSwapMetadata(
+	unknowns []*psbt.Unknown) (fn.Option[SwapMetadata], error) {
+
+	var (
+		none = fn.None[SwapMetadata]()
+		meta SwapMetadata
+	)
+
+	swapID := findProprietaryField(
+		unknowns, PsbtProprietarySubTypeSwapID,
+	)
+	if swapID == nil {
+		return none, nil
+	}
+
+	if len(swapID.Value) != len(meta.SwapID) {
+		return none, fmt.Errorf("invalid swap ID length %d",
+			len(swapID.Value))
+	}
+	copy(meta.SwapID[:], swapID.Value)
+
+	hashField := findProprietaryField(
+		unknowns, PsbtProprietarySubTypeSwapPaymentHash,
+	)
+	if hashField != nil {
+		hash, err := lntypes.MakeHash(hashField.Value)
+		if err != nil {
+			return none, fmt.Errorf("invalid swap payment hash: %w",
+				err)
+		}
+		meta.PaymentHash = fn.Some(hash)
+	}
+
+	expiryField := findProprietaryField(
+		unknowns, PsbtProprietarySubTypeSwapExpiry,
+	)
+	if expiryField != nil {
+		value := expiryField.Value
+		if len(value) != 4 {
+			return none, fmt.Errorf("invalid swap expiry length %d",
+				len(value))
+		}
+		meta.Expiry = fn.Some(binary.BigEndian.Uint32(value))
+	}
+
+	return fn.Some(meta), nil
+}
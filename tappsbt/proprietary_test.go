@@ -0,0 +1,102 @@
+package tappsbt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProprietaryKey tests the encoding and parsing of proprietary keys.
+func TestProprietaryKey(t *testing.T) {
+	t.Parallel()
+
+	key := ProprietaryKey(PsbtProprietarySubTypeSwapExpiry, []byte{0xaa})
+	require.Equal(t, []byte{0xfc, 0x03, 't', 'a', 'p', 0x02, 0xaa}, key)
+
+	id, subType, keyData, err := ParseProprietaryKey(key)
+	require.NoError(t, err)
+	require.Equal(t, PsbtProprietaryIdentifier, id)
+	require.Equal(t, PsbtProprietarySubTypeSwapExpiry, subType)
+	require.Equal(t, []byte{0xaa}, keyData)
+
+	invalidKeys := [][]byte{
+		nil,
+		{0x70},
+		{0xfc},
+		{0xfc, 0x05, 't', 'a', 'p'},
+		{0xfc, 0x03, 't', 'a', 'p'},
+	}
+	for _, invalidKey := range invalidKeys {
+		_, _, _, err := ParseProprietaryKey(invalidKey)
+		require.ErrorIs(t, err, ErrInvalidProprietaryKey)
+	}
+}
+
+// TestSwapMetadata tests that swap metadata survives a round trip through the
+// anchor PSBT and virtual PSBT encodings.
+func TestSwapMetadata(t *testing.T) {
+	t.Parallel()
+
+	meta := SwapMetadata{
+		SwapID:      test.RandHash(),
+		PaymentHash: fn.Some(lntypes.Hash(test.RandHash())),
+		Expiry:      fn.Some(uint32(850_000)),
+	}
+
+	// The metadata can be attached to the inputs and outputs of a regular
+	// BTC level anchor PSBT.
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(&wire.TxOut{Value: 1000, PkScript: []byte{0x51}})
+	anchorPkt, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+
+	anchorPkt.Outputs[0].Unknowns = SetSwapMetadata(
+		anchorPkt.Outputs[0].Unknowns, meta,
+	)
+
+	// Setting it a second time replaces the previous fields.
+	anchorPkt.Outputs[0].Unknowns = SetSwapMetadata(
+		anchorPkt.Outputs[0].Unknowns, meta,
+	)
+	require.Len(t, anchorPkt.Outputs[0].Unknowns, 3)
+
+	var buf bytes.Buffer
+	require.NoError(t, anchorPkt.Serialize(&buf))
+	decodedPkt, err := psbt.NewFromRawBytes(&buf, false)
+	require.NoError(t, err)
+
+	decodedMeta, err := ExtractSwapMetadata(decodedPkt.Outputs[0].Unknowns)
+	require.NoError(t, err)
+	require.Equal(t, fn.Some(meta), decodedMeta)
+
+	noMeta, err := ExtractSwapMetadata(decodedPkt.Inputs[0].Unknowns)
+	require.NoError(t, err)
+	require.True(t, noMeta.IsNone())
+
+	// The metadata is also carried by virtual packets.
+	vPkt := RandPacket(t, test.RandBool(), test.RandBool())
+	vPkt.SwapMetadata = fn.Some(SwapMetadata{
+		SwapID: meta.SwapID,
+	})
+
+	buf.Reset()
+	require.NoError(t, vPkt.Serialize(&buf))
+	decodedVPkt, err := NewFromRawBytes(&buf, false)
+	require.NoError(t, err)
+	require.Equal(t, vPkt.SwapMetadata, decodedVPkt.SwapMetadata)
+	require.Equal(t, vPkt.SwapMetadata, decodedVPkt.Copy().SwapMetadata)
+
+	// Invalid values are rejected.
+	_, err = ExtractSwapMetadata([]*psbt.Unknown{{
+		Key:   ProprietaryKey(PsbtProprietarySubTypeSwapID, nil),
+		Value: []byte{0x01},
+	}})
+	require.ErrorContains(t, err, "invalid swap ID length")
+}
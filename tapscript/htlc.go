@@ -0,0 +1,117 @@
+package tapscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// SecondLevelHtlcTree is the asset-level script tree of the intermediate output
+// of a two-stage HTLC claim. Instead of sweeping an HTLC directly to a wallet
+// key, the claiming party first moves the assets to this output, which can
+// only be spent by them after a relative delay (script path) or immediately by
+// the holder of the revocation key (key path). This mirrors the second-level
+// HTLC outputs used in Lightning channels, but without requiring a channel.
+type SecondLevelHtlcTree struct {
+	*input.SecondLevelScriptTree
+
+	// CsvDelay is the relative delay (in blocks) that must pass after the
+	// intermediate output confirmed before it can be swept by the delay
+	// key.
+	CsvDelay uint32
+}
+
+// NewSecondLevelHtlcTree creates the asset-level script tree for the
+// intermediate output of a two-stage HTLC claim. The delay key can sweep the
+// output after csvDelay blocks, while the revocation key can spend it at any
+// time through the key spend path.
+func NewSecondLevelHtlcTree(revokeKey, delayKey *btcec.PublicKey,
+	csvDelay uint32) (*SecondLevelHtlcTree, error) {
+
+	if revokeKey == nil || delayKey == nil {
+		return nil, fmt.Errorf("revocation and delay key must be set")
+	}
+
+	if csvDelay == 0 {
+		return nil, fmt.Errorf("CSV delay must be greater than zero")
+	}
+
+	tree, err := input.TaprootSecondLevelScriptTree(
+		revokeKey, delayKey, csvDelay, input.NoneTapLeaf(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create second level script "+
+			"tree: %w", err)
+	}
+
+	return &SecondLevelHtlcTree{
+		SecondLevelScriptTree: tree,
+		CsvDelay:              csvDelay,
+	}, nil
+}
+
+// ScriptKey returns the asset-level script key of the intermediate output. The
+// raw key of the tweaked script key is the revocation key, which is the
+// internal key of the tree, and the tweak is the tapscript root. The delay
+// key's owner sweeps the output through the script path and therefore signs
+// with the delay key instead.
+func (t *SecondLevelHtlcTree) ScriptKey() asset.ScriptKey {
+	return asset.ScriptKey{
+		PubKey: asset.NewScriptKey(t.TaprootKey).PubKey,
+		TweakedScriptKey: &asset.TweakedScriptKey{
+			RawKey: keychain.KeyDescriptor{
+				PubKey: t.InternalKey,
+			},
+			Tweak: t.TapscriptRoot,
+		},
+	}
+}
+
+// SweepLeaf returns the tap leaf that must be signed by the delay key when
+// sweeping the intermediate output after the CSV delay.
+func (t *SecondLevelHtlcTree) SweepLeaf() txscript.TapLeaf {
+	return t.SuccessTapLeaf
+}
+
+// SweepWitness creates the asset-level witness that spends the intermediate
+// output through the delay script path, given a valid Schnorr signature of
+// the delay key over the sweeping virtual transaction.
+func (t *SecondLevelHtlcTree) SweepWitness(sig []byte) (wire.TxWitness,
+	error) {
+
+	ctrlBlock, err := t.CtrlBlockForPath(input.ScriptPathDelay)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create control block: %w",
+			err)
+	}
+
+	ctrlBlockBytes, err := ctrlBlock.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize control "+
+			"block: %w", err)
+	}
+
+	return wire.TxWitness{
+		sig, t.SuccessTapLeaf.Script, ctrlBlockBytes,
+	}, nil
+}
+
+// RevokeWitness creates the asset-level witness that spends the intermediate
+// output through the key spend path, given a valid Schnorr signature of the
+// (tweaked) revocation key.
+func (t *SecondLevelHtlcTree) RevokeWitness(sig []byte) wire.TxWitness {
+	return wire.TxWitness{sig}
+}
+
+// SweepHeight returns the first block height at which the intermediate output
+// that confirmed at the given height can be swept through the delay path. A
+// watcher of the intermediate stage should hand the output over to the sweeper
+// once this height is reached.
+func (t *SecondLevelHtlcTree) SweepHeight(confHeight uint32) uint32 {
+	return confHeight + t.CsvDelay
+}
@@ -0,0 +1,60 @@
+package tapscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSecondLevelHtlcTree tests that the script key and sweep witness of a
+// second level HTLC tree commit to the expected delay script.
+func TestSecondLevelHtlcTree(t *testing.T) {
+	t.Parallel()
+
+	revokeKey := test.RandPubKey(t)
+	delayKey := test.RandPubKey(t)
+
+	_, err := NewSecondLevelHtlcTree(revokeKey, delayKey, 0)
+	require.ErrorContains(t, err, "CSV delay must be greater than zero")
+
+	_, err = NewSecondLevelHtlcTree(nil, delayKey, 144)
+	require.ErrorContains(t, err, "must be set")
+
+	tree, err := NewSecondLevelHtlcTree(revokeKey, delayKey, 144)
+	require.NoError(t, err)
+	require.EqualValues(t, 144+100, tree.SweepHeight(100))
+
+	// The script key must be the x-only version of the taproot output key,
+	// with the revocation key as the internal key.
+	scriptKey := tree.ScriptKey()
+	require.Equal(
+		t, schnorr.SerializePubKey(tree.TaprootKey),
+		schnorr.SerializePubKey(scriptKey.PubKey),
+	)
+	require.True(
+		t, scriptKey.TweakedScriptKey.RawKey.PubKey.IsEqual(revokeKey),
+	)
+	require.Equal(t, tree.TapscriptRoot, scriptKey.TweakedScriptKey.Tweak)
+
+	// The sweep witness must reveal the delay script and a control block
+	// that proves its inclusion in the script key.
+	sig := make([]byte, schnorr.SignatureSize)
+	witness, err := tree.SweepWitness(sig)
+	require.NoError(t, err)
+	require.Len(t, witness, 3)
+	require.Equal(t, tree.SweepLeaf().Script, witness[1])
+
+	ctrlBlock, err := txscript.ParseControlBlock(witness[2])
+	require.NoError(t, err)
+
+	err = txscript.VerifyTaprootLeafCommitment(
+		ctrlBlock, schnorr.SerializePubKey(scriptKey.PubKey),
+		witness[1],
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{sig}, [][]byte(tree.RevokeWitness(sig)))
+}
@@ -0,0 +1,196 @@
+package tapscript
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// MaxMultiSigKeys is the maximum number of keys of a k-of-n multisig
+	// script key. Every key adds a signature (or empty element) to the
+	// witness stack, which is limited to 1000 elements by the script
+	// engine.
+	MaxMultiSigKeys = 100
+)
+
+// MultiSigTree is the asset-level script tree of a k-of-n multisig script key.
+// The tree consists of a single OP_CHECKSIGADD based tapscript leaf and uses a
+// NUMS key as its internal key, so the assets can only be spent by providing
+// at least k signatures of the n keys.
+type MultiSigTree struct {
+	// Threshold is the number of signatures required to spend the assets.
+	Threshold uint32
+
+	// Keys is the list of keys of all participants, sorted by their
+	// x-only serialization.
+	Keys []*btcec.PublicKey
+
+	// InternalKey is the NUMS internal key of the script key.
+	InternalKey *btcec.PublicKey
+
+	// Leaf is the multisig tapscript leaf.
+	Leaf txscript.TapLeaf
+
+	// TapscriptRoot is the root of the tapscript tree, which is the hash
+	// of the multisig leaf.
+	TapscriptRoot []byte
+
+	// TaprootKey is the final, tweaked script key of the assets.
+	TaprootKey *btcec.PublicKey
+}
+
+// NewMultiSigTree creates the asset-level script tree of a k-of-n multisig
+// script key with the given threshold and participant keys. The order of the
+// keys doesn't matter, as they are sorted before the script is created. The
+// BIP-0341 NUMS key is used as the internal key, unless a different one is
+// specified with WithNUMSKey.
+func NewMultiSigTree(threshold uint32, keys []*btcec.PublicKey,
+	opts ...ScriptTreeOption) (*MultiSigTree, error) {
+
+	cfg := newScriptTreeConfig(opts...)
+
+	if len(keys) == 0 || len(keys) > MaxMultiSigKeys {
+		return nil, fmt.Errorf("number of multisig keys must be "+
+			"between 1 and %d, got %d", MaxMultiSigKeys, len(keys))
+	}
+	if threshold == 0 || threshold > uint32(len(keys)) {
+		return nil, fmt.Errorf("multisig threshold must be between 1 "+
+			"and %d, got %d", len(keys), threshold)
+	}
+
+	sortedKeys := make([]*btcec.PublicKey, len(keys))
+	for idx, key := range keys {
+		if key == nil {
+			return nil, fmt.Errorf("multisig key %d is nil", idx)
+		}
+		sortedKeys[idx] = key
+	}
+	sort.Slice(sortedKeys, func(i, j int) bool {
+		return bytes.Compare(
+			schnorr.SerializePubKey(sortedKeys[i]),
+			schnorr.SerializePubKey(sortedKeys[j]),
+		) < 0
+	})
+
+	builder := txscript.NewScriptBuilder()
+	for idx, key := range sortedKeys {
+		xOnlyKey := schnorr.SerializePubKey(key)
+		if idx > 0 && bytes.Equal(
+			xOnlyKey, schnorr.SerializePubKey(sortedKeys[idx-1]),
+		) {
+
+			return nil, fmt.Errorf("duplicate multisig key %x",
+				xOnlyKey)
+		}
+
+		builder.AddData(xOnlyKey)
+		if idx == 0 {
+			builder.AddOp(txscript.OP_CHECKSIG)
+		} else {
+			builder.AddOp(txscript.OP_CHECKSIGADD)
+		}
+	}
+	builder.AddInt64(int64(threshold))
+	builder.AddOp(txscript.OP_NUMEQUAL)
+
+	script, err := builder.Script()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create multisig script: %w",
+			err)
+	}
+
+	leaf := txscript.NewBaseTapLeaf(script)
+	rootHash := leaf.TapHash()
+	taprootKey := txscript.ComputeTaprootOutputKey(
+		cfg.NUMSKey, rootHash[:],
+	)
+
+	return &MultiSigTree{
+		Threshold:     threshold,
+		Keys:          sortedKeys,
+		InternalKey:   cfg.NUMSKey,
+		Leaf:          leaf,
+		TapscriptRoot: rootHash[:],
+		TaprootKey:    taprootKey,
+	}, nil
+}
+
+// ScriptKey returns the asset-level script key of the multisig tree. The
+// tweaked script key information is populated and the key is marked as an
+// externally defined script path key, so it can be declared to and tracked by
+// the wallets of all participants.
+func (t *MultiSigTree) ScriptKey() asset.ScriptKey {
+	return asset.ScriptKey{
+		PubKey: t.TaprootKey,
+		TweakedScriptKey: &asset.TweakedScriptKey{
+			RawKey: keychain.KeyDescriptor{
+				PubKey: t.InternalKey,
+			},
+			Tweak: t.TapscriptRoot,
+			Type:  asset.ScriptKeyScriptPathExternal,
+		},
+	}
+}
+
+// ControlBlock returns the control block for spending the multisig leaf.
+func (t *MultiSigTree) ControlBlock() *txscript.ControlBlock {
+	return &txscript.ControlBlock{
+		InternalKey:     t.InternalKey,
+		OutputKeyYIsOdd: t.TaprootKey.SerializeCompressed()[0] == 0x03,
+		LeafVersion:     t.Leaf.LeafVersion,
+	}
+}
+
+// Witness creates the asset-level witness that spends the multisig leaf from
+// the given signatures, which are keyed by the x-only serialization of the
+// participant key that created them. Exactly the threshold number of
+// signatures must be provided.
+func (t *MultiSigTree) Witness(sigs map[[32]byte][]byte) (wire.TxWitness,
+	error) {
+
+	if uint32(len(sigs)) != t.Threshold {
+		return nil, fmt.Errorf("expected %d multisig signatures, got "+
+			"%d", t.Threshold, len(sigs))
+	}
+
+	// The first key is checked by OP_CHECKSIG, so its signature needs to
+	// be on top of the stack, meaning it is the last element of the
+	// witness before the script and control block. Participants that
+	// don't sign are represented by an empty element.
+	witness := make(wire.TxWitness, 0, len(t.Keys)+2)
+	numSigs := 0
+	for idx := len(t.Keys) - 1; idx >= 0; idx-- {
+		var xOnlyKey [32]byte
+		copy(xOnlyKey[:], schnorr.SerializePubKey(t.Keys[idx]))
+
+		sig, ok := sigs[xOnlyKey]
+		if !ok {
+			witness = append(witness, nil)
+			continue
+		}
+
+		witness = append(witness, sig)
+		numSigs++
+	}
+
+	if numSigs != len(sigs) {
+		return nil, fmt.Errorf("signatures for unknown multisig keys " +
+			"provided")
+	}
+
+	ctrlBlockBytes, err := t.ControlBlock().ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize control "+
+			"block: %w", err)
+	}
+
+	return append(witness, t.Leaf.Script, ctrlBlockBytes), nil
+}
@@ -3,6 +3,7 @@ package tapscript
 import (
 	"fmt"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/lightninglabs/taproot-assets/asset"
@@ -15,6 +16,54 @@ func AnyoneCanSpendScript() []byte {
 	return []byte{txscript.OP_TRUE}
 }
 
+// ScriptTreeConfig holds the configuration for the script tree builders of
+// this package.
+type ScriptTreeConfig struct {
+	// NUMSKey is the provably unspendable internal key that is used to
+	// force a script path only spend.
+	NUMSKey *btcec.PublicKey
+}
+
+// DefaultScriptTreeConfig returns the default script tree configuration, which
+// uses the NUMS key defined in BIP-0341.
+func DefaultScriptTreeConfig() *ScriptTreeConfig {
+	return &ScriptTreeConfig{
+		NUMSKey: &input.TaprootNUMSKey,
+	}
+}
+
+// ScriptTreeOption is a functional option that can be used to configure the
+// script tree builders of this package.
+type ScriptTreeOption func(*ScriptTreeConfig)
+
+// WithNUMSKey is an option that can be used to use a custom NUMS key instead
+// of the default one as the internal key of a script path only output. This
+// allows protocols to use their own domain separated NUMS key (see
+// asset.DeriveNUMSKey). The same option must be used when creating the spend
+// witness for the resulting script tree.
+//
+// NOTE: This only affects the script trees built by this package. Tombstones
+// and split roots always use asset.NUMSPubKey, as that is how the protocol
+// recognizes them as un-spendable.
+func WithNUMSKey(numsKey *btcec.PublicKey) ScriptTreeOption {
+	return func(cfg *ScriptTreeConfig) {
+		if numsKey != nil {
+			cfg.NUMSKey = numsKey
+		}
+	}
+}
+
+// newScriptTreeConfig creates a script tree configuration with the given
+// options applied to the default values.
+func newScriptTreeConfig(opts ...ScriptTreeOption) *ScriptTreeConfig {
+	cfg := DefaultScriptTreeConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
 // FundingScriptTree is a struct that contains the funding script tree for a
 // custom channel.
 type FundingScriptTree struct {
@@ -26,8 +75,11 @@ type FundingScriptTree struct {
 // OP_TRUE script that allows anyone to spend the output. This simplifies the
 // funding process as no signatures for the asset-level witnesses need to be
 // exchanged. This is still safe because the BTC level multi-sig output is still
-// protected by a 2-of-2 MuSig2 output.
-func NewChannelFundingScriptTree() *FundingScriptTree {
+// protected by a 2-of-2 MuSig2 output. The BIP-0341 NUMS key is used as the
+// internal key, unless a different one is specified with WithNUMSKey.
+func NewChannelFundingScriptTree(opts ...ScriptTreeOption) *FundingScriptTree {
+	cfg := newScriptTreeConfig(opts...)
+
 	// First, we'll generate our OP_TRUE script.
 	fundingScript := AnyoneCanSpendScript()
 	fundingTapLeaf := txscript.NewBaseTapLeaf(fundingScript)
@@ -40,12 +92,12 @@ func NewChannelFundingScriptTree() *FundingScriptTree {
 	// Finally, we'll make the funding output script which actually uses a
 	// NUMS key to force a script path only.
 	fundingOutputKey := txscript.ComputeTaprootOutputKey(
-		&input.TaprootNUMSKey, tapScriptRoot[:],
+		cfg.NUMSKey, tapScriptRoot[:],
 	)
 
 	return &FundingScriptTree{
 		ScriptTree: input.ScriptTree{
-			InternalKey:   &input.TaprootNUMSKey,
+			InternalKey:   cfg.NUMSKey,
 			TaprootKey:    fundingOutputKey,
 			TapscriptTree: tapscriptTree,
 			TapscriptRoot: tapScriptRoot[:],
@@ -60,8 +112,10 @@ func NewChannelFundingScriptTree() *FundingScriptTree {
 // the funding process as no signatures for the asset-level witnesses need to be
 // exchanged. This is still safe because the BTC level multi-sig output is still
 // protected by a 2-of-2 MuSig2 output.
-func NewChannelFundingScriptTreeUniqueID(id asset.ID) (*FundingScriptTree,
-	error) {
+func NewChannelFundingScriptTreeUniqueID(id asset.ID,
+	opts ...ScriptTreeOption) (*FundingScriptTree, error) {
+
+	cfg := newScriptTreeConfig(opts...)
 
 	// First, we'll generate our OP_TRUE script.
 	fundingScript := AnyoneCanSpendScript()
@@ -86,12 +140,12 @@ func NewChannelFundingScriptTreeUniqueID(id asset.ID) (*FundingScriptTree,
 	// Finally, we'll make the funding output script which actually uses a
 	// NUMS key to force a script path only.
 	fundingOutputKey := txscript.ComputeTaprootOutputKey(
-		&input.TaprootNUMSKey, tapScriptRoot[:],
+		cfg.NUMSKey, tapScriptRoot[:],
 	)
 
 	return &FundingScriptTree{
 		ScriptTree: input.ScriptTree{
-			InternalKey:   &input.TaprootNUMSKey,
+			InternalKey:   cfg.NUMSKey,
 			TaprootKey:    fundingOutputKey,
 			TapscriptTree: tapscriptTree,
 			TapscriptRoot: tapScriptRoot[:],
@@ -101,10 +155,11 @@ func NewChannelFundingScriptTreeUniqueID(id asset.ID) (*FundingScriptTree,
 
 // ChannelFundingSpendWitness creates a complete witness to spend the OP_TRUE
 // funding script of an asset funding output.
-func ChannelFundingSpendWitness(uniqueScriptKeys bool,
-	assetID asset.ID) (wire.TxWitness, error) {
+func ChannelFundingSpendWitness(uniqueScriptKeys bool, assetID asset.ID,
+	opts ...ScriptTreeOption) (wire.TxWitness, error) {
 
-	fundingScriptTree := NewChannelFundingScriptTree()
+	cfg := newScriptTreeConfig(opts...)
+	fundingScriptTree := NewChannelFundingScriptTree(opts...)
 
 	// If we're using unique script keys for multiple virtual packets with
 	// different asset IDs, we need to derive a specific script tree that
@@ -113,7 +168,7 @@ func ChannelFundingSpendWitness(uniqueScriptKeys bool,
 	if uniqueScriptKeys {
 		var err error
 		fundingScriptTree, err = NewChannelFundingScriptTreeUniqueID(
-			assetID,
+			assetID, opts...,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create unique "+
@@ -124,7 +179,7 @@ func ChannelFundingSpendWitness(uniqueScriptKeys bool,
 	const opTrueIndex = 0
 	tapscriptTree := fundingScriptTree.TapscriptTree
 	ctrlBlock := tapscriptTree.LeafMerkleProofs[opTrueIndex].ToControlBlock(
-		&input.TaprootNUMSKey,
+		cfg.NUMSKey,
 	)
 	ctrlBlockBytes, err := ctrlBlock.ToBytes()
 	if err != nil {
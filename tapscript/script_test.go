@@ -4,6 +4,9 @@ import (
 	"encoding/hex"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/lightninglabs/taproot-assets/asset"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,3 +29,33 @@ func TestNewChannelFundingScriptTree(t *testing.T) {
 	serializedTaprootKey := taprootKey.SerializeCompressed()
 	require.Equal(t, expectedTaprootKeyBytes, serializedTaprootKey)
 }
+
+// TestChannelFundingScriptTreeCustomNUMS tests that a custom NUMS key can be
+// used for the funding script tree and its spend witness.
+func TestChannelFundingScriptTreeCustomNUMS(t *testing.T) {
+	t.Parallel()
+
+	numsKey, err := asset.DeriveNUMSKey([]byte("test"))
+	require.NoError(t, err)
+
+	defaultTree := NewChannelFundingScriptTree()
+	customTree := NewChannelFundingScriptTree(WithNUMSKey(numsKey))
+	require.True(t, customTree.InternalKey.IsEqual(numsKey))
+	require.False(t, customTree.TaprootKey.IsEqual(defaultTree.TaprootKey))
+
+	witness, err := ChannelFundingSpendWitness(
+		false, asset.ID{}, WithNUMSKey(numsKey),
+	)
+	require.NoError(t, err)
+	require.Len(t, witness, 2)
+
+	ctrlBlock, err := txscript.ParseControlBlock(witness[1])
+	require.NoError(t, err)
+	require.True(t, ctrlBlock.InternalKey.IsEqual(numsKey))
+
+	err = txscript.VerifyTaprootLeafCommitment(
+		ctrlBlock, schnorr.SerializePubKey(customTree.TaprootKey),
+		witness[0],
+	)
+	require.NoError(t, err)
+}
@@ -0,0 +1,366 @@
+package tapscript
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// dummySchnorrSig is a placeholder signature that is used to estimate the size
+// of a witness before the actual signatures are known. A Schnorr signature
+// with the default sighash type is always 64 bytes long.
+var dummySchnorrSig = make([]byte, schnorr.SignatureSize)
+
+// SingleLeafTree is an asset-level script tree that consists of a single
+// tapscript leaf. It is the common base of the spend script templates in this
+// package.
+type SingleLeafTree struct {
+	// InternalKey is the internal key of the script key. For templates
+	// that can only be spent through the script path, this is a NUMS key.
+	InternalKey *btcec.PublicKey
+
+	// Leaf is the single tapscript leaf of the tree.
+	Leaf txscript.TapLeaf
+
+	// TapscriptRoot is the root of the tapscript tree, which is the hash
+	// of the single leaf.
+	TapscriptRoot []byte
+
+	// TaprootKey is the final, tweaked script key of the assets.
+	TaprootKey *btcec.PublicKey
+}
+
+// newSingleLeafTree creates a single leaf script tree from the given internal
+// key and leaf script.
+func newSingleLeafTree(internalKey *btcec.PublicKey,
+	script []byte) SingleLeafTree {
+
+	leaf := txscript.NewBaseTapLeaf(script)
+	rootHash := leaf.TapHash()
+
+	return SingleLeafTree{
+		InternalKey:   internalKey,
+		Leaf:          leaf,
+		TapscriptRoot: rootHash[:],
+		TaprootKey: txscript.ComputeTaprootOutputKey(
+			internalKey, rootHash[:],
+		),
+	}
+}
+
+// ScriptKey returns the asset-level script key of the tree. The tweaked script
+// key information is populated and the key is marked as an externally defined
+// script path key, so it can be declared to and tracked by the wallet.
+func (t *SingleLeafTree) ScriptKey() asset.ScriptKey {
+	return asset.ScriptKey{
+		PubKey: t.TaprootKey,
+		TweakedScriptKey: &asset.TweakedScriptKey{
+			RawKey: keychain.KeyDescriptor{
+				PubKey: t.InternalKey,
+			},
+			Tweak: t.TapscriptRoot,
+			Type:  asset.ScriptKeyScriptPathExternal,
+		},
+	}
+}
+
+// ControlBlock returns the control block for spending the single leaf.
+func (t *SingleLeafTree) ControlBlock() *txscript.ControlBlock {
+	return &txscript.ControlBlock{
+		InternalKey:     t.InternalKey,
+		OutputKeyYIsOdd: t.TaprootKey.SerializeCompressed()[0] == 0x03,
+		LeafVersion:     t.Leaf.LeafVersion,
+	}
+}
+
+// scriptPathWitness creates the asset-level witness that spends the single
+// leaf with the given witness stack elements, which are placed in front of the
+// leaf script and control block.
+func (t *SingleLeafTree) scriptPathWitness(
+	stack ...[]byte) (wire.TxWitness, error) {
+
+	ctrlBlockBytes, err := t.ControlBlock().ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize control "+
+			"block: %w", err)
+	}
+
+	witness := make(wire.TxWitness, 0, len(stack)+2)
+	witness = append(witness, stack...)
+
+	return append(witness, t.Leaf.Script, ctrlBlockBytes), nil
+}
+
+// HashLockTree is the asset-level script tree of a hash lock. The assets can
+// only be spent by revealing the preimage of the payment hash together with a
+// signature of the receiver key. A NUMS key is used as the internal key, so
+// there is no key spend path.
+type HashLockTree struct {
+	SingleLeafTree
+
+	// PaymentHash is the SHA256 hash of the preimage that unlocks the
+	// assets.
+	PaymentHash [32]byte
+
+	// ReceiverKey is the key that must sign the spending transaction.
+	ReceiverKey *btcec.PublicKey
+}
+
+// NewHashLockTree creates the asset-level script tree of a hash lock for the
+// given payment hash and receiver key. The BIP-0341 NUMS key is used as the
+// internal key, unless a different one is specified with WithNUMSKey.
+func NewHashLockTree(paymentHash [32]byte, receiverKey *btcec.PublicKey,
+	opts ...ScriptTreeOption) (*HashLockTree, error) {
+
+	cfg := newScriptTreeConfig(opts...)
+
+	if receiverKey == nil {
+		return nil, fmt.Errorf("receiver key must be set")
+	}
+
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_SIZE).
+		AddInt64(sha256.Size).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_SHA256).
+		AddData(paymentHash[:]).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddData(schnorr.SerializePubKey(receiverKey)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create hash lock script: %w",
+			err)
+	}
+
+	return &HashLockTree{
+		SingleLeafTree: newSingleLeafTree(cfg.NUMSKey, script),
+		PaymentHash:    paymentHash,
+		ReceiverKey:    receiverKey,
+	}, nil
+}
+
+// Witness creates the asset-level witness that spends the hash lock, given the
+// preimage of the payment hash and a valid Schnorr signature of the receiver
+// key over the spending virtual transaction.
+func (t *HashLockTree) Witness(preimage [32]byte,
+	sig []byte) (wire.TxWitness, error) {
+
+	if sha256.Sum256(preimage[:]) != t.PaymentHash {
+		return nil, fmt.Errorf("preimage doesn't match payment hash")
+	}
+
+	return t.scriptPathWitness(sig, preimage[:])
+}
+
+// WitnessSize returns the serialized size of the witness that spends the hash
+// lock, which can be used to estimate the size of the resulting proof.
+func (t *HashLockTree) WitnessSize() int {
+	witness, _ := t.scriptPathWitness(
+		dummySchnorrSig, make([]byte, sha256.Size),
+	)
+
+	return witness.SerializeSize()
+}
+
+// TimeLockType is the type of lock time a TimeLockTree is encumbered with.
+type TimeLockType uint8
+
+const (
+	// TimeLockAbsolute is an absolute lock time enforced with
+	// OP_CHECKLOCKTIMEVERIFY. The spending asset must set its LockTime
+	// to at least the lock time of the script.
+	TimeLockAbsolute TimeLockType = 0
+
+	// TimeLockRelative is a relative lock time enforced with
+	// OP_CHECKSEQUENCEVERIFY. The spending asset must set its
+	// RelativeLockTime to at least the lock time of the script.
+	TimeLockRelative TimeLockType = 1
+)
+
+// String returns a human-readable representation of the lock time type.
+func (t TimeLockType) String() string {
+	switch t {
+	case TimeLockAbsolute:
+		return "absolute"
+
+	case TimeLockRelative:
+		return "relative"
+
+	default:
+		return fmt.Sprintf("unknown <%d>", t)
+	}
+}
+
+// TimeLockTree is the asset-level script tree of a time lock. The assets can
+// only be spent by the owner key after the absolute or relative lock time has
+// passed. A NUMS key is used as the internal key, so there is no key spend
+// path.
+type TimeLockTree struct {
+	SingleLeafTree
+
+	// Type is the type of the lock time.
+	Type TimeLockType
+
+	// LockTime is the absolute or relative lock time of the assets, using
+	// the same encoding as the transaction lock time or sequence.
+	LockTime uint32
+
+	// OwnerKey is the key that can spend the assets after the lock time.
+	OwnerKey *btcec.PublicKey
+}
+
+// NewTimeLockTree creates the asset-level script tree of a time lock for the
+// given owner key. The BIP-0341 NUMS key is used as the internal key, unless a
+// different one is specified with WithNUMSKey.
+func NewTimeLockTree(lockType TimeLockType, lockTime uint32,
+	ownerKey *btcec.PublicKey,
+	opts ...ScriptTreeOption) (*TimeLockTree, error) {
+
+	cfg := newScriptTreeConfig(opts...)
+
+	if ownerKey == nil {
+		return nil, fmt.Errorf("owner key must be set")
+	}
+
+	if lockTime == 0 {
+		return nil, fmt.Errorf("lock time must be greater than zero")
+	}
+
+	var lockOp byte
+	switch lockType {
+	case TimeLockAbsolute:
+		lockOp = txscript.OP_CHECKLOCKTIMEVERIFY
+
+	case TimeLockRelative:
+		// With the disable flag set, OP_CHECKSEQUENCEVERIFY behaves
+		// like a no-op, so the output wouldn't be locked at all.
+		if lockTime&wire.SequenceLockTimeDisabled != 0 {
+			return nil, fmt.Errorf("relative lock time %d has the "+
+				"disable flag set", lockTime)
+		}
+
+		lockOp = txscript.OP_CHECKSEQUENCEVERIFY
+
+	default:
+		return nil, fmt.Errorf("unknown lock time type: %v", lockType)
+	}
+
+	script, err := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(ownerKey)).
+		AddOp(txscript.OP_CHECKSIGVERIFY).
+		AddInt64(int64(lockTime)).
+		AddOp(lockOp).
+		Script()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create time lock script: %w",
+			err)
+	}
+
+	return &TimeLockTree{
+		SingleLeafTree: newSingleLeafTree(cfg.NUMSKey, script),
+		Type:           lockType,
+		LockTime:       lockTime,
+		OwnerKey:       ownerKey,
+	}, nil
+}
+
+// Witness creates the asset-level witness that spends the time lock, given a
+// valid Schnorr signature of the owner key over the spending virtual
+// transaction.
+func (t *TimeLockTree) Witness(sig []byte) (wire.TxWitness, error) {
+	return t.scriptPathWitness(sig)
+}
+
+// WitnessSize returns the serialized size of the witness that spends the time
+// lock, which can be used to estimate the size of the resulting proof.
+func (t *TimeLockTree) WitnessSize() int {
+	witness, _ := t.scriptPathWitness(dummySchnorrSig)
+
+	return witness.SerializeSize()
+}
+
+// DelegationTree is the asset-level script tree of a delegated script key. The
+// owner key is used as the internal key and can spend the assets at any time
+// through the key spend path, while the delegate key can spend them through
+// the single script path leaf.
+type DelegationTree struct {
+	SingleLeafTree
+
+	// DelegateKey is the key the spending authority is delegated to.
+	DelegateKey *btcec.PublicKey
+}
+
+// NewDelegationTree creates the asset-level script tree that delegates the
+// spending authority of the owner key to the delegate key.
+func NewDelegationTree(ownerKey,
+	delegateKey *btcec.PublicKey) (*DelegationTree, error) {
+
+	if ownerKey == nil || delegateKey == nil {
+		return nil, fmt.Errorf("owner and delegate key must be set")
+	}
+
+	script, err := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(delegateKey)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create delegation script: "+
+			"%w", err)
+	}
+
+	return &DelegationTree{
+		SingleLeafTree: newSingleLeafTree(ownerKey, script),
+		DelegateKey:    delegateKey,
+	}, nil
+}
+
+// OwnerWitness creates the asset-level witness that spends the assets through
+// the key spend path, given a valid Schnorr signature of the (tweaked) owner
+// key.
+func (t *DelegationTree) OwnerWitness(sig []byte) wire.TxWitness {
+	return wire.TxWitness{sig}
+}
+
+// DelegateWitness creates the asset-level witness that spends the assets
+// through the delegation script path, given a valid Schnorr signature of the
+// delegate key over the spending virtual transaction.
+func (t *DelegationTree) DelegateWitness(sig []byte) (wire.TxWitness, error) {
+	return t.scriptPathWitness(sig)
+}
+
+// WitnessSize returns the serialized size of the larger of the two possible
+// witnesses, which is the delegate witness. This can be used to estimate the
+// size of the resulting proof.
+func (t *DelegationTree) WitnessSize() int {
+	witness, _ := t.scriptPathWitness(dummySchnorrSig)
+
+	return witness.SerializeSize()
+}
+
+// WitnessSize returns the serialized size of the witness that spends the
+// multisig leaf, which can be used to estimate the size of the resulting
+// proof.
+func (t *MultiSigTree) WitnessSize() int {
+	// Every participant is represented by either a signature or an empty
+	// element, so we count the signatures and the empty elements.
+	numEmpty := len(t.Keys) - int(t.Threshold)
+	witness := make(wire.TxWitness, 0, len(t.Keys)+2)
+	for i := 0; i < int(t.Threshold); i++ {
+		witness = append(witness, dummySchnorrSig)
+	}
+	for i := 0; i < numEmpty; i++ {
+		witness = append(witness, nil)
+	}
+
+	ctrlBlockBytes, _ := t.ControlBlock().ToBytes()
+	witness = append(witness, t.Leaf.Script, ctrlBlockBytes)
+
+	return witness.SerializeSize()
+}
@@ -0,0 +1,208 @@
+package tapscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// spendTemplate signs a transaction that spends an output locked to the given
+// script tree and executes the resulting witness with the script engine.
+func spendTemplate(t *testing.T, tree *SingleLeafTree, lockTime,
+	sequence uint32, witnessFn func(sig []byte) (wire.TxWitness, error),
+	signKey *btcec.PrivateKey) error {
+
+	t.Helper()
+
+	pkScript, err := txscript.PayToTaprootScript(tree.TaprootKey)
+	require.NoError(t, err)
+
+	prevOut := &wire.TxOut{Value: 1000, PkScript: pkScript}
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = lockTime
+	tx.AddTxIn(&wire.TxIn{Sequence: sequence})
+	tx.AddTxOut(&wire.TxOut{Value: 1000, PkScript: pkScript})
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(
+		prevOut.PkScript, prevOut.Value,
+	)
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+	sig, err := txscript.RawTxInTapscriptSignature(
+		tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, tree.Leaf,
+		txscript.SigHashDefault, signKey,
+	)
+	require.NoError(t, err)
+
+	witness, err := witnessFn(sig)
+	if err != nil {
+		return err
+	}
+	tx.TxIn[0].Witness = witness
+
+	engine, err := txscript.NewEngine(
+		prevOut.PkScript, tx, 0, txscript.StandardVerifyFlags, nil,
+		sigHashes, prevOut.Value, prevOutFetcher,
+	)
+	require.NoError(t, err)
+
+	return engine.Execute()
+}
+
+// TestSpendTemplates tests that the spend script templates can be spent with
+// the witnesses they create and that the witness size estimates are exact.
+func TestSpendTemplates(t *testing.T) {
+	t.Parallel()
+
+	receiverPriv := test.RandPrivKey()
+	receiverKey := receiverPriv.PubKey()
+	otherPriv := test.RandPrivKey()
+
+	// A hash lock can only be spent with the correct preimage and the
+	// receiver's signature.
+	var preimage [32]byte
+	copy(preimage[:], test.RandBytes(32))
+	paymentHash := sha256.Sum256(preimage[:])
+
+	_, err := NewHashLockTree(paymentHash, nil)
+	require.ErrorContains(t, err, "receiver key must be set")
+
+	hashLock, err := NewHashLockTree(paymentHash, receiverKey)
+	require.NoError(t, err)
+	require.Equal(
+		t, schnorr.SerializePubKey(hashLock.TaprootKey),
+		schnorr.SerializePubKey(hashLock.ScriptKey().PubKey),
+	)
+
+	hashLockWitness := func(sig []byte) (wire.TxWitness, error) {
+		witness, err := hashLock.Witness(preimage, sig)
+		if err == nil {
+			require.Equal(
+				t, hashLock.WitnessSize(),
+				witness.SerializeSize(),
+			)
+		}
+
+		return witness, err
+	}
+	require.NoError(t, spendTemplate(
+		t, &hashLock.SingleLeafTree, 0, wire.MaxTxInSequenceNum,
+		hashLockWitness, receiverPriv,
+	))
+	require.Error(t, spendTemplate(
+		t, &hashLock.SingleLeafTree, 0, wire.MaxTxInSequenceNum,
+		hashLockWitness, otherPriv,
+	))
+
+	_, err = hashLock.Witness([32]byte{1}, nil)
+	require.ErrorContains(t, err, "preimage doesn't match")
+
+	// A time lock can only be spent after the lock time.
+	_, err = NewTimeLockTree(TimeLockAbsolute, 0, receiverKey)
+	require.ErrorContains(t, err, "lock time must be greater than zero")
+
+	_, err = NewTimeLockTree(TimeLockType(9), 10, receiverKey)
+	require.ErrorContains(t, err, "unknown lock time type")
+
+	// A relative lock time with the disable flag set would make the CSV
+	// check a no-op, so it is rejected.
+	_, err = NewTimeLockTree(
+		TimeLockRelative, wire.SequenceLockTimeDisabled|144,
+		receiverKey,
+	)
+	require.ErrorContains(t, err, "disable flag set")
+
+	for _, lockType := range []TimeLockType{
+		TimeLockAbsolute, TimeLockRelative,
+	} {
+		const lockTime = 144
+		timeLock, err := NewTimeLockTree(
+			lockType, lockTime, receiverKey,
+		)
+		require.NoError(t, err)
+
+		witnessFn := func(sig []byte) (wire.TxWitness, error) {
+			witness, err := timeLock.Witness(sig)
+			require.NoError(t, err)
+			require.Equal(
+				t, timeLock.WitnessSize(),
+				witness.SerializeSize(),
+			)
+
+			return witness, nil
+		}
+
+		spend := func(value uint32) error {
+			if lockType == TimeLockAbsolute {
+				return spendTemplate(
+					t, &timeLock.SingleLeafTree, value, 0,
+					witnessFn, receiverPriv,
+				)
+			}
+
+			return spendTemplate(
+				t, &timeLock.SingleLeafTree, 0, value,
+				witnessFn, receiverPriv,
+			)
+		}
+
+		require.Error(t, spend(lockTime-1), lockType.String())
+		require.NoError(t, spend(lockTime), lockType.String())
+	}
+
+	// A delegation can be spent by the delegate through the script path.
+	ownerKey := test.RandPubKey(t)
+	_, err = NewDelegationTree(ownerKey, nil)
+	require.ErrorContains(t, err, "must be set")
+
+	delegation, err := NewDelegationTree(ownerKey, receiverKey)
+	require.NoError(t, err)
+	require.True(t, delegation.ScriptKey().TweakedScriptKey.RawKey.PubKey.
+		IsEqual(ownerKey))
+
+	delegateWitness := func(sig []byte) (wire.TxWitness, error) {
+		witness, err := delegation.DelegateWitness(sig)
+		require.NoError(t, err)
+		require.Equal(
+			t, delegation.WitnessSize(), witness.SerializeSize(),
+		)
+
+		return witness, nil
+	}
+	require.NoError(t, spendTemplate(
+		t, &delegation.SingleLeafTree, 0, wire.MaxTxInSequenceNum,
+		delegateWitness, receiverPriv,
+	))
+	require.Error(t, spendTemplate(
+		t, &delegation.SingleLeafTree, 0, wire.MaxTxInSequenceNum,
+		delegateWitness, otherPriv,
+	))
+}
+
+// TestMultiSigWitnessSize tests that the witness size estimate of a multisig
+// tree matches the size of an actual witness.
+func TestMultiSigWitnessSize(t *testing.T) {
+	t.Parallel()
+
+	keys := []*btcec.PublicKey{
+		test.RandPubKey(t), test.RandPubKey(t), test.RandPubKey(t),
+	}
+	tree, err := NewMultiSigTree(2, keys)
+	require.NoError(t, err)
+
+	sigs := make(map[[32]byte][]byte)
+	for _, key := range keys[:2] {
+		var xOnlyKey [32]byte
+		copy(xOnlyKey[:], schnorr.SerializePubKey(key))
+		sigs[xOnlyKey] = test.RandBytes(schnorr.SignatureSize)
+	}
+
+	witness, err := tree.Witness(sigs)
+	require.NoError(t, err)
+	require.Equal(t, tree.WitnessSize(), witness.SerializeSize())
+}
@@ -0,0 +1,186 @@
+package tapsend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+)
+
+var (
+	// ErrAnchorOutputNotAllowed is returned when a non-asset output of an
+	// anchor transaction pays to a script that isn't allowed.
+	ErrAnchorOutputNotAllowed = errors.New("anchor output script not " +
+		"allowed")
+)
+
+// AnchorOutputAllowList is a list of BTC level output scripts that the
+// non-asset outputs of an anchor transaction are allowed to pay to.
+type AnchorOutputAllowList struct {
+	scripts fn.Set[string]
+}
+
+// NewAnchorOutputAllowList creates a new allow list from the given output
+// scripts.
+func NewAnchorOutputAllowList(pkScripts ...[]byte) *AnchorOutputAllowList {
+	scripts := fn.NewSet[string]()
+	for _, pkScript := range pkScripts {
+		scripts.Add(string(pkScript))
+	}
+
+	return &AnchorOutputAllowList{
+		scripts: scripts,
+	}
+}
+
+// IsAllowed returns true if the given output script is on the allow list.
+func (l *AnchorOutputAllowList) IsAllowed(pkScript []byte) bool {
+	return l.scripts.Contains(string(pkScript))
+}
+
+// NonAssetAnchorOutputs returns the indexes of all outputs of the given anchor
+// transaction that don't carry an asset commitment of any of the given virtual
+// packets.
+func NonAssetAnchorOutputs(anchorPkt *psbt.Packet,
+	vPackets []*tappsbt.VPacket) []int {
+
+	assetOutputs := fn.NewSet[uint32]()
+	for _, vPkt := range vPackets {
+		for _, vOut := range vPkt.Outputs {
+			assetOutputs.Add(vOut.AnchorOutputIndex)
+		}
+	}
+
+	var nonAssetOutputs []int
+	for idx := range anchorPkt.UnsignedTx.TxOut {
+		if !assetOutputs.Contains(uint32(idx)) {
+			nonAssetOutputs = append(nonAssetOutputs, idx)
+		}
+	}
+
+	return nonAssetOutputs
+}
+
+// ValidateNonAssetOutputs makes sure that every output of the anchor
+// transaction that doesn't carry an asset commitment either is one of the
+// given wallet controlled outputs (such as the change output added by the
+// wallet when funding the transaction) or pays to a script on the allow list.
+// This protects against a manipulated anchor transaction template in a
+// multi-party transfer diverting BTC to a third party.
+func ValidateNonAssetOutputs(anchorPkt *psbt.Packet,
+	vPackets []*tappsbt.VPacket, allowList *AnchorOutputAllowList,
+	walletOutputs ...int) error {
+
+	if anchorPkt == nil || anchorPkt.UnsignedTx == nil {
+		return fmt.Errorf("anchor packet is missing")
+	}
+
+	for _, idx := range NonAssetAnchorOutputs(anchorPkt, vPackets) {
+		if slices.Contains(walletOutputs, idx) {
+			continue
+		}
+
+		pkScript := anchorPkt.UnsignedTx.TxOut[idx].PkScript
+		if allowList != nil && allowList.IsAllowed(pkScript) {
+			continue
+		}
+
+		return fmt.Errorf("%w: output %d pays to %x",
+			ErrAnchorOutputNotAllowed, idx, pkScript)
+	}
+
+	return nil
+}
+
+// ExtraAnchorOutput is a plain BTC output that is added to an anchor
+// transaction in addition to the asset carrying outputs, for example to pay a
+// service fee.
+type ExtraAnchorOutput struct {
+	// TxOut is the BTC output to add.
+	TxOut *wire.TxOut
+
+	// InternalKey is the BIP-0086 internal key of a P2TR output. It is
+	// required for P2TR outputs, as every P2TR output of an anchor
+	// transaction needs an exclusion proof that shows it doesn't commit to
+	// any assets. It must be nil for all other output types.
+	InternalKey *btcec.PublicKey
+}
+
+// Validate makes sure the extra output is spendable and that an exclusion
+// proof can be created for it.
+func (o *ExtraAnchorOutput) Validate() error {
+	if o.TxOut == nil {
+		return fmt.Errorf("extra anchor output is missing tx out")
+	}
+
+	if !txscript.IsNullData(o.TxOut.PkScript) &&
+		mempool.IsDust(o.TxOut, mempool.DefaultMinRelayTxFee) {
+
+		return fmt.Errorf("extra anchor output of %d sats is dust",
+			o.TxOut.Value)
+	}
+
+	if !txscript.IsPayToTaproot(o.TxOut.PkScript) {
+		if o.InternalKey != nil {
+			return fmt.Errorf("internal key given for non-P2TR " +
+				"extra anchor output")
+		}
+
+		return nil
+	}
+
+	// The exclusion proof of a P2TR output can only be created if we know
+	// the output doesn't commit to a tapscript tree.
+	if o.InternalKey == nil {
+		return fmt.Errorf("P2TR extra anchor output is missing " +
+			"internal key")
+	}
+
+	outputKey := txscript.ComputeTaprootKeyNoScript(o.InternalKey)
+	expectedScript, err := txscript.PayToTaprootScript(outputKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expectedScript, o.TxOut.PkScript) {
+		return fmt.Errorf("P2TR extra anchor output is not a " +
+			"BIP-0086 output of the given internal key")
+	}
+
+	return nil
+}
+
+// addExtraAnchorOutputs appends the given extra outputs to the anchor packet.
+func addExtraAnchorOutputs(anchorPkt *psbt.Packet,
+	extraOutputs []*ExtraAnchorOutput) error {
+
+	for idx, extraOut := range extraOutputs {
+		if err := extraOut.Validate(); err != nil {
+			return fmt.Errorf("invalid extra anchor output %d: %w",
+				idx, err)
+		}
+
+		var pOut psbt.POutput
+		if extraOut.InternalKey != nil {
+			pOut.TaprootInternalKey = schnorr.SerializePubKey(
+				extraOut.InternalKey,
+			)
+		}
+
+		anchorPkt.UnsignedTx.AddTxOut(&wire.TxOut{
+			Value:    extraOut.TxOut.Value,
+			PkScript: slices.Clone(extraOut.TxOut.PkScript),
+		})
+		anchorPkt.Outputs = append(anchorPkt.Outputs, pOut)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package tapsend_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/proof"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/lightninglabs/taproot-assets/tapsend"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateNonAssetOutputs tests that non-asset outputs of an anchor
+// transaction are only accepted if they are wallet controlled or on the allow
+// list.
+func TestValidateNonAssetOutputs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		assetScript   = test.RandBytes(34)
+		changeScript  = test.RandBytes(34)
+		allowedScript = test.RandBytes(34)
+		foreignScript = test.RandBytes(34)
+	)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	for _, pkScript := range [][]byte{
+		assetScript, changeScript, allowedScript,
+	} {
+		tx.AddTxOut(&wire.TxOut{Value: 1000, PkScript: pkScript})
+	}
+	anchorPkt, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+
+	vPackets := []*tappsbt.VPacket{{
+		Outputs: []*tappsbt.VOutput{{
+			AnchorOutputIndex: 0,
+		}},
+	}}
+	require.Equal(
+		t, []int{1, 2},
+		tapsend.NonAssetAnchorOutputs(anchorPkt, vPackets),
+	)
+
+	allowList := tapsend.NewAnchorOutputAllowList(allowedScript)
+
+	// The change output is only accepted if it is marked as wallet
+	// controlled.
+	err = tapsend.ValidateNonAssetOutputs(anchorPkt, vPackets, allowList)
+	require.ErrorIs(t, err, tapsend.ErrAnchorOutputNotAllowed)
+
+	err = tapsend.ValidateNonAssetOutputs(
+		anchorPkt, vPackets, allowList, 1,
+	)
+	require.NoError(t, err)
+
+	// An output that pays to a script that isn't on the allow list is
+	// rejected.
+	tx.AddTxOut(&wire.TxOut{Value: 1000, PkScript: foreignScript})
+	anchorPkt, err = psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+
+	err = tapsend.ValidateNonAssetOutputs(
+		anchorPkt, vPackets, allowList, 1,
+	)
+	require.ErrorIs(t, err, tapsend.ErrAnchorOutputNotAllowed)
+	require.ErrorContains(t, err, "output 3")
+}
+
+// TestPrepareAnchoringTemplateExtraOutputs tests that extra plain BTC outputs
+// are appended to the anchoring template and receive an exclusion proof.
+func TestPrepareAnchoringTemplateExtraOutputs(t *testing.T) {
+	t.Parallel()
+
+	vPackets := []*tappsbt.VPacket{{
+		Outputs: []*tappsbt.VOutput{{
+			AnchorOutputIndex:       0,
+			AnchorOutputInternalKey: test.RandPubKey(t),
+		}},
+	}}
+
+	feeKey := test.RandPubKey(t)
+	feeScript, err := txscript.PayToTaprootScript(
+		txscript.ComputeTaprootKeyNoScript(feeKey),
+	)
+	require.NoError(t, err)
+
+	p2wkhScript := append([]byte{txscript.OP_0, 20}, test.RandBytes(20)...)
+
+	// A P2TR output without internal key can't get an exclusion proof.
+	_, err = tapsend.PrepareAnchoringTemplate(
+		vPackets, &tapsend.ExtraAnchorOutput{
+			TxOut: wire.NewTxOut(10_000, feeScript),
+		},
+	)
+	require.ErrorContains(t, err, "missing internal key")
+
+	// Neither can one that commits to a tapscript tree.
+	_, err = tapsend.PrepareAnchoringTemplate(
+		vPackets, &tapsend.ExtraAnchorOutput{
+			TxOut:       wire.NewTxOut(10_000, feeScript),
+			InternalKey: test.RandPubKey(t),
+		},
+	)
+	require.ErrorContains(t, err, "not a BIP-0086 output")
+
+	// Dust outputs are rejected.
+	_, err = tapsend.PrepareAnchoringTemplate(
+		vPackets, &tapsend.ExtraAnchorOutput{
+			TxOut: wire.NewTxOut(100, p2wkhScript),
+		},
+	)
+	require.ErrorContains(t, err, "dust")
+
+	btcPkt, err := tapsend.PrepareAnchoringTemplate(
+		vPackets, &tapsend.ExtraAnchorOutput{
+			TxOut:       wire.NewTxOut(10_000, feeScript),
+			InternalKey: feeKey,
+		}, &tapsend.ExtraAnchorOutput{
+			TxOut: wire.NewTxOut(5_000, p2wkhScript),
+		},
+	)
+	require.NoError(t, err)
+
+	// The extra outputs are appended after the asset anchor outputs.
+	require.Len(t, btcPkt.UnsignedTx.TxOut, 3)
+	require.Len(t, btcPkt.Outputs, 3)
+	require.Equal(t, feeScript, btcPkt.UnsignedTx.TxOut[1].PkScript)
+	require.Equal(t, p2wkhScript, btcPkt.UnsignedTx.TxOut[2].PkScript)
+	require.Equal(
+		t, []int{1, 2},
+		tapsend.NonAssetAnchorOutputs(btcPkt, vPackets),
+	)
+
+	// Only the P2TR fee output needs an exclusion proof.
+	var params proof.BaseProofParams
+	err = proof.AddExclusionProofs(
+		&params, btcPkt.UnsignedTx, btcPkt.Outputs,
+		func(idx uint32) bool {
+			return idx == 0
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, params.ExclusionProofs, 1)
+	require.EqualValues(t, 1, params.ExclusionProofs[0].OutputIndex)
+	require.True(t, params.ExclusionProofs[0].InternalKey.IsEqual(feeKey))
+}
@@ -0,0 +1,272 @@
+package tapsend
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/proof"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// CreateSecondLevelHtlcClaimPacket creates the virtual packet for the first
+// stage of a two-stage HTLC claim. The full value of the HTLC asset described
+// by the given proof is moved to the intermediate output described by the
+// second level tree. The input witness (for example the preimage and signature
+// of the HTLC's success path) still needs to be added by the caller before the
+// packet can be anchored.
+func CreateSecondLevelHtlcClaimPacket(htlcProof *proof.Proof,
+	tree *tapscript.SecondLevelHtlcTree, anchorOutputIndex uint32,
+	anchorInternalKey keychain.KeyDescriptor,
+	chainParams *address.ChainParams) (*tappsbt.VPacket, error) {
+
+	if tree == nil {
+		return nil, fmt.Errorf("second level tree must be set")
+	}
+
+	return fullValueHtlcPacket(
+		htlcProof, tree.ScriptKey(), 0, anchorOutputIndex,
+		anchorInternalKey, chainParams,
+	)
+}
+
+// CreateSecondLevelHtlcSweepPacket creates the virtual packet for the second
+// stage of a two-stage HTLC claim. The full value of the intermediate output
+// described by the given proof is swept to the given script key. The relative
+// lock time of the output asset is set to the CSV delay of the second level
+// tree, so the packet can only be anchored once the delay has passed.
+func CreateSecondLevelHtlcSweepPacket(secondLevelProof *proof.Proof,
+	tree *tapscript.SecondLevelHtlcTree, sweepScriptKey asset.ScriptKey,
+	anchorOutputIndex uint32, anchorInternalKey keychain.KeyDescriptor,
+	chainParams *address.ChainParams) (*tappsbt.VPacket, error) {
+
+	if tree == nil {
+		return nil, fmt.Errorf("second level tree must be set")
+	}
+
+	// Make sure we're actually sweeping an intermediate output that was
+	// created from the given tree. Otherwise, the witness created by the
+	// tree would not be valid for the input.
+	if secondLevelProof == nil {
+		return nil, fmt.Errorf("second level proof must be set")
+	}
+	expectedKey := tree.ScriptKey().PubKey
+	if !secondLevelProof.Asset.ScriptKey.PubKey.IsEqual(expectedKey) {
+		return nil, fmt.Errorf("asset script key %x doesn't match "+
+			"second level script key %x",
+			secondLevelProof.Asset.ScriptKey.PubKey.
+				SerializeCompressed(),
+			expectedKey.SerializeCompressed())
+	}
+
+	return fullValueHtlcPacket(
+		secondLevelProof, sweepScriptKey, uint64(tree.CsvDelay),
+		anchorOutputIndex, anchorInternalKey, chainParams,
+	)
+}
+
+// HtlcOutput describes a single script constrained HTLC output of a batch of
+// HTLCs that are funded in the same virtual packet.
+type HtlcOutput struct {
+	// Amount is the number of asset units locked in the HTLC.
+	Amount uint64
+
+	// ScriptKey is the script key of the HTLC, committing to its spending
+	// conditions.
+	ScriptKey asset.ScriptKey
+
+	// AnchorOutputIndex is the index of the BTC level output that carries
+	// the HTLC. Multiple HTLCs can share the same anchor output, as long as
+	// their script keys differ.
+	AnchorOutputIndex uint32
+
+	// AnchorInternalKey is the internal key of the anchor output.
+	AnchorInternalKey keychain.KeyDescriptor
+}
+
+// CreateHtlcBatchPacket creates a virtual packet that funds multiple
+// independent HTLC outputs of the same asset from the given input proofs, for
+// example a batch of swap HTLCs to different peers. Any amount not allocated to
+// the HTLCs is sent to the given change output, the amount of which is
+// ignored. The change output is marked as the split root, so each HTLC becomes
+// an independent split output that can be spent on its own. If there is no
+// change, the first HTLC is used as the split root instead.
+func CreateHtlcBatchPacket(inputProofs []*proof.Proof, htlcs []HtlcOutput,
+	change HtlcOutput,
+	chainParams *address.ChainParams) (*tappsbt.VPacket, error) {
+
+	if len(inputProofs) == 0 {
+		return nil, ErrMissingInputs
+	}
+	if len(htlcs) == 0 {
+		return nil, fmt.Errorf("at least one HTLC output is required")
+	}
+
+	// All inputs must be of the same asset, as a virtual packet can only
+	// ever transfer a single asset ID.
+	var inputSum uint64
+	assetID := inputProofs[0].Asset.ID()
+	for _, p := range inputProofs {
+		if p.Asset.ID() != assetID {
+			return nil, fmt.Errorf("all inputs must be of the "+
+				"same asset, got %v and %v", assetID,
+				p.Asset.ID())
+		}
+
+		if inputSum > math.MaxUint64-p.Asset.Amount {
+			return nil, fmt.Errorf("input amount overflow")
+		}
+		inputSum += p.Asset.Amount
+	}
+
+	// Two HTLCs with the same script key in the same anchor output would
+	// end up in the same leaf of the asset commitment, so we don't allow
+	// that.
+	type leafKey struct {
+		anchorOutputIndex uint32
+		scriptKey         asset.SerializedKey
+	}
+	leaves := make(map[leafKey]struct{}, len(htlcs))
+
+	var htlcSum uint64
+	for idx, htlc := range htlcs {
+		if htlc.Amount == 0 {
+			return nil, fmt.Errorf("HTLC %d has zero amount", idx)
+		}
+		if htlc.ScriptKey.PubKey == nil {
+			return nil, fmt.Errorf("HTLC %d has no script key", idx)
+		}
+
+		key := leafKey{
+			anchorOutputIndex: htlc.AnchorOutputIndex,
+			scriptKey: asset.ToSerialized(
+				htlc.ScriptKey.PubKey,
+			),
+		}
+		if _, ok := leaves[key]; ok {
+			return nil, fmt.Errorf("HTLC %d has duplicate script "+
+				"key in anchor output %d", idx,
+				htlc.AnchorOutputIndex)
+		}
+		leaves[key] = struct{}{}
+
+		if htlcSum > math.MaxUint64-htlc.Amount {
+			return nil, fmt.Errorf("HTLC amount overflow")
+		}
+		htlcSum += htlc.Amount
+	}
+
+	if htlcSum > inputSum {
+		return nil, fmt.Errorf("%w: HTLCs require %d units, "+
+			"inputs only have %d", ErrInsufficientInputAssets,
+			htlcSum, inputSum)
+	}
+
+	// The change output is only created if there is any change. It would
+	// end up in the same leaf as an HTLC with the same script key in the
+	// same anchor output, which would merge the change into the HTLC.
+	changeAmount := inputSum - htlcSum
+	if changeAmount > 0 {
+		if change.ScriptKey.PubKey == nil {
+			return nil, fmt.Errorf("change output has no script " +
+				"key")
+		}
+
+		key := leafKey{
+			anchorOutputIndex: change.AnchorOutputIndex,
+			scriptKey: asset.ToSerialized(
+				change.ScriptKey.PubKey,
+			),
+		}
+		if _, ok := leaves[key]; ok {
+			return nil, fmt.Errorf("change output has same script "+
+				"key as an HTLC in anchor output %d",
+				change.AnchorOutputIndex)
+		}
+	}
+
+	vPkt, err := tappsbt.FromProofs(inputProofs, chainParams, tappsbt.V1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create packet from "+
+			"proofs: %w", err)
+	}
+
+	assetVersion := inputProofs[0].Asset.Version
+	newOutput := func(out HtlcOutput, amount uint64,
+		outType tappsbt.VOutputType) *tappsbt.VOutput {
+
+		vOut := &tappsbt.VOutput{
+			Amount:            amount,
+			AssetVersion:      assetVersion,
+			Type:              outType,
+			Interactive:       true,
+			AnchorOutputIndex: out.AnchorOutputIndex,
+			ScriptKey:         out.ScriptKey,
+		}
+		vOut.SetAnchorInternalKey(
+			out.AnchorInternalKey, chainParams.HDCoinType,
+		)
+
+		return vOut
+	}
+
+	if changeAmount > 0 {
+		vPkt.Outputs = append(vPkt.Outputs, newOutput(
+			change, changeAmount, tappsbt.TypeSplitRoot,
+		))
+	}
+
+	for _, htlc := range htlcs {
+		vPkt.Outputs = append(vPkt.Outputs, newOutput(
+			htlc, htlc.Amount, tappsbt.TypeSimple,
+		))
+	}
+
+	// Without change, one of the HTLCs needs to carry the split root if we
+	// split the input into multiple outputs. Just like in the coin
+	// distribution logic, we just select the first one.
+	if len(vPkt.Outputs) > 1 && !vPkt.HasSplitRootOutput() {
+		vPkt.Outputs[0].Type = tappsbt.TypeSplitRoot
+	}
+
+	return vPkt, nil
+}
+
+// fullValueHtlcPacket creates a virtual packet that spends the full value of
+// the asset in the given proof to a single interactive output with the given
+// script key and relative lock time.
+func fullValueHtlcPacket(inputProof *proof.Proof, scriptKey asset.ScriptKey,
+	relativeLockTime uint64, anchorOutputIndex uint32,
+	anchorInternalKey keychain.KeyDescriptor,
+	chainParams *address.ChainParams) (*tappsbt.VPacket, error) {
+
+	if inputProof == nil {
+		return nil, fmt.Errorf("input proof must be set")
+	}
+
+	vPkt, err := tappsbt.FromProofs(
+		[]*proof.Proof{inputProof}, chainParams, tappsbt.V1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create packet from proof: %w",
+			err)
+	}
+
+	inputAsset := inputProof.Asset
+	vOut := &tappsbt.VOutput{
+		Amount:            inputAsset.Amount,
+		AssetVersion:      inputAsset.Version,
+		Type:              tappsbt.TypeSimple,
+		Interactive:       true,
+		AnchorOutputIndex: anchorOutputIndex,
+		ScriptKey:         scriptKey,
+		RelativeLockTime:  relativeLockTime,
+	}
+	vOut.SetAnchorInternalKey(anchorInternalKey, chainParams.HDCoinType)
+	vPkt.Outputs = append(vPkt.Outputs, vOut)
+
+	return vPkt, nil
+}
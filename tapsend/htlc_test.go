@@ -0,0 +1,179 @@
+package tapsend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/proof"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSecondLevelHtlcPackets tests that the claim and sweep packets of a
+// two-stage HTLC claim move the full value to the expected script keys.
+func TestSecondLevelHtlcPackets(t *testing.T) {
+	t.Parallel()
+
+	const csvDelay = 144
+
+	tree, err := tapscript.NewSecondLevelHtlcTree(
+		test.RandPubKey(t), test.RandPubKey(t), csvDelay,
+	)
+	require.NoError(t, err)
+
+	anchorKey := keychain.KeyDescriptor{
+		PubKey: test.RandPubKey(t),
+	}
+
+	htlcAsset := asset.NewAssetNoErr(
+		t, asset.RandGenesis(t, asset.Normal), 1000, 0, 0,
+		asset.RandScriptKey(t), nil,
+	)
+	claimPkt, err := CreateSecondLevelHtlcClaimPacket(
+		makeProof(t, htlcAsset), tree, 1, anchorKey, testParams,
+	)
+	require.NoError(t, err)
+	require.Len(t, claimPkt.Inputs, 1)
+	require.Len(t, claimPkt.Outputs, 1)
+
+	claimOut := claimPkt.Outputs[0]
+	require.Equal(t, tappsbt.TypeSimple, claimOut.Type)
+	require.EqualValues(t, 1000, claimOut.Amount)
+	require.EqualValues(t, 1, claimOut.AnchorOutputIndex)
+	require.Zero(t, claimOut.RelativeLockTime)
+	require.True(t, claimOut.ScriptKey.PubKey.IsEqual(
+		tree.ScriptKey().PubKey,
+	))
+
+	// Sweeping an asset that isn't locked to the second level tree must
+	// fail.
+	sweepKey := asset.RandScriptKey(t)
+	_, err = CreateSecondLevelHtlcSweepPacket(
+		makeProof(t, htlcAsset), tree, sweepKey, 0, anchorKey,
+		testParams,
+	)
+	require.ErrorContains(t, err, "doesn't match second level script")
+
+	secondLevelAsset := asset.NewAssetNoErr(
+		t, htlcAsset.Genesis, 1000, 0, 0, tree.ScriptKey(), nil,
+	)
+	sweepPkt, err := CreateSecondLevelHtlcSweepPacket(
+		makeProof(t, secondLevelAsset), tree, sweepKey, 0, anchorKey,
+		testParams,
+	)
+	require.NoError(t, err)
+	require.Len(t, sweepPkt.Outputs, 1)
+
+	sweepOut := sweepPkt.Outputs[0]
+	require.EqualValues(t, 1000, sweepOut.Amount)
+	require.EqualValues(t, csvDelay, sweepOut.RelativeLockTime)
+	require.True(t, sweepOut.ScriptKey.PubKey.IsEqual(sweepKey.PubKey))
+}
+
+// TestHtlcBatchPacket tests that multiple independent HTLC outputs can be
+// funded in the same virtual packet and that the resulting split commitment
+// is valid.
+func TestHtlcBatchPacket(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	gen := asset.RandGenesis(t, asset.Normal)
+	inputs := []*proof.Proof{
+		makeProof(t, asset.NewAssetNoErr(
+			t, gen, 600, 0, 0, asset.RandScriptKey(t), nil,
+		)),
+		makeProof(t, asset.NewAssetNoErr(
+			t, gen, 400, 0, 0, asset.RandScriptKey(t), nil,
+		)),
+	}
+
+	newHtlc := func(amount uint64, anchorIdx uint32) HtlcOutput {
+		return HtlcOutput{
+			Amount:            amount,
+			ScriptKey:         asset.RandScriptKey(t),
+			AnchorOutputIndex: anchorIdx,
+			AnchorInternalKey: keychain.KeyDescriptor{
+				PubKey: test.RandPubKey(t),
+			},
+		}
+	}
+	change := newHtlc(0, 0)
+
+	// Two HTLCs share the second anchor output, the third one has its own
+	// anchor output.
+	htlcs := []HtlcOutput{
+		newHtlc(100, 1), newHtlc(200, 1), newHtlc(300, 2),
+	}
+	vPkt, err := CreateHtlcBatchPacket(inputs, htlcs, change, testParams)
+	require.NoError(t, err)
+	require.Len(t, vPkt.Inputs, 2)
+	require.Len(t, vPkt.Outputs, 4)
+
+	changeOut := vPkt.Outputs[0]
+	require.Equal(t, tappsbt.TypeSplitRoot, changeOut.Type)
+	require.EqualValues(t, 400, changeOut.Amount)
+	for idx, htlc := range htlcs {
+		vOut := vPkt.Outputs[idx+1]
+		require.Equal(t, tappsbt.TypeSimple, vOut.Type)
+		require.Equal(t, htlc.Amount, vOut.Amount)
+		require.Equal(t, htlc.AnchorOutputIndex, vOut.AnchorOutputIndex)
+	}
+
+	// The split commitment must be valid, with each HTLC being a separate
+	// split output.
+	require.NoError(t, PrepareOutputAssets(ctx, vPkt))
+	for _, vOut := range vPkt.Outputs[1:] {
+		require.True(t, vOut.Asset.HasSplitCommitmentWitness())
+	}
+
+	// Without change, the first HTLC becomes the split root.
+	htlcs = []HtlcOutput{newHtlc(400, 1), newHtlc(600, 2)}
+	vPkt, err = CreateHtlcBatchPacket(inputs, htlcs, change, testParams)
+	require.NoError(t, err)
+	require.Len(t, vPkt.Outputs, 2)
+	require.Equal(t, tappsbt.TypeSplitRoot, vPkt.Outputs[0].Type)
+	require.Equal(t, tappsbt.TypeSimple, vPkt.Outputs[1].Type)
+	require.NoError(t, PrepareOutputAssets(ctx, vPkt))
+
+	// Allocating more than the inputs carry must fail.
+	htlcs = []HtlcOutput{newHtlc(1000, 1), newHtlc(1, 2)}
+	_, err = CreateHtlcBatchPacket(inputs, htlcs, change, testParams)
+	require.ErrorIs(t, err, ErrInsufficientInputAssets)
+
+	// Two HTLCs with the same script key can't share an anchor output.
+	dup := newHtlc(100, 1)
+	htlcs = []HtlcOutput{dup, dup}
+	_, err = CreateHtlcBatchPacket(inputs, htlcs, change, testParams)
+	require.ErrorContains(t, err, "duplicate script key")
+
+	// The change output can't share a script key with an HTLC in the same
+	// anchor output either, but it can in a different anchor output.
+	htlc := newHtlc(100, 1)
+	collidingChange := htlc
+	collidingChange.Amount = 0
+	htlcs = []HtlcOutput{htlc}
+	_, err = CreateHtlcBatchPacket(
+		inputs, htlcs, collidingChange, testParams,
+	)
+	require.ErrorContains(t, err, "same script key as an HTLC")
+
+	collidingChange.AnchorOutputIndex = 0
+	_, err = CreateHtlcBatchPacket(
+		inputs, htlcs, collidingChange, testParams,
+	)
+	require.NoError(t, err)
+
+	// Inputs of different assets can't be mixed.
+	otherInput := makeProof(t, asset.NewAssetNoErr(
+		t, asset.RandGenesis(t, asset.Normal), 100, 0, 0,
+		asset.RandScriptKey(t), nil,
+	))
+	_, err = CreateHtlcBatchPacket(
+		append(inputs, otherInput), htlcs[:1], change, testParams,
+	)
+	require.ErrorContains(t, err, "must be of the same asset")
+}
@@ -0,0 +1,150 @@
+package tapsend
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// PrepareMultiSigInput prepares the virtual input at the given index to be
+// signed by a single participant of the given k-of-n multisig tree. Each
+// participant signs their own copy of the packet with SignVirtualTransaction
+// (using a validator that accepts incomplete witnesses), extracts the
+// signature with MultiSigPartialSig and hands it to the coordinator, who
+// combines them with MultiSigTree.Witness and ApplyMultiSigWitness.
+func PrepareMultiSigInput(vPkt *tappsbt.VPacket, inputIdx int,
+	tree *tapscript.MultiSigTree, signerKey keychain.KeyDescriptor) error {
+
+	if inputIdx < 0 || inputIdx >= len(vPkt.Inputs) {
+		return fmt.Errorf("invalid input index %d", inputIdx)
+	}
+
+	vIn := vPkt.Inputs[inputIdx]
+	if vIn.Asset() == nil {
+		return fmt.Errorf("input %d has no asset", inputIdx)
+	}
+	if !vIn.Asset().ScriptKey.PubKey.IsEqual(tree.TaprootKey) {
+		return fmt.Errorf("input %d script key doesn't match multisig "+
+			"script key", inputIdx)
+	}
+
+	isParticipant := fn.Any(tree.Keys, signerKey.PubKey.IsEqual)
+	if !isParticipant {
+		return fmt.Errorf("signer key %x is not a multisig participant",
+			signerKey.PubKey.SerializeCompressed())
+	}
+
+	ctrlBlockBytes, err := tree.ControlBlock().ToBytes()
+	if err != nil {
+		return fmt.Errorf("unable to serialize control block: %w", err)
+	}
+
+	derivation, trDerivation := tappsbt.Bip32DerivationFromKeyDesc(
+		signerKey, vPkt.ChainParams.HDCoinType,
+	)
+	leafHash := tree.Leaf.TapHash()
+	trDerivation.LeafHashes = [][]byte{leafHash[:]}
+
+	vIn.Bip32Derivation = []*psbt.Bip32Derivation{derivation}
+	vIn.TaprootBip32Derivation = []*psbt.TaprootBip32Derivation{
+		trDerivation,
+	}
+	vIn.TaprootMerkleRoot = tree.TapscriptRoot
+	vIn.TaprootLeafScript = []*psbt.TaprootTapLeafScript{{
+		ControlBlock: ctrlBlockBytes,
+		Script:       tree.Leaf.Script,
+		LeafVersion:  tree.Leaf.LeafVersion,
+	}}
+	vIn.SighashType = txscript.SigHashDefault
+
+	return nil
+}
+
+// multiSigSignedAsset returns the asset of the virtual packet that carries
+// the input witnesses, which is the split root asset for split transfers.
+func multiSigSignedAsset(vPkt *tappsbt.VPacket) (*asset.Asset, error) {
+	isSplit, err := vPkt.HasSplitCommitment()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSplit {
+		return vPkt.Outputs[0].Asset, nil
+	}
+
+	splitOut, err := vPkt.SplitRootOutput()
+	if err != nil {
+		return nil, fmt.Errorf("no split root output found for split "+
+			"transaction: %w", err)
+	}
+
+	return splitOut.Asset, nil
+}
+
+// MultiSigPartialSig extracts the signature of a single multisig participant
+// for the input at the given index from a virtual packet that was prepared
+// with PrepareMultiSigInput and signed with SignVirtualTransaction.
+func MultiSigPartialSig(vPkt *tappsbt.VPacket, inputIdx int) ([]byte, error) {
+	signedAsset, err := multiSigSignedAsset(vPkt)
+	if err != nil {
+		return nil, err
+	}
+
+	if inputIdx < 0 || inputIdx >= len(signedAsset.PrevWitnesses) {
+		return nil, fmt.Errorf("invalid input index %d", inputIdx)
+	}
+
+	witness := signedAsset.PrevWitnesses[inputIdx].TxWitness
+	if len(witness) == 0 {
+		return nil, fmt.Errorf("input %d is not signed", inputIdx)
+	}
+
+	return witness[0], nil
+}
+
+// ApplyMultiSigWitness sets the given final multisig witness on the input at
+// the given index of the virtual packet. For split transfers, the root asset
+// copies in the split commitments of all outputs are updated as well.
+func ApplyMultiSigWitness(vPkt *tappsbt.VPacket, inputIdx int,
+	witness wire.TxWitness) error {
+
+	signedAsset, err := multiSigSignedAsset(vPkt)
+	if err != nil {
+		return err
+	}
+
+	if inputIdx < 0 || inputIdx >= len(signedAsset.PrevWitnesses) {
+		return fmt.Errorf("invalid input index %d", inputIdx)
+	}
+	signedAsset.PrevWitnesses[inputIdx].TxWitness = witness
+
+	isSplit, err := vPkt.HasSplitCommitment()
+	if err != nil || !isSplit {
+		return err
+	}
+
+	for _, vOut := range vPkt.Outputs {
+		splitAsset := vOut.Asset
+		if vOut.Type.IsSplitRoot() {
+			splitAsset = vOut.SplitAsset
+		}
+		if splitAsset == nil {
+			continue
+		}
+		if !splitAsset.HasSplitCommitmentWitness() {
+			continue
+		}
+
+		splitCommitment := splitAsset.PrevWitnesses[0].SplitCommitment
+		splitCommitment.RootAsset = *signedAsset.Copy()
+	}
+
+	return nil
+}
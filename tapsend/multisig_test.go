@@ -0,0 +1,150 @@
+package tapsend_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	tap "github.com/lightninglabs/taproot-assets"
+	"github.com/lightninglabs/taproot-assets/address"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/commitment"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/lightninglabs/taproot-assets/tapscript"
+	"github.com/lightninglabs/taproot-assets/tapsend"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/stretchr/testify/require"
+)
+
+// noOpWitnessValidator is a witness validator that accepts any witness. It
+// is used by multisig participants that only create a partial signature.
+type noOpWitnessValidator struct{}
+
+// ValidateWitnesses accepts any witness.
+func (noOpWitnessValidator) ValidateWitnesses(*asset.Asset,
+	[]*commitment.SplitAsset, commitment.InputSet) error {
+
+	return nil
+}
+
+// TestMultiSigScriptKey tests that assets locked to a k-of-n multisig script
+// key can be spent by combining the partial signatures of k participants.
+func TestMultiSigScriptKey(t *testing.T) {
+	t.Parallel()
+
+	const numKeys = 3
+	privKeys := make([]*btcec.PrivateKey, numKeys)
+	pubKeys := make([]*btcec.PublicKey, numKeys)
+	for idx := range privKeys {
+		privKeys[idx] = test.RandPrivKey()
+		pubKeys[idx] = privKeys[idx].PubKey()
+	}
+
+	tree, err := tapscript.NewMultiSigTree(2, pubKeys)
+	require.NoError(t, err)
+	require.Len(t, tree.Keys, numKeys)
+	require.Equal(
+		t, asset.ScriptKeyScriptPathExternal, tree.ScriptKey().Type,
+	)
+
+	_, err = tapscript.NewMultiSigTree(4, pubKeys)
+	require.ErrorContains(t, err, "threshold")
+	_, err = tapscript.NewMultiSigTree(
+		1, []*btcec.PublicKey{pubKeys[0], pubKeys[0]},
+	)
+	require.ErrorContains(t, err, "duplicate")
+
+	// Create a full value send of an asset locked to the multisig script
+	// key.
+	ctx := context.Background()
+	gen := asset.RandGenesis(t, asset.Normal)
+	inputAsset := asset.NewAssetNoErr(
+		t, gen, 1000, 0, 0, tree.ScriptKey(), nil,
+	)
+	prevID := asset.PrevID{
+		OutPoint:  test.RandOp(t),
+		ID:        gen.ID(),
+		ScriptKey: asset.ToSerialized(tree.TaprootKey),
+	}
+
+	vPkt := tappsbt.ForInteractiveSend(
+		gen.ID(), 1000, asset.RandScriptKey(t), 0, 0, 0,
+		keychain.KeyDescriptor{PubKey: test.RandPubKey(t)}, asset.V0,
+		&address.RegressionNetTap,
+	)
+	vPkt.Inputs[0].PrevID = prevID
+	vPkt.SetInputAsset(0, inputAsset)
+	require.NoError(t, tapsend.PrepareOutputAssets(ctx, vPkt))
+
+	// A key that isn't part of the multisig can't be used to sign.
+	err = tapsend.PrepareMultiSigInput(
+		vPkt.Copy(), 0, tree, keychain.KeyDescriptor{
+			PubKey: test.RandPubKey(t),
+		},
+	)
+	require.ErrorContains(t, err, "not a multisig participant")
+
+	// The first and last participant sign their own copy of the packet.
+	partialSign := func(signerIdx int) ([32]byte, []byte) {
+		pkt := vPkt.Copy()
+		err := tapsend.PrepareMultiSigInput(
+			pkt, 0, tree, keychain.KeyDescriptor{
+				PubKey: pubKeys[signerIdx],
+			},
+		)
+		require.NoError(t, err)
+
+		err = tapsend.SignVirtualTransaction(
+			pkt, tapscript.NewMockSigner(privKeys[signerIdx]),
+			noOpWitnessValidator{},
+		)
+		require.NoError(t, err)
+
+		sig, err := tapsend.MultiSigPartialSig(pkt, 0)
+		require.NoError(t, err)
+
+		var xOnlyKey [32]byte
+		copy(xOnlyKey[:], schnorr.SerializePubKey(pubKeys[signerIdx]))
+
+		return xOnlyKey, sig
+	}
+
+	sigs := make(map[[32]byte][]byte)
+	key0, sig0 := partialSign(0)
+	sigs[key0] = sig0
+
+	// A single signature isn't enough to create the witness.
+	_, err = tree.Witness(sigs)
+	require.ErrorContains(t, err, "expected 2 multisig signatures")
+
+	key2, sig2 := partialSign(2)
+	sigs[key2] = sig2
+
+	witness, err := tree.Witness(sigs)
+	require.NoError(t, err)
+	require.Len(t, witness, numKeys+2)
+	require.NoError(t, tapsend.ApplyMultiSigWitness(vPkt, 0, witness))
+
+	prevAssets := commitment.InputSet{
+		prevID: inputAsset,
+	}
+	validator := &tap.WitnessValidatorV0{}
+	err = validator.ValidateWitnesses(
+		vPkt.Outputs[0].Asset, nil, prevAssets,
+	)
+	require.NoError(t, err)
+
+	// Swapping the signatures of the two participants must result in an
+	// invalid witness.
+	sigs[key0], sigs[key2] = sig2, sig0
+	witness, err = tree.Witness(sigs)
+	require.NoError(t, err)
+	require.NoError(t, tapsend.ApplyMultiSigWitness(vPkt, 0, witness))
+
+	err = validator.ValidateWitnesses(
+		vPkt.Outputs[0].Asset, nil, prevAssets,
+	)
+	require.Error(t, err)
+}
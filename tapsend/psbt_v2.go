@@ -0,0 +1,640 @@
+package tapsend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+)
+
+// The following key types are defined by BIP-0370 for version 2 PSBTs. They
+// are not known to the psbt package, which only supports version 0 packets.
+const (
+	// psbtGlobalUnsignedTx is the global key type of the unsigned
+	// transaction, which is only allowed in version 0 packets.
+	psbtGlobalUnsignedTx byte = 0x00
+
+	// psbtGlobalTxVersion is the global key type of the transaction
+	// version.
+	psbtGlobalTxVersion byte = 0x02
+
+	// psbtGlobalFallbackLocktime is the global key type of the lock time
+	// used if no input requires a specific lock time.
+	psbtGlobalFallbackLocktime byte = 0x03
+
+	// psbtGlobalInputCount is the global key type of the number of inputs.
+	psbtGlobalInputCount byte = 0x04
+
+	// psbtGlobalOutputCount is the global key type of the number of
+	// outputs.
+	psbtGlobalOutputCount byte = 0x05
+
+	// psbtGlobalTxModifiable is the global key type of the flags that
+	// signal which parts of the transaction can still be modified.
+	psbtGlobalTxModifiable byte = 0x06
+
+	// psbtGlobalVersion is the global key type of the PSBT version.
+	psbtGlobalVersion byte = 0xfb
+
+	// psbtInPreviousTxid is the input key type of the previous
+	// transaction ID.
+	psbtInPreviousTxid byte = 0x0e
+
+	// psbtInOutputIndex is the input key type of the previous output
+	// index.
+	psbtInOutputIndex byte = 0x0f
+
+	// psbtInSequence is the input key type of the input sequence.
+	psbtInSequence byte = 0x10
+
+	// psbtInRequiredTimeLocktime is the input key type of the minimum
+	// time based lock time the input requires.
+	psbtInRequiredTimeLocktime byte = 0x11
+
+	// psbtInRequiredHeightLocktime is the input key type of the minimum
+	// height based lock time the input requires.
+	psbtInRequiredHeightLocktime byte = 0x12
+
+	// psbtOutAmount is the output key type of the output amount.
+	psbtOutAmount byte = 0x03
+
+	// psbtOutScript is the output key type of the output script.
+	psbtOutScript byte = 0x04
+
+	// psbtMagicLength is the length of the PSBT magic bytes.
+	psbtMagicLength = 5
+
+	// lockTimeThreshold is the lock time value below which a lock time is
+	// interpreted as a block height instead of a timestamp.
+	lockTimeThreshold = 500_000_000
+)
+
+var (
+	// psbtMagic is the magic prefix of a serialized PSBT.
+	psbtMagic = [psbtMagicLength]byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+	// ErrInvalidPsbtV2 is returned when a version 2 PSBT can't be decoded
+	// or converted.
+	ErrInvalidPsbtV2 = errors.New("invalid version 2 PSBT")
+)
+
+// psbtKeyValue is a single raw key-value pair of a PSBT map.
+type psbtKeyValue struct {
+	key   []byte
+	value []byte
+}
+
+// psbtMap is a raw PSBT map, which is a list of key-value pairs.
+type psbtMap []psbtKeyValue
+
+// get returns the value of the first pair with the given single byte key.
+func (m psbtMap) get(keyType byte) ([]byte, bool) {
+	for _, kv := range m {
+		if len(kv.key) == 1 && kv.key[0] == keyType {
+			return kv.value, true
+		}
+	}
+
+	return nil, false
+}
+
+// without returns a copy of the map without any pairs of the given single
+// byte keys.
+func (m psbtMap) without(keyTypes ...byte) psbtMap {
+	result := make(psbtMap, 0, len(m))
+	for _, kv := range m {
+		if len(kv.key) == 1 && slices.Contains(keyTypes, kv.key[0]) {
+			continue
+		}
+		result = append(result, kv)
+	}
+
+	return result
+}
+
+// rawPsbt is a PSBT decoded into its raw maps, without interpreting any of the
+// key-value pairs.
+type rawPsbt struct {
+	global  psbtMap
+	inputs  []psbtMap
+	outputs []psbtMap
+}
+
+// readPsbtMap reads a single PSBT map up to and including its separator.
+func readPsbtMap(r *bytes.Reader) (psbtMap, error) {
+	var m psbtMap
+	for {
+		key, err := readPsbtBytes(r)
+		if err != nil {
+			return nil, err
+		}
+
+		// A zero length key is the separator at the end of the map.
+		if len(key) == 0 {
+			return m, nil
+		}
+
+		value, err := readPsbtBytes(r)
+		if err != nil {
+			return nil, err
+		}
+
+		m = append(m, psbtKeyValue{key: key, value: value})
+	}
+}
+
+// readPsbtBytes reads a compact size length prefixed byte slice.
+func readPsbtBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > uint64(r.Len()) {
+		return nil, fmt.Errorf("%w: length %d exceeds remaining %d "+
+			"bytes", ErrInvalidPsbtV2, length, r.Len())
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// writePsbtMap writes a PSBT map followed by its separator.
+func writePsbtMap(w *bytes.Buffer, m psbtMap) error {
+	for _, kv := range m {
+		if err := wire.WriteVarBytes(w, 0, kv.key); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, kv.value); err != nil {
+			return err
+		}
+	}
+
+	return w.WriteByte(0x00)
+}
+
+// serialize encodes the raw PSBT.
+func (p *rawPsbt) serialize() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(psbtMagic[:])
+
+	if err := writePsbtMap(&buf, p.global); err != nil {
+		return nil, err
+	}
+	for _, m := range p.inputs {
+		if err := writePsbtMap(&buf, m); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range p.outputs {
+		if err := writePsbtMap(&buf, m); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// psbtVersion returns the version of the PSBT with the given global map.
+func psbtVersion(global psbtMap) (uint32, error) {
+	value, ok := global.get(psbtGlobalVersion)
+	if !ok {
+		return 0, nil
+	}
+
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid PSBT version length %d",
+			len(value))
+	}
+
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// readPsbtGlobal reads the magic bytes and the global map of a PSBT.
+func readPsbtGlobal(r *bytes.Reader) (psbtMap, error) {
+	var magic [psbtMagicLength]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != psbtMagic {
+		return nil, psbt.ErrInvalidMagicBytes
+	}
+
+	return readPsbtMap(r)
+}
+
+// IsPsbtV2 returns true if the given serialized PSBT is a version 2 PSBT as
+// defined in BIP-0370.
+func IsPsbtV2(packet []byte) bool {
+	global, err := readPsbtGlobal(bytes.NewReader(packet))
+	if err != nil {
+		return false
+	}
+
+	version, err := psbtVersion(global)
+
+	return err == nil && version == 2
+}
+
+// DecodeAnchorPsbt decodes a serialized anchor transaction PSBT that can
+// either be a version 0 or a version 2 PSBT. Version 2 packets are converted
+// to version 0, which is the version used internally and by the wallet.
+func DecodeAnchorPsbt(packet []byte) (*psbt.Packet, error) {
+	if IsPsbtV2(packet) {
+		return ConvertFromPsbtV2(packet)
+	}
+
+	return psbt.NewFromRawBytes(bytes.NewReader(packet), false)
+}
+
+// ConvertToPsbtV2 converts the given version 0 PSBT to a serialized version 2
+// PSBT as defined in BIP-0370. The unsigned transaction is split into the
+// global, per input and per output fields of version 2, all other fields are
+// kept as they are.
+func ConvertToPsbtV2(pkt *psbt.Packet) ([]byte, error) {
+	if pkt == nil || pkt.UnsignedTx == nil {
+		return nil, fmt.Errorf("packet is missing unsigned transaction")
+	}
+
+	var buf bytes.Buffer
+	if err := pkt.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("unable to serialize packet: %w", err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	global, err := readPsbtGlobal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := pkt.UnsignedTx
+	raw := rawPsbt{
+		inputs:  make([]psbtMap, len(tx.TxIn)),
+		outputs: make([]psbtMap, len(tx.TxOut)),
+	}
+	for idx, txIn := range tx.TxIn {
+		m, err := readPsbtMap(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read input %d: %w",
+				idx, err)
+		}
+
+		prevOut := txIn.PreviousOutPoint
+		m = append(m, psbtKeyValue{
+			key:   []byte{psbtInPreviousTxid},
+			value: prevOut.Hash[:],
+		}, uint32Pair(psbtInOutputIndex, prevOut.Index))
+		if txIn.Sequence != wire.MaxTxInSequenceNum {
+			m = append(m, uint32Pair(psbtInSequence, txIn.Sequence))
+		}
+		raw.inputs[idx] = m
+	}
+	for idx, txOut := range tx.TxOut {
+		m, err := readPsbtMap(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read output %d: %w",
+				idx, err)
+		}
+
+		var amount [8]byte
+		binary.LittleEndian.PutUint64(amount[:], uint64(txOut.Value))
+		m = append(m, psbtKeyValue{
+			key:   []byte{psbtOutAmount},
+			value: amount[:],
+		}, psbtKeyValue{
+			key:   []byte{psbtOutScript},
+			value: txOut.PkScript,
+		})
+		raw.outputs[idx] = m
+	}
+
+	raw.global = append(
+		global.without(psbtGlobalUnsignedTx, psbtGlobalVersion),
+		uint32Pair(psbtGlobalTxVersion, uint32(tx.Version)),
+		uint32Pair(psbtGlobalFallbackLocktime, tx.LockTime),
+		varIntPair(psbtGlobalInputCount, uint64(len(tx.TxIn))),
+		varIntPair(psbtGlobalOutputCount, uint64(len(tx.TxOut))),
+		uint32Pair(psbtGlobalVersion, 2),
+	)
+
+	return raw.serialize()
+}
+
+// ConvertFromPsbtV2 converts the given serialized version 2 PSBT as defined in
+// BIP-0370 to a version 0 PSBT.
+func ConvertFromPsbtV2(packet []byte) (*psbt.Packet, error) {
+	r := bytes.NewReader(packet)
+	global, err := readPsbtGlobal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := psbtVersion(global)
+	if err != nil {
+		return nil, err
+	}
+	if version != 2 {
+		return nil, fmt.Errorf("%w: unexpected version %d",
+			ErrInvalidPsbtV2, version)
+	}
+	if _, ok := global.get(psbtGlobalUnsignedTx); ok {
+		return nil, fmt.Errorf("%w: unsigned transaction not allowed",
+			ErrInvalidPsbtV2)
+	}
+
+	txVersion, err := requiredUint32(global, psbtGlobalTxVersion)
+	if err != nil {
+		return nil, err
+	}
+	numInputs, err := requiredVarInt(global, psbtGlobalInputCount)
+	if err != nil {
+		return nil, err
+	}
+	numOutputs, err := requiredVarInt(global, psbtGlobalOutputCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every input and output map consists of at least one separator byte,
+	// which limits the number of maps we need to allocate.
+	if numInputs+numOutputs > uint64(r.Len()) {
+		return nil, fmt.Errorf("%w: %d inputs and %d outputs exceed "+
+			"packet size", ErrInvalidPsbtV2, numInputs, numOutputs)
+	}
+
+	tx := wire.NewMsgTx(int32(txVersion))
+	raw := rawPsbt{
+		global: global.without(
+			psbtGlobalTxVersion, psbtGlobalFallbackLocktime,
+			psbtGlobalInputCount, psbtGlobalOutputCount,
+			psbtGlobalTxModifiable, psbtGlobalVersion,
+		),
+		inputs:  make([]psbtMap, numInputs),
+		outputs: make([]psbtMap, numOutputs),
+	}
+
+	var locks lockTimeReqs
+	for idx := range raw.inputs {
+		m, err := readPsbtMap(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read input %d: %w",
+				idx, err)
+		}
+
+		txIn, err := parseInputV2(m, &locks)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", idx, err)
+		}
+		tx.AddTxIn(txIn)
+
+		raw.inputs[idx] = m.without(
+			psbtInPreviousTxid, psbtInOutputIndex, psbtInSequence,
+			psbtInRequiredTimeLocktime,
+			psbtInRequiredHeightLocktime,
+		)
+	}
+
+	for idx := range raw.outputs {
+		m, err := readPsbtMap(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read output %d: %w",
+				idx, err)
+		}
+
+		txOut, err := parseOutputV2(m)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %w", idx, err)
+		}
+		tx.AddTxOut(txOut)
+
+		raw.outputs[idx] = m.without(psbtOutAmount, psbtOutScript)
+	}
+
+	tx.LockTime, err = locks.lockTime(global)
+	if err != nil {
+		return nil, err
+	}
+
+	var txBuf bytes.Buffer
+	if err := tx.Serialize(&txBuf); err != nil {
+		return nil, err
+	}
+	raw.global = append(psbtMap{{
+		key:   []byte{psbtGlobalUnsignedTx},
+		value: txBuf.Bytes(),
+	}}, raw.global...)
+
+	v0Packet, err := raw.serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	return psbt.NewFromRawBytes(bytes.NewReader(v0Packet), false)
+}
+
+// lockTimeReqs tracks the lock time requirements of the inputs of a version 2
+// PSBT.
+type lockTimeReqs struct {
+	// anyLock is true if at least one input requires a lock time.
+	anyLock bool
+
+	// noHeight is true if at least one input requires a lock time but
+	// doesn't support a height based one.
+	noHeight bool
+
+	// noTime is true if at least one input requires a lock time but
+	// doesn't support a time based one.
+	noTime bool
+
+	maxHeight uint32
+	maxTime   uint32
+}
+
+// add adds the lock time requirements of a single input.
+func (l *lockTimeReqs) add(timeLock, heightLock fn.Option[uint32]) {
+	if timeLock.IsNone() && heightLock.IsNone() {
+		return
+	}
+
+	l.anyLock = true
+	heightLock.WhenSome(func(h uint32) {
+		l.maxHeight = max(l.maxHeight, h)
+	})
+	timeLock.WhenSome(func(t uint32) {
+		l.maxTime = max(l.maxTime, t)
+	})
+	l.noHeight = l.noHeight || heightLock.IsNone()
+	l.noTime = l.noTime || timeLock.IsNone()
+}
+
+// lockTime determines the lock time of the transaction as defined in
+// BIP-0370. If no input requires a lock time, the fallback lock time is used.
+// Otherwise, the lock type supported by all inputs that require one is used,
+// preferring height based lock times.
+func (l *lockTimeReqs) lockTime(global psbtMap) (uint32, error) {
+	switch {
+	case !l.anyLock:
+		if _, ok := global.get(psbtGlobalFallbackLocktime); !ok {
+			return 0, nil
+		}
+
+		return requiredUint32(global, psbtGlobalFallbackLocktime)
+
+	case !l.noHeight:
+		return l.maxHeight, nil
+
+	case !l.noTime:
+		return l.maxTime, nil
+
+	default:
+		return 0, fmt.Errorf("%w: no lock time type supported by all "+
+			"inputs", ErrInvalidPsbtV2)
+	}
+}
+
+// parseInputV2 parses the transaction input from the given version 2 input map
+// and adds its lock time requirements.
+func parseInputV2(m psbtMap, locks *lockTimeReqs) (*wire.TxIn, error) {
+	txid, ok := m.get(psbtInPreviousTxid)
+	if !ok || len(txid) != chainhash.HashSize {
+		return nil, fmt.Errorf("%w: invalid or missing previous txid",
+			ErrInvalidPsbtV2)
+	}
+	hash, err := chainhash.NewHash(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := requiredUint32(m, psbtInOutputIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	txIn := wire.NewTxIn(wire.NewOutPoint(hash, index), nil, nil)
+	if _, ok := m.get(psbtInSequence); ok {
+		txIn.Sequence, err = requiredUint32(m, psbtInSequence)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timeLock, err := optionalUint32(m, psbtInRequiredTimeLocktime)
+	if err != nil {
+		return nil, err
+	}
+	heightLock, err := optionalUint32(m, psbtInRequiredHeightLocktime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Time based lock times must be above and height based lock times
+	// below the threshold, otherwise they would be interpreted as the
+	// other type.
+	if timeLock.UnwrapOr(lockTimeThreshold) < lockTimeThreshold {
+		return nil, fmt.Errorf("%w: invalid required time lock time",
+			ErrInvalidPsbtV2)
+	}
+	if heightLock.UnwrapOr(0) >= lockTimeThreshold {
+		return nil, fmt.Errorf("%w: invalid required height lock time",
+			ErrInvalidPsbtV2)
+	}
+	locks.add(timeLock, heightLock)
+
+	return txIn, nil
+}
+
+// parseOutputV2 parses the transaction output from the given version 2 output
+// map.
+func parseOutputV2(m psbtMap) (*wire.TxOut, error) {
+	amount, ok := m.get(psbtOutAmount)
+	if !ok || len(amount) != 8 {
+		return nil, fmt.Errorf("%w: invalid or missing output amount",
+			ErrInvalidPsbtV2)
+	}
+
+	value := binary.LittleEndian.Uint64(amount)
+	if value > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: invalid output amount %d",
+			ErrInvalidPsbtV2, value)
+	}
+
+	pkScript, ok := m.get(psbtOutScript)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing output script",
+			ErrInvalidPsbtV2)
+	}
+
+	return wire.NewTxOut(int64(value), pkScript), nil
+}
+
+// optionalUint32 returns the little endian uint32 value of the given key type,
+// if present.
+func optionalUint32(m psbtMap, keyType byte) (fn.Option[uint32], error) {
+	if _, ok := m.get(keyType); !ok {
+		return fn.None[uint32](), nil
+	}
+
+	value, err := requiredUint32(m, keyType)
+	if err != nil {
+		return fn.None[uint32](), err
+	}
+
+	return fn.Some(value), nil
+}
+
+// requiredUint32 returns the little endian uint32 value of the given key type.
+func requiredUint32(m psbtMap, keyType byte) (uint32, error) {
+	value, ok := m.get(keyType)
+	if !ok || len(value) != 4 {
+		return 0, fmt.Errorf("%w: invalid or missing field 0x%02x",
+			ErrInvalidPsbtV2, keyType)
+	}
+
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// requiredVarInt returns the compact size value of the given key type.
+func requiredVarInt(m psbtMap, keyType byte) (uint64, error) {
+	value, ok := m.get(keyType)
+	if !ok {
+		return 0, fmt.Errorf("%w: missing field 0x%02x",
+			ErrInvalidPsbtV2, keyType)
+	}
+
+	r := bytes.NewReader(value)
+	result, err := wire.ReadVarInt(r, 0)
+	if err != nil || r.Len() != 0 {
+		return 0, fmt.Errorf("%w: invalid field 0x%02x",
+			ErrInvalidPsbtV2, keyType)
+	}
+
+	return result, nil
+}
+
+// uint32Pair creates a key-value pair with a little endian uint32 value.
+func uint32Pair(keyType byte, value uint32) psbtKeyValue {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], value)
+
+	return psbtKeyValue{key: []byte{keyType}, value: b[:]}
+}
+
+// varIntPair creates a key-value pair with a compact size value.
+func varIntPair(keyType byte, value uint64) psbtKeyValue {
+	var buf bytes.Buffer
+	_ = wire.WriteVarInt(&buf, 0, value)
+
+	return psbtKeyValue{key: []byte{keyType}, value: buf.Bytes()}
+}
@@ -0,0 +1,170 @@
+package tapsend
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// randV0Packet creates a version 0 packet with two inputs and two outputs.
+func randV0Packet(t *testing.T) *psbt.Packet {
+	t.Helper()
+
+	prevOuts := []*wire.OutPoint{
+		wire.NewOutPoint(&chainhash.Hash{1, 2, 3}, 1),
+		wire.NewOutPoint(&chainhash.Hash{4, 5, 6}, 7),
+	}
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(1_000, test.RandBytes(34)),
+		wire.NewTxOut(2_000, test.RandBytes(22)),
+	}
+	pkt, err := psbt.New(
+		prevOuts, outputs, 2, 800_000, []uint32{
+			wire.MaxTxInSequenceNum, 10,
+		},
+	)
+	require.NoError(t, err)
+
+	pkt.Inputs[0].WitnessUtxo = wire.NewTxOut(5_000, test.RandBytes(34))
+	pkt.Inputs[1].TaprootInternalKey = schnorr.SerializePubKey(
+		test.RandPubKey(t),
+	)
+	pkt.Outputs[0].TaprootInternalKey = schnorr.SerializePubKey(
+		test.RandPubKey(t),
+	)
+
+	return pkt
+}
+
+// TestPsbtV2RoundTrip tests that a version 0 packet can be converted to a
+// version 2 packet and back without losing any information.
+func TestPsbtV2RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	pkt := randV0Packet(t)
+
+	var v0Buf bytes.Buffer
+	require.NoError(t, pkt.Serialize(&v0Buf))
+	require.False(t, IsPsbtV2(v0Buf.Bytes()))
+
+	v2Packet, err := ConvertToPsbtV2(pkt)
+	require.NoError(t, err)
+	require.True(t, IsPsbtV2(v2Packet))
+
+	// The version 2 packet must not be parseable as a version 0 packet, as
+	// it doesn't contain the unsigned transaction.
+	_, err = psbt.NewFromRawBytes(bytes.NewReader(v2Packet), false)
+	require.Error(t, err)
+
+	decoded, err := DecodeAnchorPsbt(v2Packet)
+	require.NoError(t, err)
+	require.Equal(t, pkt.UnsignedTx.TxHash(), decoded.UnsignedTx.TxHash())
+	require.Equal(t, pkt.Inputs, decoded.Inputs)
+	require.Equal(t, pkt.Outputs, decoded.Outputs)
+
+	var decodedBuf bytes.Buffer
+	require.NoError(t, decoded.Serialize(&decodedBuf))
+	require.Equal(t, v0Buf.Bytes(), decodedBuf.Bytes())
+
+	// Version 0 packets are decoded as they are.
+	decoded, err = DecodeAnchorPsbt(v0Buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, pkt.UnsignedTx.TxHash(), decoded.UnsignedTx.TxHash())
+}
+
+// TestPsbtV2LockTime tests that the lock time of a converted version 2 packet
+// is determined from the required lock times of its inputs.
+func TestPsbtV2LockTime(t *testing.T) {
+	t.Parallel()
+
+	v2Packet, err := ConvertToPsbtV2(randV0Packet(t))
+	require.NoError(t, err)
+
+	// withLocks returns the version 2 packet with the given required lock
+	// times added to its inputs.
+	withLocks := func(locks ...[]psbtKeyValue) []byte {
+		r := bytes.NewReader(v2Packet)
+		global, err := readPsbtGlobal(r)
+		require.NoError(t, err)
+
+		raw := rawPsbt{global: global}
+		for idx := 0; idx < 2; idx++ {
+			m, err := readPsbtMap(r)
+			require.NoError(t, err)
+			m = append(m, locks[idx]...)
+			raw.inputs = append(raw.inputs, m)
+		}
+		for idx := 0; idx < 2; idx++ {
+			m, err := readPsbtMap(r)
+			require.NoError(t, err)
+			raw.outputs = append(raw.outputs, m)
+		}
+
+		packet, err := raw.serialize()
+		require.NoError(t, err)
+
+		return packet
+	}
+
+	height := func(h uint32) psbtKeyValue {
+		return uint32Pair(psbtInRequiredHeightLocktime, h)
+	}
+	timestamp := func(t uint32) psbtKeyValue {
+		return uint32Pair(psbtInRequiredTimeLocktime, t)
+	}
+
+	testCases := []struct {
+		name     string
+		locks    [][]psbtKeyValue
+		lockTime uint32
+		err      bool
+	}{{
+		name:     "fallback lock time",
+		locks:    [][]psbtKeyValue{nil, nil},
+		lockTime: 800_000,
+	}, {
+		name: "max height",
+		locks: [][]psbtKeyValue{
+			{height(100)}, {height(200), timestamp(600_000_000)},
+		},
+		lockTime: 200,
+	}, {
+		name: "time supported by all inputs",
+		locks: [][]psbtKeyValue{
+			{timestamp(600_000_001)},
+			{height(200), timestamp(600_000_000)},
+		},
+		lockTime: 600_000_001,
+	}, {
+		name: "no common lock type",
+		locks: [][]psbtKeyValue{
+			{timestamp(600_000_000)}, {height(200)},
+		},
+		err: true,
+	}, {
+		name: "invalid height",
+		locks: [][]psbtKeyValue{
+			{height(600_000_000)}, nil,
+		},
+		err: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pkt, err := ConvertFromPsbtV2(withLocks(tc.locks...))
+			if tc.err {
+				require.ErrorIs(t, err, ErrInvalidPsbtV2)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.lockTime, pkt.UnsignedTx.LockTime)
+		})
+	}
+}
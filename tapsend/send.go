@@ -1165,8 +1165,10 @@ func CreateAnchorTx(vPackets []*tappsbt.VPacket) (*psbt.Packet, error) {
 // necessary inputs and outputs to anchor the virtual packets, but without any
 // signatures. The main difference to CreateAnchorTx is that this function
 // populates the inputs with the witness UTXO and derivation path information.
-func PrepareAnchoringTemplate(
-	vPackets []*tappsbt.VPacket) (*psbt.Packet, error) {
+// Any extra plain BTC outputs (for example service fee outputs) are appended
+// after the asset anchor outputs.
+func PrepareAnchoringTemplate(vPackets []*tappsbt.VPacket,
+	extraOutputs ...*ExtraAnchorOutput) (*psbt.Packet, error) {
 
 	err := ValidateVPacketVersions(vPackets)
 	if err != nil {
@@ -1178,6 +1180,11 @@ func PrepareAnchoringTemplate(
 		return nil, err
 	}
 
+	err = addExtraAnchorOutputs(btcPacket, extraOutputs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Populate input information now. We add the witness UTXO and
 	// derivation path information for each asset input. Since the virtual
 	// transactions might refer to the same BTC level input, we need to make
@@ -0,0 +1,477 @@
+package tapsend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+)
+
+var (
+	// ErrSponsorModifiedTemplate is returned when a fee sponsor modified
+	// the inputs or outputs of the anchor transaction template it was
+	// asked to pay the fees for.
+	ErrSponsorModifiedTemplate = errors.New("fee sponsor modified anchor " +
+		"transaction template")
+)
+
+// SponsorFeeInput is a BTC input that is contributed by a fee sponsor to pay
+// the chain fees of an anchor transaction on behalf of the sender of the
+// assets.
+type SponsorFeeInput struct {
+	// OutPoint is the outpoint of the sponsor's UTXO.
+	OutPoint wire.OutPoint
+
+	// Sequence is the sequence number of the input.
+	Sequence uint32
+
+	// WitnessUtxo is the output that is being spent. It is required so
+	// the fee of the sponsored transaction can be validated and signed
+	// for.
+	WitnessUtxo *wire.TxOut
+}
+
+// AddSponsorFees creates a copy of the given anchor transaction template and
+// adds the fee inputs and the optional change output of a fee sponsor to it.
+// The inputs and outputs of the template remain unchanged at their original
+// indexes, so the resulting packet passes ValidateSponsoredAnchor. This is
+// meant to be called by the fee sponsor, who then signs their own inputs only.
+func AddSponsorFees(template *psbt.Packet, inputs []*SponsorFeeInput,
+	change *ExtraAnchorOutput) (*psbt.Packet, error) {
+
+	if template == nil || template.UnsignedTx == nil {
+		return nil, fmt.Errorf("anchor template is missing")
+	}
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("at least one sponsor fee input is " +
+			"required")
+	}
+
+	sponsored, err := copyPacket(template)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, in := range inputs {
+		if in.WitnessUtxo == nil {
+			return nil, fmt.Errorf("sponsor fee input %d is "+
+				"missing witness UTXO", idx)
+		}
+
+		sponsored.UnsignedTx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: in.OutPoint,
+			Sequence:         in.Sequence,
+		})
+		sponsored.Inputs = append(sponsored.Inputs, psbt.PInput{
+			WitnessUtxo: &wire.TxOut{
+				Value:    in.WitnessUtxo.Value,
+				PkScript: fn.CopySlice(in.WitnessUtxo.PkScript),
+			},
+		})
+	}
+
+	if change != nil {
+		err := addExtraAnchorOutputs(
+			sponsored, []*ExtraAnchorOutput{change},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sponsor change "+
+				"output: %w", err)
+		}
+	}
+
+	if _, err := ValidateSponsoredAnchor(
+		template, sponsored, nil,
+	); err != nil {
+		return nil, err
+	}
+
+	return sponsored, nil
+}
+
+// ValidateSponsoredAnchor makes sure a fee sponsor only added their own fee
+// inputs and change output to the given anchor transaction template, without
+// altering any of its inputs or outputs. In particular, the asset carrying
+// outputs of the given virtual packets must be unchanged. All inputs must be
+// signed with the default or SIGHASH_ALL sighash type, so the transaction
+// can't be modified after it was signed. This must be called
+// by the sender of the assets before signing the sponsored transaction. The
+// indexes of the sponsor's inputs are returned, so only the sponsor's
+// signatures for those inputs are taken over when combining the signed
+// packets.
+func ValidateSponsoredAnchor(template, sponsored *psbt.Packet,
+	vPackets []*tappsbt.VPacket) ([]int, error) {
+
+	if template == nil || template.UnsignedTx == nil ||
+		sponsored == nil || sponsored.UnsignedTx == nil {
+
+		return nil, fmt.Errorf("anchor packet is missing")
+	}
+
+	var (
+		templateTx  = template.UnsignedTx
+		sponsoredTx = sponsored.UnsignedTx
+		numInputs   = len(templateTx.TxIn)
+		numOutputs  = len(templateTx.TxOut)
+	)
+
+	// The asset carrying outputs must be part of the template, otherwise
+	// we can't make sure they weren't modified.
+	for _, vPkt := range vPackets {
+		for _, vOut := range vPkt.Outputs {
+			if vOut.AnchorOutputIndex >= uint32(numOutputs) {
+				return nil, fmt.Errorf("%w: anchor output "+
+					"index %d is invalid",
+					ErrInvalidOutputIndexes,
+					vOut.AnchorOutputIndex)
+			}
+		}
+	}
+
+	switch {
+	case len(template.Inputs) != numInputs ||
+		len(template.Outputs) != numOutputs ||
+		len(sponsored.Inputs) != len(sponsoredTx.TxIn) ||
+		len(sponsored.Outputs) != len(sponsoredTx.TxOut):
+
+		return nil, fmt.Errorf("anchor packet is malformed")
+
+	case sponsoredTx.Version != templateTx.Version:
+		return nil, fmt.Errorf("%w: version changed",
+			ErrSponsorModifiedTemplate)
+
+	case sponsoredTx.LockTime != templateTx.LockTime:
+		return nil, fmt.Errorf("%w: lock time changed",
+			ErrSponsorModifiedTemplate)
+
+	case len(sponsoredTx.TxIn) <= numInputs:
+		return nil, fmt.Errorf("%w: no sponsor inputs added",
+			ErrSponsorModifiedTemplate)
+
+	case len(sponsoredTx.TxOut) < numOutputs:
+		return nil, fmt.Errorf("%w: outputs removed",
+			ErrSponsorModifiedTemplate)
+	}
+
+	// All inputs of the template must be spent at the same index and
+	// with the same sequence. None of the PSBT fields of those inputs
+	// may be changed either, as the sender signs them based on these
+	// fields.
+	for idx, txIn := range templateTx.TxIn {
+		sponsoredIn := sponsoredTx.TxIn[idx]
+		if sponsoredIn.PreviousOutPoint != txIn.PreviousOutPoint ||
+			sponsoredIn.Sequence != txIn.Sequence {
+
+			return nil, fmt.Errorf("%w: input %d changed",
+				ErrSponsorModifiedTemplate, idx)
+		}
+
+		equal, err := pInputsEqual(
+			&sponsored.Inputs[idx], &template.Inputs[idx],
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			return nil, fmt.Errorf("%w: input %d changed",
+				ErrSponsorModifiedTemplate, idx)
+		}
+	}
+
+	// All outputs of the template, which include the asset carrying
+	// outputs, must be unchanged. This includes their PSBT fields, as
+	// those are used to identify and later spend the outputs.
+	for idx, txOut := range templateTx.TxOut {
+		if !txOutsEqual(sponsoredTx.TxOut[idx], txOut) {
+			return nil, fmt.Errorf("%w: output %d changed",
+				ErrSponsorModifiedTemplate, idx)
+		}
+
+		equal, err := pOutputsEqual(
+			&sponsored.Outputs[idx], &template.Outputs[idx],
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			return nil, fmt.Errorf("%w: output %d changed",
+				ErrSponsorModifiedTemplate, idx)
+		}
+	}
+
+	// The sponsor's inputs must be new and provide the output they spend,
+	// so we can calculate the fee.
+	var (
+		sponsorInputs []int
+		prevOuts      = fn.NewSet[wire.OutPoint]()
+		totalIn       int64
+	)
+	for idx, txIn := range sponsoredTx.TxIn {
+		if prevOuts.Contains(txIn.PreviousOutPoint) {
+			return nil, fmt.Errorf("%w: duplicate input %v",
+				ErrSponsorModifiedTemplate,
+				txIn.PreviousOutPoint)
+		}
+		prevOuts.Add(txIn.PreviousOutPoint)
+
+		// Signatures that don't commit to all inputs and outputs
+		// would allow the transaction to be modified after signing.
+		err := checkSigHashType(&sponsored.Inputs[idx])
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", idx, err)
+		}
+
+		witnessUtxo := sponsored.Inputs[idx].WitnessUtxo
+		if witnessUtxo == nil {
+			return nil, fmt.Errorf("input %d is missing witness "+
+				"UTXO", idx)
+		}
+		totalIn += witnessUtxo.Value
+
+		if idx >= numInputs {
+			sponsorInputs = append(sponsorInputs, idx)
+		}
+	}
+
+	// Any added output is the sponsor's change. P2TR change outputs need
+	// an internal key, as every P2TR output of an anchor transaction must
+	// be provably free of asset commitments.
+	var totalOut int64
+	for idx, txOut := range sponsoredTx.TxOut {
+		totalOut += txOut.Value
+
+		if idx < numOutputs {
+			continue
+		}
+
+		change := &ExtraAnchorOutput{
+			TxOut: txOut,
+		}
+		internalKey := sponsored.Outputs[idx].TaprootInternalKey
+		if len(internalKey) > 0 {
+			key, err := schnorr.ParsePubKey(internalKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid internal key "+
+					"of sponsor change output %d: %w", idx,
+					err)
+			}
+			change.InternalKey = key
+		}
+
+		if err := change.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid sponsor change output "+
+				"%d: %w", idx, err)
+		}
+	}
+
+	if totalIn <= totalOut {
+		return nil, fmt.Errorf("sponsored anchor transaction doesn't "+
+			"pay a fee (inputs=%d, outputs=%d)", totalIn, totalOut)
+	}
+
+	return sponsorInputs, nil
+}
+
+// CombineSponsorSignatures takes over the signatures of the fee sponsor's
+// inputs from the packet signed by the sponsor into the packet signed by the
+// sender of the assets. Only the inputs with the given sponsor input indexes,
+// as returned by ValidateSponsoredAnchor, are taken over, so the sponsor can't
+// interfere with the signatures of the asset carrying inputs.
+func CombineSponsorSignatures(senderSigned, sponsorSigned *psbt.Packet,
+	sponsorInputs []int) error {
+
+	if senderSigned == nil || senderSigned.UnsignedTx == nil ||
+		sponsorSigned == nil || sponsorSigned.UnsignedTx == nil {
+
+		return fmt.Errorf("anchor packet is missing")
+	}
+
+	if senderSigned.UnsignedTx.TxHash() !=
+		sponsorSigned.UnsignedTx.TxHash() {
+
+		return fmt.Errorf("%w: sponsor signed a different transaction",
+			ErrSponsorModifiedTemplate)
+	}
+
+	for _, idx := range sponsorInputs {
+		if idx < 0 || idx >= len(sponsorSigned.Inputs) {
+			return fmt.Errorf("invalid sponsor input index %d", idx)
+		}
+
+		pIn := sponsorSigned.Inputs[idx]
+		if len(pIn.FinalScriptWitness) == 0 &&
+			len(pIn.FinalScriptSig) == 0 &&
+			len(pIn.TaprootKeySpendSig) == 0 &&
+			len(pIn.TaprootScriptSpendSig) == 0 &&
+			len(pIn.PartialSigs) == 0 {
+
+			return fmt.Errorf("sponsor input %d is not signed", idx)
+		}
+
+		if err := checkSigHashType(&pIn); err != nil {
+			return fmt.Errorf("sponsor input %d: %w", idx, err)
+		}
+
+		senderSigned.Inputs[idx] = pIn
+	}
+
+	return nil
+}
+
+// copyPacket creates a deep copy of the given PSBT packet.
+func copyPacket(packet *psbt.Packet) (*psbt.Packet, error) {
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("unable to serialize packet: %w", err)
+	}
+
+	packetCopy, err := psbt.NewFromRawBytes(&buf, false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to deserialize packet: %w", err)
+	}
+
+	return packetCopy, nil
+}
+
+// pInputsEqual returns true if the two PSBT inputs serialize to the same
+// bytes.
+func pInputsEqual(a, b *psbt.PInput) (bool, error) {
+	aBytes, err := serializePInput(a)
+	if err != nil {
+		return false, err
+	}
+
+	bBytes, err := serializePInput(b)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(aBytes, bBytes), nil
+}
+
+// serializePInput serializes the given PSBT input by wrapping it in a packet
+// that only contains that input. The psbt package doesn't expose the
+// serialization of single inputs.
+func serializePInput(pIn *psbt.PInput) ([]byte, error) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create packet: %w", err)
+	}
+	packet.Inputs[0] = *pIn
+
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("unable to serialize input: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// pOutputsEqual returns true if the two PSBT outputs serialize to the same
+// bytes.
+func pOutputsEqual(a, b *psbt.POutput) (bool, error) {
+	aBytes, err := serializePOutput(a)
+	if err != nil {
+		return false, err
+	}
+
+	bBytes, err := serializePOutput(b)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(aBytes, bBytes), nil
+}
+
+// serializePOutput serializes the given PSBT output by wrapping it in a packet
+// that only contains that output. The psbt package doesn't expose the
+// serialization of single outputs.
+func serializePOutput(pOut *psbt.POutput) ([]byte, error) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxOut(&wire.TxOut{})
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create packet: %w", err)
+	}
+	packet.Outputs[0] = *pOut
+
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("unable to serialize output: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isSigHashAll returns true if the given sighash type commits to all inputs
+// and outputs of a transaction.
+func isSigHashAll(sigHash txscript.SigHashType) bool {
+	return sigHash == txscript.SigHashDefault ||
+		sigHash == txscript.SigHashAll
+}
+
+// checkSigHashType makes sure the sighash type of the given PSBT input and of
+// all its partial signatures is either the default or SIGHASH_ALL.
+func checkSigHashType(pIn *psbt.PInput) error {
+	if !isSigHashAll(pIn.SighashType) {
+		return fmt.Errorf("%w: sighash type %v not allowed",
+			ErrSponsorModifiedTemplate, pIn.SighashType)
+	}
+
+	// A schnorr signature without a sighash byte uses the default
+	// sighash type.
+	keySpendSig := pIn.TaprootKeySpendSig
+	if len(keySpendSig) == schnorr.SignatureSize+1 &&
+		!isSigHashAll(txscript.SigHashType(keySpendSig[64])) {
+
+		return fmt.Errorf("%w: key spend signature with sighash "+
+			"type %v not allowed", ErrSponsorModifiedTemplate,
+			txscript.SigHashType(keySpendSig[64]))
+	}
+
+	for _, sig := range pIn.TaprootScriptSpendSig {
+		if !isSigHashAll(sig.SigHash) {
+			return fmt.Errorf("%w: script spend signature with "+
+				"sighash type %v not allowed",
+				ErrSponsorModifiedTemplate, sig.SigHash)
+		}
+	}
+
+	// ECDSA signatures always have the sighash type appended.
+	for _, sig := range pIn.PartialSigs {
+		if len(sig.Signature) == 0 {
+			continue
+		}
+
+		sigHash := txscript.SigHashType(
+			sig.Signature[len(sig.Signature)-1],
+		)
+		if sigHash != txscript.SigHashAll {
+			return fmt.Errorf("%w: partial signature with sighash "+
+				"type %v not allowed",
+				ErrSponsorModifiedTemplate, sigHash)
+		}
+	}
+
+	return nil
+}
+
+// txOutsEqual returns true if the two outputs are equal.
+func txOutsEqual(a, b *wire.TxOut) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Value == b.Value && bytes.Equal(a.PkScript, b.PkScript)
+}
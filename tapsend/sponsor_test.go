@@ -0,0 +1,234 @@
+package tapsend_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/taproot-assets/internal/test"
+	"github.com/lightninglabs/taproot-assets/tappsbt"
+	"github.com/lightninglabs/taproot-assets/tapsend"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeeSponsorship tests that a fee sponsor can only add their own inputs
+// and change output to an anchor transaction template and that only their
+// signatures are taken over when combining the signed packets.
+func TestFeeSponsorship(t *testing.T) {
+	t.Parallel()
+
+	// The template spends a single asset anchor and creates a single asset
+	// carrying output, without paying any fees.
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: test.RandOp(t),
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxOut(&wire.TxOut{Value: 1000, PkScript: test.RandBytes(34)})
+	template, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+	template.Inputs[0].WitnessUtxo = &wire.TxOut{
+		Value: 1000, PkScript: test.RandBytes(34),
+	}
+
+	vPackets := []*tappsbt.VPacket{{
+		Outputs: []*tappsbt.VOutput{{
+			AnchorOutputIndex: 0,
+		}},
+	}}
+
+	// The sponsor adds a fee input and a P2TR change output.
+	changeKey := test.RandPubKey(t)
+	changeScript, err := txscript.PayToTaprootScript(
+		txscript.ComputeTaprootKeyNoScript(changeKey),
+	)
+	require.NoError(t, err)
+
+	feeInput := &tapsend.SponsorFeeInput{
+		OutPoint: test.RandOp(t),
+		Sequence: wire.MaxTxInSequenceNum,
+		WitnessUtxo: &wire.TxOut{
+			Value: 5000, PkScript: test.RandBytes(34),
+		},
+	}
+	change := &tapsend.ExtraAnchorOutput{
+		TxOut:       &wire.TxOut{Value: 4500, PkScript: changeScript},
+		InternalKey: changeKey,
+	}
+
+	_, err = tapsend.AddSponsorFees(template, nil, change)
+	require.ErrorContains(t, err, "at least one sponsor fee input")
+
+	// A change output that consumes the whole fee input is rejected.
+	_, err = tapsend.AddSponsorFees(
+		template, []*tapsend.SponsorFeeInput{feeInput},
+		&tapsend.ExtraAnchorOutput{
+			TxOut: &wire.TxOut{
+				Value: 5000, PkScript: changeScript,
+			},
+			InternalKey: changeKey,
+		},
+	)
+	require.ErrorContains(t, err, "doesn't pay a fee")
+
+	sponsored, err := tapsend.AddSponsorFees(
+		template, []*tapsend.SponsorFeeInput{feeInput}, change,
+	)
+	require.NoError(t, err)
+	require.Len(t, template.UnsignedTx.TxIn, 1)
+	require.Len(t, sponsored.UnsignedTx.TxIn, 2)
+	require.Len(t, sponsored.UnsignedTx.TxOut, 2)
+
+	sponsorInputs, err := tapsend.ValidateSponsoredAnchor(
+		template, sponsored, vPackets,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, sponsorInputs)
+
+	// The asset carrying outputs must be part of the template.
+	_, err = tapsend.ValidateSponsoredAnchor(
+		template, sponsored, []*tappsbt.VPacket{{
+			Outputs: []*tappsbt.VOutput{{
+				AnchorOutputIndex: 1,
+			}},
+		}},
+	)
+	require.ErrorIs(t, err, tapsend.ErrInvalidOutputIndexes)
+
+	// The sponsor can't modify the asset carrying output, the asset
+	// input or remove their change output's internal key.
+	modify := func(modifyFn func(*psbt.Packet)) error {
+		sponsored, err := tapsend.AddSponsorFees(
+			template, []*tapsend.SponsorFeeInput{feeInput}, change,
+		)
+		require.NoError(t, err)
+
+		modifyFn(sponsored)
+
+		_, err = tapsend.ValidateSponsoredAnchor(
+			template, sponsored, vPackets,
+		)
+		return err
+	}
+
+	err = modify(func(p *psbt.Packet) {
+		p.UnsignedTx.TxOut[0].Value = 900
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "output 0 changed")
+
+	err = modify(func(p *psbt.Packet) {
+		p.UnsignedTx.TxOut[0].PkScript = test.RandBytes(34)
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+
+	err = modify(func(p *psbt.Packet) {
+		p.UnsignedTx.TxIn[0].Sequence = 0
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "input 0 changed")
+
+	err = modify(func(p *psbt.Packet) {
+		p.UnsignedTx.LockTime = 100
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+
+	err = modify(func(p *psbt.Packet) {
+		p.Outputs[1].TaprootInternalKey = nil
+	})
+	require.ErrorContains(t, err, "missing internal key")
+
+	// The PSBT fields of the template's outputs can't be changed either.
+	err = modify(func(p *psbt.Packet) {
+		p.Outputs[0].TaprootInternalKey = schnorr.SerializePubKey(
+			test.RandPubKey(t),
+		)
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "output 0 changed")
+
+	// The sponsor can't change any PSBT field of the template's inputs,
+	// such as the sighash type the sender signs with.
+	err = modify(func(p *psbt.Packet) {
+		p.Inputs[0].SighashType = txscript.SigHashNone
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "input 0 changed")
+
+	err = modify(func(p *psbt.Packet) {
+		p.Inputs[0].TaprootMerkleRoot = test.RandBytes(32)
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "input 0 changed")
+
+	// The sponsor's own inputs must commit to the whole transaction as
+	// well.
+	err = modify(func(p *psbt.Packet) {
+		p.Inputs[1].SighashType = txscript.SigHashSingle |
+			txscript.SigHashAnyOneCanPay
+	})
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "sighash type")
+
+	require.NoError(t, modify(func(p *psbt.Packet) {
+		p.Inputs[1].SighashType = txscript.SigHashAll
+	}))
+
+	// Only the signature of the sponsor's input is taken over, even if the
+	// sponsor's packet contains a witness for the asset input.
+	senderSigned, err := tapsend.AddSponsorFees(
+		template, []*tapsend.SponsorFeeInput{feeInput}, change,
+	)
+	require.NoError(t, err)
+	senderWitness := test.RandBytes(65)
+	senderSigned.Inputs[0].FinalScriptWitness = senderWitness
+
+	sponsorSigned, err := tapsend.AddSponsorFees(
+		template, []*tapsend.SponsorFeeInput{feeInput}, change,
+	)
+	require.NoError(t, err)
+
+	err = tapsend.CombineSponsorSignatures(
+		senderSigned, sponsorSigned, sponsorInputs,
+	)
+	require.ErrorContains(t, err, "input 1 is not signed")
+
+	sponsorWitness := test.RandBytes(65)
+	sponsorSigned.Inputs[0].FinalScriptWitness = test.RandBytes(65)
+	sponsorSigned.Inputs[1].FinalScriptWitness = sponsorWitness
+
+	require.NoError(t, tapsend.CombineSponsorSignatures(
+		senderSigned, sponsorSigned, sponsorInputs,
+	))
+	require.Equal(
+		t, senderWitness, senderSigned.Inputs[0].FinalScriptWitness,
+	)
+	require.Equal(
+		t, sponsorWitness, senderSigned.Inputs[1].FinalScriptWitness,
+	)
+
+	// Signatures that don't commit to all outputs are rejected.
+	sponsorSigned.Inputs[1].FinalScriptWitness = nil
+	sponsorSigned.Inputs[1].TaprootKeySpendSig = append(
+		test.RandBytes(64), byte(txscript.SigHashNone),
+	)
+	err = tapsend.CombineSponsorSignatures(
+		senderSigned, sponsorSigned, sponsorInputs,
+	)
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+	require.ErrorContains(t, err, "key spend signature")
+
+	sponsorSigned.Inputs[1].TaprootKeySpendSig = test.RandBytes(64)
+	require.NoError(t, tapsend.CombineSponsorSignatures(
+		senderSigned, sponsorSigned, sponsorInputs,
+	))
+
+	// Signatures for a different transaction are rejected.
+	sponsorSigned.UnsignedTx.TxOut[1].Value = 4000
+	err = tapsend.CombineSponsorSignatures(
+		senderSigned, sponsorSigned, sponsorInputs,
+	)
+	require.ErrorIs(t, err, tapsend.ErrSponsorModifiedTemplate)
+}
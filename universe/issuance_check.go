@@ -0,0 +1,330 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightninglabs/taproot-assets/asset"
+)
+
+// IssuanceDiscrepancyType is the type of discrepancy found when cross-checking
+// locally observed issuances of an asset group against the issuance data
+// reported by a universe.
+type IssuanceDiscrepancyType uint8
+
+const (
+	// IssuanceUnknown indicates that the universe reports an issuance that
+	// wasn't observed locally. This is the case that should be alerted on,
+	// as it may indicate unexpected inflation of the asset group.
+	IssuanceUnknown IssuanceDiscrepancyType = iota
+
+	// IssuanceMissing indicates that an issuance that was observed locally
+	// isn't known to the universe.
+	IssuanceMissing
+
+	// IssuanceAmountMismatch indicates that the amount issued for an asset
+	// ID differs between the local view and the universe.
+	IssuanceAmountMismatch
+
+	// IssuanceGroupMismatch indicates that the universe returned an
+	// issuance leaf that doesn't belong to the queried asset group.
+	IssuanceGroupMismatch
+)
+
+// String returns a human-readable string for the discrepancy type.
+func (t IssuanceDiscrepancyType) String() string {
+	switch t {
+	case IssuanceUnknown:
+		return "unknown_issuance"
+
+	case IssuanceMissing:
+		return "missing_issuance"
+
+	case IssuanceAmountMismatch:
+		return "amount_mismatch"
+
+	case IssuanceGroupMismatch:
+		return "group_mismatch"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// IssuanceDiscrepancy describes a single difference between the locally
+// observed issuances of an asset group and the ones reported by a universe.
+type IssuanceDiscrepancy struct {
+	// Type is the type of the discrepancy.
+	Type IssuanceDiscrepancyType
+
+	// AssetID is the ID of the asset the discrepancy was found for.
+	AssetID asset.ID
+
+	// LocalAmount is the total amount issued for the asset ID according
+	// to the local view.
+	LocalAmount uint64
+
+	// UniverseAmount is the total amount issued for the asset ID according
+	// to the universe.
+	UniverseAmount uint64
+}
+
+// String returns a human-readable description of the discrepancy.
+func (d IssuanceDiscrepancy) String() string {
+	return fmt.Sprintf("%v: asset_id=%v, local_amount=%d, "+
+		"universe_amount=%d", d.Type, d.AssetID, d.LocalAmount,
+		d.UniverseAmount)
+}
+
+// IssuanceReport is the result of cross-checking the locally observed
+// issuances of an asset group against the issuance data of a universe.
+type IssuanceReport struct {
+	// GroupKey is the group key of the asset group that was checked.
+	GroupKey *btcec.PublicKey
+
+	// LocalSupply is the total amount issued in the group according to
+	// the local view.
+	LocalSupply uint64
+
+	// UniverseSupply is the total amount issued in the group according to
+	// the universe.
+	UniverseSupply uint64
+
+	// Discrepancies is the list of all discrepancies found, sorted by
+	// asset ID.
+	Discrepancies []IssuanceDiscrepancy
+}
+
+// HasDiscrepancies returns true if any discrepancy was found.
+func (r *IssuanceReport) HasDiscrepancies() bool {
+	return len(r.Discrepancies) > 0
+}
+
+// HasUnknownIssuance returns true if the universe reports any issuance that
+// wasn't observed locally, which may indicate unexpected inflation.
+func (r *IssuanceReport) HasUnknownIssuance() bool {
+	for _, d := range r.Discrepancies {
+		switch d.Type {
+		case IssuanceUnknown, IssuanceGroupMismatch:
+			return true
+		}
+	}
+
+	return false
+}
+
+// IssuanceLeafFetcher is used to fetch the issuance leaves of a universe. It
+// is satisfied by the local multiverse archive as well as by universe clients
+// that query a remote universe server, such as the DiffEngine.
+type IssuanceLeafFetcher interface {
+	// UniverseLeafKeys returns all the keys inserted in the universe.
+	UniverseLeafKeys(ctx context.Context,
+		q UniverseLeafKeysQuery) ([]LeafKey, error)
+
+	// FetchProofLeaf returns the proof leaves for the target leaf key of
+	// the given universe.
+	FetchProofLeaf(ctx context.Context, id Identifier,
+		key LeafKey) ([]*Proof, error)
+}
+
+// CheckGroupIssuance fetches all issuance leaves of the given asset group from
+// the universe and cross-checks them against the given locally observed
+// genesis assets. Any discrepancy is logged as a warning and returned as part
+// of the report. A non-nil error is only returned if the check itself failed.
+func CheckGroupIssuance(ctx context.Context, fetcher IssuanceLeafFetcher,
+	groupKey *btcec.PublicKey,
+	localAssets []*asset.Asset) (*IssuanceReport, error) {
+
+	if groupKey == nil {
+		return nil, fmt.Errorf("group key must be set")
+	}
+
+	leaves, err := fetchGroupIssuanceLeaves(ctx, fetcher, groupKey)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := CompareGroupIssuance(groupKey, localAssets, leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range report.Discrepancies {
+		log.Warnf("Issuance discrepancy for group %x: %v",
+			groupKey.SerializeCompressed(), d)
+	}
+
+	return report, nil
+}
+
+// fetchGroupIssuanceLeaves fetches all issuance leaves of the given asset group
+// from the universe.
+func fetchGroupIssuanceLeaves(ctx context.Context, fetcher IssuanceLeafFetcher,
+	groupKey *btcec.PublicKey) ([]*Leaf, error) {
+
+	uniID := Identifier{
+		GroupKey:  groupKey,
+		ProofType: ProofTypeIssuance,
+	}
+
+	// A proof leaf can only be fetched by its full key, so we first
+	// enumerate all leaf keys of the group's issuance universe.
+	var (
+		leaves []*Leaf
+		offset int32
+	)
+	for {
+		leafKeys, err := fetcher.UniverseLeafKeys(
+			ctx, UniverseLeafKeysQuery{
+				Id:            uniID,
+				Offset:        offset,
+				Limit:         defaultPageSize,
+				SortDirection: SortAscending,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch issuance leaf "+
+				"keys for %v: %w", uniID.StringForLog(), err)
+		}
+
+		if len(leafKeys) == 0 {
+			break
+		}
+
+		for _, leafKey := range leafKeys {
+			uniProofs, err := fetcher.FetchProofLeaf(
+				ctx, uniID, leafKey,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("unable to fetch "+
+					"issuance leaf %x for %v: %w",
+					leafKey.UniverseKey(),
+					uniID.StringForLog(), err)
+			}
+
+			for _, uniProof := range uniProofs {
+				if uniProof.Leaf != nil {
+					leaves = append(leaves, uniProof.Leaf)
+				}
+			}
+		}
+
+		offset += defaultPageSize
+	}
+
+	return leaves, nil
+}
+
+// CompareGroupIssuance cross-checks the given locally observed genesis assets
+// of an asset group against the given universe issuance leaves of the same
+// group and returns a report of all discrepancies.
+func CompareGroupIssuance(groupKey *btcec.PublicKey, localAssets []*asset.Asset,
+	uniLeaves []*Leaf) (*IssuanceReport, error) {
+
+	if groupKey == nil {
+		return nil, fmt.Errorf("group key must be set")
+	}
+
+	report := &IssuanceReport{
+		GroupKey: groupKey,
+	}
+
+	localAmounts := make(map[asset.ID]uint64)
+	for _, a := range localAssets {
+		if !a.IsGenesisAsset() {
+			return nil, fmt.Errorf("asset %v is not a genesis "+
+				"asset", a.ID())
+		}
+		if a.GroupKey == nil ||
+			!a.GroupKey.GroupPubKey.IsEqual(groupKey) {
+
+			return nil, fmt.Errorf("asset %v is not part of the "+
+				"asset group", a.ID())
+		}
+
+		localAmounts[a.ID()] += a.Amount
+		report.LocalSupply += a.Amount
+	}
+
+	uniAmounts := make(map[asset.ID]uint64)
+	for _, leaf := range uniLeaves {
+		// Burns only reduce the supply, so they can't be a sign of
+		// inflation and aren't part of the issuance amounts.
+		if leaf.IsBurn {
+			continue
+		}
+
+		assetID := leaf.Genesis.ID()
+		if leaf.GroupKey == nil ||
+			!leaf.GroupKey.GroupPubKey.IsEqual(groupKey) {
+
+			report.Discrepancies = append(
+				report.Discrepancies, IssuanceDiscrepancy{
+					Type:           IssuanceGroupMismatch,
+					AssetID:        assetID,
+					LocalAmount:    localAmounts[assetID],
+					UniverseAmount: leaf.Amt,
+				},
+			)
+			continue
+		}
+
+		uniAmounts[assetID] += leaf.Amt
+		report.UniverseSupply += leaf.Amt
+	}
+
+	for assetID, uniAmount := range uniAmounts {
+		localAmount, ok := localAmounts[assetID]
+		switch {
+		case !ok:
+			report.Discrepancies = append(
+				report.Discrepancies, IssuanceDiscrepancy{
+					Type:           IssuanceUnknown,
+					AssetID:        assetID,
+					UniverseAmount: uniAmount,
+				},
+			)
+
+		case localAmount != uniAmount:
+			report.Discrepancies = append(
+				report.Discrepancies, IssuanceDiscrepancy{
+					Type:           IssuanceAmountMismatch,
+					AssetID:        assetID,
+					LocalAmount:    localAmount,
+					UniverseAmount: uniAmount,
+				},
+			)
+		}
+	}
+
+	for assetID, localAmount := range localAmounts {
+		if _, ok := uniAmounts[assetID]; ok {
+			continue
+		}
+
+		report.Discrepancies = append(
+			report.Discrepancies, IssuanceDiscrepancy{
+				Type:        IssuanceMissing,
+				AssetID:     assetID,
+				LocalAmount: localAmount,
+			},
+		)
+	}
+
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		a, b := report.Discrepancies[i], report.Discrepancies[j]
+		if a.AssetID != b.AssetID {
+			return a.AssetID.String() < b.AssetID.String()
+		}
+
+		return a.Type < b.Type
+	})
+
+	return report, nil
+}
+
+// A compile-time assertion to ensure that any DiffEngine, which includes the
+// RPC client of remote universe servers, can be used to fetch issuance leaves.
+var _ IssuanceLeafFetcher = (DiffEngine)(nil)
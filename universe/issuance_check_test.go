@@ -0,0 +1,159 @@
+package universe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuanceFetcher is a mock IssuanceLeafFetcher that returns a static set
+// of issuance leaves. Each leaf is keyed by the script key of its asset.
+type mockIssuanceFetcher struct {
+	leaves []*Leaf
+}
+
+// leafKey returns the leaf key of the given leaf.
+func (m *mockIssuanceFetcher) leafKey(leaf *Leaf) LeafKey {
+	return BaseLeafKey{
+		OutPoint:  leaf.Genesis.FirstPrevOut,
+		ScriptKey: &leaf.Asset.ScriptKey,
+	}
+}
+
+// UniverseLeafKeys returns the requested page of leaf keys.
+func (m *mockIssuanceFetcher) UniverseLeafKeys(_ context.Context,
+	q UniverseLeafKeysQuery) ([]LeafKey, error) {
+
+	keys := make([]LeafKey, 0, len(m.leaves))
+	for _, leaf := range m.leaves {
+		keys = append(keys, m.leafKey(leaf))
+	}
+
+	start := min(int(q.Offset), len(keys))
+	end := min(start+int(q.Limit), len(keys))
+
+	return keys[start:end], nil
+}
+
+// FetchProofLeaf returns the issuance leaf with the given key.
+func (m *mockIssuanceFetcher) FetchProofLeaf(_ context.Context, _ Identifier,
+	key LeafKey) ([]*Proof, error) {
+
+	for _, leaf := range m.leaves {
+		if m.leafKey(leaf).UniverseKey() == key.UniverseKey() {
+			return []*Proof{{Leaf: leaf}}, nil
+		}
+	}
+
+	return nil, ErrNoUniverseProofFound
+}
+
+// TestCheckGroupIssuance tests that discrepancies between the local view and
+// the universe view of the issuances of an asset group are detected.
+func TestCheckGroupIssuance(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// Create three issuances of the same group, of which only the first
+	// two are known locally.
+	groupAssets := make([]*asset.Asset, 3)
+	for idx := range groupAssets {
+		a := randGenesisAsset(t)
+		groupAssets[idx] = &a
+	}
+	groupKey := groupAssets[0].GroupKey
+	for _, a := range groupAssets {
+		a.GroupKey = groupKey
+	}
+
+	leafFromAsset := func(a *asset.Asset, amt uint64) *Leaf {
+		return &Leaf{
+			GenesisWithGroup: GenesisWithGroup{
+				Genesis:  a.Genesis,
+				GroupKey: a.GroupKey,
+			},
+			Asset: a,
+			Amt:   amt,
+		}
+	}
+
+	localAssets := groupAssets[:2]
+	fetcher := &mockIssuanceFetcher{
+		leaves: []*Leaf{
+			leafFromAsset(groupAssets[0], groupAssets[0].Amount),
+			leafFromAsset(groupAssets[1], groupAssets[1].Amount),
+		},
+	}
+
+	// If the universe reports the same issuances, there are no
+	// discrepancies.
+	report, err := CheckGroupIssuance(
+		ctx, fetcher, &groupKey.GroupPubKey, localAssets,
+	)
+	require.NoError(t, err)
+	require.False(t, report.HasDiscrepancies())
+	require.Equal(t, report.LocalSupply, report.UniverseSupply)
+
+	// An additional issuance in the universe is flagged as unknown, a
+	// differing amount as mismatch.
+	fetcher.leaves[1].Amt++
+	fetcher.leaves = append(
+		fetcher.leaves, leafFromAsset(groupAssets[2], 1000),
+	)
+
+	report, err = CheckGroupIssuance(
+		ctx, fetcher, &groupKey.GroupPubKey, localAssets,
+	)
+	require.NoError(t, err)
+	require.True(t, report.HasUnknownIssuance())
+	require.Len(t, report.Discrepancies, 2)
+
+	discrepancies := make(map[asset.ID]IssuanceDiscrepancy)
+	for _, d := range report.Discrepancies {
+		discrepancies[d.AssetID] = d
+	}
+	require.Equal(
+		t, IssuanceAmountMismatch,
+		discrepancies[groupAssets[1].ID()].Type,
+	)
+	require.Equal(
+		t, IssuanceUnknown, discrepancies[groupAssets[2].ID()].Type,
+	)
+	require.EqualValues(
+		t, 1000, discrepancies[groupAssets[2].ID()].UniverseAmount,
+	)
+
+	// An issuance that is only known locally is flagged as missing, a leaf
+	// of a different group as group mismatch.
+	otherAsset := randGenesisAsset(t)
+	fetcher.leaves = []*Leaf{
+		leafFromAsset(groupAssets[0], groupAssets[0].Amount),
+		leafFromAsset(&otherAsset, otherAsset.Amount),
+	}
+
+	report, err = CheckGroupIssuance(
+		ctx, fetcher, &groupKey.GroupPubKey, localAssets,
+	)
+	require.NoError(t, err)
+	require.True(t, report.HasUnknownIssuance())
+
+	types := make([]IssuanceDiscrepancyType, 0, 2)
+	for _, d := range report.Discrepancies {
+		types = append(types, d.Type)
+	}
+	require.ElementsMatch(
+		t, []IssuanceDiscrepancyType{
+			IssuanceMissing, IssuanceGroupMismatch,
+		}, types,
+	)
+
+	// Local assets that aren't part of the group are rejected.
+	_, err = CheckGroupIssuance(
+		ctx, fetcher, &groupKey.GroupPubKey,
+		[]*asset.Asset{&otherAsset},
+	)
+	require.ErrorContains(t, err, "not part of the asset group")
+}
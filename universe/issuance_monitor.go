@@ -0,0 +1,282 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+)
+
+const (
+	// DefaultIssuanceCheckInterval is the default interval at which the
+	// issuance monitor cross-checks the monitored asset groups.
+	DefaultIssuanceCheckInterval = time.Hour
+)
+
+// IssuanceAlertEvent is sent to the subscribers of the issuance monitor if a
+// universe server reports an issuance of a monitored asset group that wasn't
+// observed locally, which may indicate unexpected inflation.
+type IssuanceAlertEvent struct {
+	// timestamp is the time the event was created.
+	timestamp time.Time
+
+	// Server is the universe server that reported the issuance.
+	Server ServerAddr
+
+	// Report is the full report of the cross-check.
+	Report *IssuanceReport
+}
+
+// Timestamp returns the timestamp of the event.
+func (e *IssuanceAlertEvent) Timestamp() time.Time {
+	return e.timestamp
+}
+
+// A compile-time assertion to ensure that IssuanceAlertEvent implements the
+// fn.Event interface.
+var _ fn.Event = (*IssuanceAlertEvent)(nil)
+
+// IssuanceMonitorConfig houses the dependencies of the issuance monitor.
+type IssuanceMonitorConfig struct {
+	// Groups are the asset groups that are monitored.
+	Groups []*btcec.PublicKey
+
+	// CheckInterval is the interval at which the groups are checked.
+	CheckInterval time.Duration
+
+	// LocalIssuance is used to fetch the locally observed issuance leaves
+	// of the monitored groups.
+	LocalIssuance IssuanceLeafFetcher
+
+	// FederationDB is used to look up the universe servers the issuance
+	// is cross-checked with.
+	FederationDB FederationLog
+
+	// NewRemoteDiffEngine returns a new diff engine that can be used to
+	// fetch the issuance leaves of a remote universe server.
+	NewRemoteDiffEngine func(ServerAddr) (DiffEngine, error)
+}
+
+// IssuanceMonitor periodically cross-checks the locally observed issuances of
+// a set of asset groups against the issuance data reported by the universe
+// servers of the federation. If a server reports an issuance that wasn't
+// observed locally, an alert is logged and an IssuanceAlertEvent is sent to
+// all subscribers.
+type IssuanceMonitor struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	cfg *IssuanceMonitorConfig
+
+	// eventDistributor is used to distribute the alerts to subscribers.
+	eventDistributor *fn.EventDistributor[fn.Event]
+
+	// ContextGuard provides a wait group and main quit channel that can be
+	// used to create guarded contexts.
+	*fn.ContextGuard
+}
+
+// NewIssuanceMonitor creates a new issuance monitor from the given config.
+func NewIssuanceMonitor(cfg *IssuanceMonitorConfig) (*IssuanceMonitor, error) {
+	if len(cfg.Groups) == 0 {
+		return nil, fmt.Errorf("at least one group is required")
+	}
+
+	if cfg.CheckInterval <= 0 {
+		return nil, fmt.Errorf("issuance check interval must be " +
+			"positive")
+	}
+
+	return &IssuanceMonitor{
+		cfg:              cfg,
+		eventDistributor: fn.NewEventDistributor[fn.Event](),
+		ContextGuard: &fn.ContextGuard{
+			DefaultTimeout: DefaultTimeout,
+			Quit:           make(chan struct{}),
+		},
+	}, nil
+}
+
+// Start starts the issuance check loop.
+func (m *IssuanceMonitor) Start() error {
+	m.startOnce.Do(func() {
+		log.Infof("Starting issuance monitor (groups=%d)",
+			len(m.cfg.Groups))
+
+		m.Wg.Add(1)
+		go m.checkLoop()
+	})
+
+	return nil
+}
+
+// Stop stops the issuance check loop.
+func (m *IssuanceMonitor) Stop() error {
+	m.stopOnce.Do(func() {
+		log.Info("Stopping issuance monitor")
+
+		close(m.Quit)
+		m.Wg.Wait()
+	})
+
+	return nil
+}
+
+// RegisterSubscriber adds a new subscriber for receiving events.
+//
+// NOTE: This is part of the fn.EventPublisher interface.
+func (m *IssuanceMonitor) RegisterSubscriber(
+	receiver *fn.EventReceiver[fn.Event], deliverExisting bool,
+	_ bool) error {
+
+	if deliverExisting {
+		return fmt.Errorf("IssuanceMonitor does not support " +
+			"delivering existing events")
+	}
+
+	m.eventDistributor.RegisterSubscriber(receiver)
+	return nil
+}
+
+// RemoveSubscriber removes the given subscriber and also stops it from
+// processing events.
+//
+// NOTE: This is part of the fn.EventPublisher interface.
+func (m *IssuanceMonitor) RemoveSubscriber(
+	subscriber *fn.EventReceiver[fn.Event]) error {
+
+	return m.eventDistributor.RemoveSubscriber(subscriber)
+}
+
+// A compile-time assertion to ensure IssuanceMonitor implements the
+// fn.EventPublisher interface.
+var _ fn.EventPublisher[fn.Event, bool] = (*IssuanceMonitor)(nil)
+
+// checkLoop checks the monitored groups every check interval.
+//
+// NOTE: This function MUST be run as a goroutine.
+func (m *IssuanceMonitor) checkLoop() {
+	defer m.Wg.Done()
+
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.checkGroups(); err != nil {
+				log.Errorf("Unable to check group issuance: "+
+					"%v", err)
+			}
+
+		case <-m.Quit:
+			return
+		}
+	}
+}
+
+// checkGroups cross-checks the issuance of all monitored groups against all
+// universe servers of the federation.
+func (m *IssuanceMonitor) checkGroups() error {
+	ctx, cancel := m.WithCtxQuitNoTimeout()
+	defer cancel()
+
+	servers, err := m.cfg.FederationDB.UniverseServers(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to fetch universe servers: %w", err)
+	}
+
+	for _, groupKey := range m.cfg.Groups {
+		localAssets, err := m.localIssuance(ctx, groupKey)
+		if err != nil {
+			return err
+		}
+
+		// A single unreachable server shouldn't prevent us from
+		// checking the others.
+		for _, server := range servers {
+			err := m.checkServer(
+				ctx, server, groupKey, localAssets,
+			)
+			if err != nil {
+				log.Warnf("Unable to check issuance of group "+
+					"%x with server %v: %v",
+					groupKey.SerializeCompressed(),
+					server.HostStr(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// localIssuance returns the locally observed genesis assets of the given
+// group.
+func (m *IssuanceMonitor) localIssuance(ctx context.Context,
+	groupKey *btcec.PublicKey) ([]*asset.Asset, error) {
+
+	leaves, err := fetchGroupIssuanceLeaves(
+		ctx, m.cfg.LocalIssuance, groupKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch local issuance: %w",
+			err)
+	}
+
+	var localAssets []*asset.Asset
+	for _, leaf := range leaves {
+		// Burns aren't issuances, see CompareGroupIssuance.
+		if leaf.IsBurn || leaf.Asset == nil {
+			continue
+		}
+
+		localAssets = append(localAssets, leaf.Asset)
+	}
+
+	return localAssets, nil
+}
+
+// checkServer cross-checks the issuance of the given group against the given
+// universe server and sends an alert if the server reports an issuance that
+// wasn't observed locally.
+func (m *IssuanceMonitor) checkServer(ctx context.Context, server ServerAddr,
+	groupKey *btcec.PublicKey, localAssets []*asset.Asset) error {
+
+	diffEngine, err := m.cfg.NewRemoteDiffEngine(server)
+	if err != nil {
+		return fmt.Errorf("unable to connect: %w", err)
+	}
+	defer func() {
+		if err := diffEngine.Close(); err != nil {
+			log.Warnf("Unable to close diff engine: %v", err)
+		}
+	}()
+
+	report, err := CheckGroupIssuance(
+		ctx, diffEngine, groupKey, localAssets,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !report.HasUnknownIssuance() {
+		return nil
+	}
+
+	log.Errorf("Universe server %v reports unknown issuance "+
+		"for group %x (local_supply=%d, universe_supply=%d)",
+		server.HostStr(), groupKey.SerializeCompressed(),
+		report.LocalSupply, report.UniverseSupply)
+
+	m.eventDistributor.NotifySubscribers(&IssuanceAlertEvent{
+		timestamp: time.Now().UTC(),
+		Server:    server,
+		Report:    report,
+	})
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightninglabs/taproot-assets/asset"
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuanceDiffEngine is a DiffEngine that only serves issuance leaves.
+type mockIssuanceDiffEngine struct {
+	*mockIssuanceFetcher
+}
+
+// RootNode isn't used by the issuance monitor.
+func (m *mockIssuanceDiffEngine) RootNode(context.Context,
+	Identifier) (Root, error) {
+
+	return Root{}, fmt.Errorf("not implemented")
+}
+
+// RootNodes isn't used by the issuance monitor.
+func (m *mockIssuanceDiffEngine) RootNodes(context.Context,
+	RootNodesQuery) ([]Root, error) {
+
+	return nil, fmt.Errorf("not implemented")
+}
+
+// Close does nothing.
+func (m *mockIssuanceDiffEngine) Close() error {
+	return nil
+}
+
+// mockFederationServers is a FederationLog that only returns a static set of
+// servers.
+type mockFederationServers struct {
+	FederationLog
+
+	servers []ServerAddr
+}
+
+// UniverseServers returns the static set of servers.
+func (m *mockFederationServers) UniverseServers(
+	context.Context) ([]ServerAddr, error) {
+
+	return m.servers, nil
+}
+
+// TestIssuanceMonitor tests that the issuance monitor sends an alert for each
+// universe server that reports an issuance that wasn't observed locally.
+func TestIssuanceMonitor(t *testing.T) {
+	t.Parallel()
+
+	groupAssets := make([]*asset.Asset, 2)
+	for idx := range groupAssets {
+		a := randGenesisAsset(t)
+		groupAssets[idx] = &a
+	}
+	groupKey := groupAssets[0].GroupKey
+	for _, a := range groupAssets {
+		a.GroupKey = groupKey
+	}
+
+	leafFromAsset := func(a *asset.Asset) *Leaf {
+		return &Leaf{
+			GenesisWithGroup: GenesisWithGroup{
+				Genesis:  a.Genesis,
+				GroupKey: a.GroupKey,
+			},
+			Asset: a,
+			Amt:   a.Amount,
+		}
+	}
+
+	// Only the first issuance was observed locally. The honest server
+	// agrees with that, while the other one reports a second issuance.
+	local := &mockIssuanceFetcher{
+		leaves: []*Leaf{leafFromAsset(groupAssets[0])},
+	}
+	honestServer := NewServerAddr(1, "honest:10029")
+	inflatingServer := NewServerAddr(2, "inflating:10029")
+	remotes := map[string]*mockIssuanceFetcher{
+		honestServer.HostStr(): {
+			leaves: []*Leaf{leafFromAsset(groupAssets[0])},
+		},
+		inflatingServer.HostStr(): {
+			leaves: []*Leaf{
+				leafFromAsset(groupAssets[0]),
+				leafFromAsset(groupAssets[1]),
+			},
+		},
+	}
+
+	// A monitor needs at least one group and a positive interval.
+	_, err := NewIssuanceMonitor(&IssuanceMonitorConfig{
+		CheckInterval: time.Hour,
+	})
+	require.Error(t, err)
+	_, err = NewIssuanceMonitor(&IssuanceMonitorConfig{
+		Groups: []*btcec.PublicKey{&groupKey.GroupPubKey},
+	})
+	require.Error(t, err)
+
+	monitor, err := NewIssuanceMonitor(&IssuanceMonitorConfig{
+		Groups:        []*btcec.PublicKey{&groupKey.GroupPubKey},
+		CheckInterval: time.Hour,
+		LocalIssuance: local,
+		FederationDB: &mockFederationServers{
+			servers: []ServerAddr{honestServer, inflatingServer},
+		},
+		NewRemoteDiffEngine: func(addr ServerAddr) (DiffEngine, error) {
+			return &mockIssuanceDiffEngine{
+				mockIssuanceFetcher: remotes[addr.HostStr()],
+			}, nil
+		},
+	})
+	require.NoError(t, err)
+
+	events := fn.NewEventReceiver[fn.Event](fn.DefaultQueueSize)
+	require.NoError(t, monitor.RegisterSubscriber(events, false, false))
+
+	require.NoError(t, monitor.checkGroups())
+
+	select {
+	case event := <-events.NewItemCreated.ChanOut():
+		alert, ok := event.(*IssuanceAlertEvent)
+		require.True(t, ok)
+		require.Equal(
+			t, inflatingServer.HostStr(), alert.Server.HostStr(),
+		)
+		require.True(t, alert.Report.HasUnknownIssuance())
+		require.EqualValues(
+			t, groupAssets[0].Amount, alert.Report.LocalSupply,
+		)
+
+	case <-time.After(time.Second):
+		t.Fatalf("no alert received")
+	}
+
+	// The honest server doesn't cause an alert.
+	select {
+	case event := <-events.NewItemCreated.ChanOut():
+		t.Fatalf("unexpected event: %v", event)
+
+	case <-time.After(50 * time.Millisecond):
+	}
+}
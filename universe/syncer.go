@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -45,8 +46,28 @@ type SimpleSyncCfg struct {
 
 	// SyncBatchSize is the number of items to sync in a single batch.
 	SyncBatchSize int
+
+	// EventSequences is an optional store used to persist the sequence
+	// numbers of the sync diff events, so they keep increasing across
+	// restarts.
+	EventSequences fn.SequenceStore
+
+	// EventStartSeq is the sequence number of the first sync diff event
+	// published by the syncer. It should be loaded from EventSequences on
+	// startup.
+	EventStartSeq uint64
 }
 
+const (
+	// SyncEventStream is the name of the event stream of the sync diff
+	// events, under which its sequence numbers are persisted.
+	SyncEventStream = "universe_sync_diffs"
+
+	// syncEventReplaySize is the number of sync diff events that are kept
+	// for subscribers that want to catch up on missed events.
+	syncEventReplaySize = 100
+)
+
 // SyncDiffEvent is sent to subscribers after a universe sync completes,
 // if a diff between the local and remote universe was detected.
 type SyncDiffEvent struct {
@@ -78,6 +99,15 @@ type SimpleSyncer struct {
 
 	// eventDistributor is used to distribute sync events to subscribers.
 	eventDistributor *fn.EventDistributor[fn.Event]
+
+	// replayDistributor distributes the sync events together with their
+	// sequence number, so subscribers can catch up on events they missed
+	// since this syncer was started.
+	replayDistributor *fn.ReplayEventDistributor[fn.Event]
+
+	// eventMtx makes sure the events are persisted and published in
+	// order.
+	eventMtx sync.Mutex
 }
 
 // NewSimpleSyncer creates a new SimpleSyncer instance.
@@ -85,6 +115,9 @@ func NewSimpleSyncer(cfg SimpleSyncCfg) *SimpleSyncer {
 	return &SimpleSyncer{
 		cfg:              cfg,
 		eventDistributor: fn.NewEventDistributor[fn.Event](),
+		replayDistributor: fn.NewReplayEventDistributor[fn.Event](
+			syncEventReplaySize, cfg.EventStartSeq,
+		),
 	}
 }
 
@@ -113,6 +146,64 @@ func (s *SimpleSyncer) RemoveSubscriber(
 	return s.eventDistributor.RemoveSubscriber(subscriber)
 }
 
+// RegisterSequencedSubscriber adds a new subscriber for receiving sync events
+// together with their sequence number. If fromSeq is set, the buffered events
+// starting at that sequence number are delivered before any new events. If
+// those events are no longer buffered, fn.ErrEventsEvicted is returned. The
+// events aren't persisted, so this is always the case for events published
+// before the last restart.
+func (s *SimpleSyncer) RegisterSequencedSubscriber(
+	receiver *fn.EventReceiver[fn.SequencedEvent[fn.Event]],
+	fromSeq fn.Option[uint64]) error {
+
+	return s.replayDistributor.RegisterSubscriber(receiver, fromSeq)
+}
+
+// RemoveSequencedSubscriber removes the given sequenced subscriber and also
+// stops it from processing events.
+func (s *SimpleSyncer) RemoveSequencedSubscriber(
+	subscriber *fn.EventReceiver[fn.SequencedEvent[fn.Event]]) error {
+
+	return s.replayDistributor.RemoveSubscriber(subscriber)
+}
+
+// publishEvents sends the given events to all subscribers. The sequence
+// numbers of the events are persisted before they are handed out to the
+// sequenced subscribers. If that fails, the events are only sent to the
+// subscribers that don't track sequence numbers and an error is returned.
+func (s *SimpleSyncer) publishEvents(ctx context.Context,
+	events []fn.Event) error {
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.eventMtx.Lock()
+	defer s.eventMtx.Unlock()
+
+	s.eventDistributor.NotifySubscribers(events...)
+
+	// We persist the sequence number before publishing the events, so a
+	// sequence number is never handed out twice, even if we shut down
+	// right after publishing. If we can't persist it, we don't assign any
+	// sequence numbers to the events, as they could be re-used for
+	// different events after a restart.
+	if s.cfg.EventSequences != nil {
+		nextSeq := s.replayDistributor.NextSeq() + uint64(len(events))
+		err := s.cfg.EventSequences.StoreNextSeq(
+			ctx, SyncEventStream, nextSeq,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to store sync event "+
+				"sequence: %w", err)
+		}
+	}
+
+	s.replayDistributor.NotifySubscribers(events...)
+
+	return nil
+}
+
 // Ensure SimpleSyncer implements the fn.EventPublisher interface.
 var _ fn.EventPublisher[fn.Event, bool] = (*SimpleSyncer)(nil)
 
@@ -632,7 +723,11 @@ func (s *SimpleSyncer) SyncUniverse(ctx context.Context, host ServerAddr,
 		}
 	}
 
-	s.eventDistributor.NotifySubscribers(events...)
+	err = s.publishEvents(ctx, events)
+	if err != nil {
+		return nil, fmt.Errorf("unable to publish sync events: %w",
+			err)
+	}
 
 	return diffs, nil
 }
@@ -0,0 +1,143 @@
+package universe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightninglabs/taproot-assets/fn"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSequenceStore is an in-memory implementation of fn.SequenceStore.
+type mockSequenceStore struct {
+	sync.Mutex
+
+	seqs map[string]uint64
+
+	// storeErr is returned by StoreNextSeq if set.
+	storeErr error
+}
+
+// FetchNextSeq returns the stored sequence number of the given stream.
+func (m *mockSequenceStore) FetchNextSeq(_ context.Context,
+	stream string) (uint64, error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	return m.seqs[stream], nil
+}
+
+// StoreNextSeq stores the sequence number of the given stream.
+func (m *mockSequenceStore) StoreNextSeq(_ context.Context, stream string,
+	nextSeq uint64) error {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.storeErr != nil {
+		return m.storeErr
+	}
+
+	m.seqs[stream] = nextSeq
+
+	return nil
+}
+
+// TestSyncerSequencedEvents tests that the sync diff events are numbered, can
+// be replayed and continue their sequence after a restart.
+func TestSyncerSequencedEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := &mockSequenceStore{
+		seqs: make(map[string]uint64),
+	}
+	newSyncer := func() *SimpleSyncer {
+		startSeq, err := store.FetchNextSeq(ctx, SyncEventStream)
+		require.NoError(t, err)
+
+		return NewSimpleSyncer(SimpleSyncCfg{
+			EventSequences: store,
+			EventStartSeq:  startSeq,
+		})
+	}
+	newEvents := func(num int) []fn.Event {
+		events := make([]fn.Event, num)
+		for idx := range events {
+			events[idx] = &SyncDiffEvent{
+				timestamp: time.Now(),
+			}
+		}
+
+		return events
+	}
+	receiveSeqs := func(t *testing.T,
+		sub *fn.EventReceiver[fn.SequencedEvent[fn.Event]],
+		num int) []uint64 {
+
+		seqs := make([]uint64, 0, num)
+		for range num {
+			select {
+			case event := <-sub.NewItemCreated.ChanOut():
+				seqs = append(seqs, event.Seq)
+
+			case <-time.After(time.Second):
+				t.Fatalf("no event received")
+			}
+		}
+
+		return seqs
+	}
+
+	syncer := newSyncer()
+	require.NoError(t, syncer.publishEvents(ctx, newEvents(3)))
+	require.EqualValues(t, 3, store.seqs[SyncEventStream])
+
+	// A subscriber that missed the first event can catch up from the
+	// replay buffer.
+	sub := fn.NewEventReceiver[fn.SequencedEvent[fn.Event]](
+		fn.DefaultQueueSize,
+	)
+	require.NoError(t, syncer.RegisterSequencedSubscriber(
+		sub, fn.Some[uint64](1),
+	))
+	require.Equal(t, []uint64{1, 2}, receiveSeqs(t, sub, 2))
+	require.NoError(t, syncer.RemoveSequencedSubscriber(sub))
+
+	// After a restart, the sequence continues where it stopped. The events
+	// from before the restart can't be replayed anymore.
+	syncer = newSyncer()
+	sub = fn.NewEventReceiver[fn.SequencedEvent[fn.Event]](
+		fn.DefaultQueueSize,
+	)
+	require.ErrorIs(t, syncer.RegisterSequencedSubscriber(
+		sub, fn.Some[uint64](1),
+	), fn.ErrEventsEvicted)
+	require.NoError(t, syncer.RegisterSequencedSubscriber(
+		sub, fn.None[uint64](),
+	))
+	require.NoError(t, syncer.publishEvents(ctx, newEvents(2)))
+	require.Equal(t, []uint64{3, 4}, receiveSeqs(t, sub, 2))
+	require.EqualValues(t, 5, store.seqs[SyncEventStream])
+
+	// If the sequence can't be stored, the events don't get a sequence
+	// number, so the next stored event continues the sequence.
+	store.storeErr = errors.New("store failed")
+	require.Error(t, syncer.publishEvents(ctx, newEvents(1)))
+
+	select {
+	case event := <-sub.NewItemCreated.ChanOut():
+		t.Fatalf("unexpected event with seq %d", event.Seq)
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	store.storeErr = nil
+	require.NoError(t, syncer.publishEvents(ctx, newEvents(1)))
+	require.Equal(t, []uint64{5}, receiveSeqs(t, sub, 1))
+	require.EqualValues(t, 6, store.seqs[SyncEventStream])
+}
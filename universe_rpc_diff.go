@@ -21,10 +21,10 @@ type RpcUniverseDiff struct {
 
 // NewRpcUniverseDiff creates a new RpcUniverseDiff instance that dials out to
 // the target remote universe server address.
-func NewRpcUniverseDiff(
-	serverAddr universe.ServerAddr) (universe.DiffEngine, error) {
+func NewRpcUniverseDiff(serverAddr universe.ServerAddr,
+	opts ...UniverseConnOption) (universe.DiffEngine, error) {
 
-	conn, err := ConnectUniverse(serverAddr)
+	conn, err := ConnectUniverse(serverAddr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to universe RPC "+
 			"server: %w", err)
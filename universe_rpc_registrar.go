@@ -3,12 +3,15 @@ package taprootassets
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/lightninglabs/taproot-assets/fn"
 	"github.com/lightninglabs/taproot-assets/mssmt"
+	"github.com/lightninglabs/taproot-assets/proof"
 	unirpc "github.com/lightninglabs/taproot-assets/taprpc/universerpc"
 	"github.com/lightninglabs/taproot-assets/universe"
 	"google.golang.org/grpc"
@@ -23,10 +26,10 @@ type RpcUniverseRegistrar struct {
 
 // NewRpcUniverseRegistrar creates a new RpcUniverseRegistrar instance that
 // dials out to the target remote universe server address.
-func NewRpcUniverseRegistrar(
-	serverAddr universe.ServerAddr) (universe.Registrar, error) {
+func NewRpcUniverseRegistrar(serverAddr universe.ServerAddr,
+	opts ...UniverseConnOption) (universe.Registrar, error) {
 
-	conn, err := ConnectUniverse(serverAddr)
+	conn, err := ConnectUniverse(serverAddr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to universe RPC "+
 			"server: %w", err)
@@ -133,7 +136,7 @@ var _ universe.Registrar = (*RpcUniverseRegistrar)(nil)
 // CheckFederationServer attempts to connect to the target server and ensure
 // that it is a valid federation server that isn't the local daemon.
 func CheckFederationServer(localRuntimeID int64, connectTimeout time.Duration,
-	server universe.ServerAddr) error {
+	server universe.ServerAddr, opts ...UniverseConnOption) error {
 
 	srvrLog.Debugf("Attempting to connect to federation server %v",
 		server.HostStr())
@@ -148,7 +151,7 @@ func CheckFederationServer(localRuntimeID int64, connectTimeout time.Duration,
 	srvrLog.Debugf("Resolved federation server address: %v",
 		resolvedAddr.String())
 
-	conn, err := ConnectUniverse(server)
+	conn, err := ConnectUniverse(server, opts...)
 	if err != nil {
 		return fmt.Errorf("error connecting to server %v: %w",
 			server.HostStr(), err)
@@ -180,15 +183,70 @@ type universeClientConn struct {
 	unirpc.UniverseClient
 }
 
+// UniverseConnOption is a functional option that modifies the way we connect
+// to a remote Universe server.
+type UniverseConnOption func(*universeConnOptions)
+
+// universeConnOptions houses the options for connecting to a remote Universe
+// server.
+type universeConnOptions struct {
+	// pinnedSPKIs is the set of SHA-256 hashes of the DER encoded
+	// SubjectPublicKeyInfo of the certificates we accept. If empty, any
+	// certificate is accepted.
+	pinnedSPKIs [][sha256.Size]byte
+}
+
+// WithPinnedSPKIs is a functional option that only allows connections to
+// Universe servers that present a certificate with a public key that matches
+// one of the given SHA-256 SubjectPublicKeyInfo hashes. This protects the
+// connection against MITM attacks, as the certificates of Universe servers are
+// commonly self-signed and therefore not verified otherwise.
+func WithPinnedSPKIs(pins ...[sha256.Size]byte) UniverseConnOption {
+	return func(o *universeConnOptions) {
+		o.pinnedSPKIs = append(o.pinnedSPKIs, pins...)
+	}
+}
+
+// ParseSPKIPin parses a hex encoded SHA-256 hash of a DER encoded
+// SubjectPublicKeyInfo.
+func ParseSPKIPin(pin string) ([sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+
+	pinBytes, err := hex.DecodeString(pin)
+	if err != nil {
+		return hash, fmt.Errorf("unable to decode SPKI pin: %w", err)
+	}
+
+	if len(pinBytes) != sha256.Size {
+		return hash, fmt.Errorf("invalid SPKI pin length %d, expected "+
+			"%d", len(pinBytes), sha256.Size)
+	}
+
+	copy(hash[:], pinBytes)
+
+	return hash, nil
+}
+
 // ConnectUniverse connects to a remote Universe server using the provided
 // server address.
-func ConnectUniverse(
-	serverAddr universe.ServerAddr) (*universeClientConn, error) {
+func ConnectUniverse(serverAddr universe.ServerAddr,
+	connOpts ...UniverseConnOption) (*universeClientConn, error) {
+
+	var options universeConnOptions
+	for _, opt := range connOpts {
+		opt(&options)
+	}
 
 	uniAddr := serverAddr.HostStr()
-	creds := credentials.NewTLS(&tls.Config{
+	tlsConfig := &tls.Config{
 		InsecureSkipVerify: true,
-	})
+	}
+	if len(options.pinnedSPKIs) > 0 {
+		tlsConfig.VerifyPeerCertificate = proof.VerifyPinnedSPKI(
+			options.pinnedSPKIs,
+		)
+	}
+	creds := credentials.NewTLS(tlsConfig)
 
 	// Create a dial options array.
 	opts := []grpc.DialOption{